@@ -2,8 +2,10 @@ package client
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -73,6 +75,39 @@ func HandleResponse(c *Client, resp *http.Response, pretty bool) {
 	os.Exit(exitStatus)
 }
 
+// HandleEventStream prints the Server-Sent Events streamed in the response body to STDOUT as they
+// arrive. It delegates to HandleResponse if the response status code is not 2xx.
+func HandleEventStream(c *Client, resp *http.Response, pretty bool) {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		HandleResponse(c, resp, pretty)
+		return
+	}
+	defer resp.Body.Close()
+	r := NewEventReader(resp.Body)
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read event: %s", err)
+			os.Exit(-1)
+		}
+		out := ev.Data
+		if pretty {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, ev.Data, "", "    "); err == nil {
+				out = buf.Bytes()
+			}
+		}
+		if ev.Name != "message" {
+			fmt.Printf("<< %s: %s\n", ev.Name, out)
+		} else {
+			fmt.Printf("<< %s\n", out)
+		}
+	}
+}
+
 // WSWrite sends STDIN lines to a websocket server.
 func WSWrite(ws *websocket.Conn) {
 	scanner := bufio.NewScanner(os.Stdin)
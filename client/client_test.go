@@ -2,6 +2,8 @@ package client_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 
 	"github.com/goadesign/goa/client"
 
@@ -46,3 +48,26 @@ var _ = Describe("client", func() {
 		})
 	})
 })
+
+var _ = Describe("SetIdempotencyKey", func() {
+	var req *http.Request
+
+	BeforeEach(func() {
+		req = httptest.NewRequest("POST", "/", nil)
+	})
+
+	It("uses the context key", func() {
+		ctx := client.SetContextIdempotencyKey(context.Background(), "key")
+		client.SetIdempotencyKey(ctx, req)
+		Expect(req.Header.Get("Idempotency-Key")).To(Equal("key"))
+	})
+
+	It("generates a new key", func() {
+		client.SetIdempotencyKey(context.Background(), req)
+		key := req.Header.Get("Idempotency-Key")
+		Expect(key).NotTo(BeEmpty())
+		other := httptest.NewRequest("POST", "/", nil)
+		client.SetIdempotencyKey(context.Background(), other)
+		Expect(other.Header.Get("Idempotency-Key")).NotTo(Equal(key))
+	})
+})
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+// RequestOption modifies the requests built by the generated clients of actions that support
+// conditional requests.
+type RequestOption func(*http.Request)
+
+// IfMatch sets the If-Match header so that the request is only processed if the current entity
+// tag of the resource is one of etags. Servers respond with 412 Precondition Failed otherwise,
+// which makes it possible to implement optimistic locking. etags may be given with or without the
+// surrounding double quotes, "*" matches any existing resource.
+func IfMatch(etags ...string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-Match", joinETags(etags))
+	}
+}
+
+// IfNoneMatch sets the If-None-Match header so that the server responds with 304 Not Modified to
+// GET and HEAD requests if the current entity tag of the resource is one of etags.
+func IfNoneMatch(etags ...string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-None-Match", joinETags(etags))
+	}
+}
+
+// IfModifiedSince sets the If-Modified-Since header so that the server responds with 304 Not
+// Modified to GET and HEAD requests if the resource was not modified since t.
+func IfModifiedSince(t time.Time) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+	}
+}
+
+// IfUnmodifiedSince sets the If-Unmodified-Since header so that the request is only processed if
+// the resource was not modified since t.
+func IfUnmodifiedSince(t time.Time) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-Unmodified-Since", t.UTC().Format(http.TimeFormat))
+	}
+}
+
+// joinETags formats the given entity tags into a conditional header value.
+func joinETags(etags []string) string {
+	formatted := make([]string, len(etags))
+	for i, etag := range etags {
+		if etag == "*" {
+			formatted[i] = etag
+			continue
+		}
+		formatted[i] = goa.FormatETag(etag)
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,26 @@
+package client_test
+
+import (
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa/client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestOption", func() {
+	It("sets the conditional headers", func() {
+		req := httptest.NewRequest("PATCH", "/", nil)
+		t := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		client.IfMatch("v1", `W/"v2"`)(req)
+		client.IfNoneMatch("*")(req)
+		client.IfModifiedSince(t)(req)
+		client.IfUnmodifiedSince(t)(req)
+		Expect(req.Header.Get("If-Match")).To(Equal(`"v1", W/"v2"`))
+		Expect(req.Header.Get("If-None-Match")).To(Equal("*"))
+		Expect(req.Header.Get("If-Modified-Since")).To(Equal("Sat, 02 Jan 2016 03:04:05 GMT"))
+		Expect(req.Header.Get("If-Unmodified-Since")).To(Equal("Sat, 02 Jan 2016 03:04:05 GMT"))
+	})
+})
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goadesign/goa/uuid"
+)
+
+// idempotencyKey is the context key used to store the idempotency key value.
+const idempotencyKey clientKey = 2
+
+// ContextIdempotencyKey extracts the idempotency key from the context.
+func ContextIdempotencyKey(ctx context.Context) string {
+	if key := ctx.Value(idempotencyKey); key != nil {
+		return key.(string)
+	}
+	return ""
+}
+
+// SetContextIdempotencyKey sets the idempotency key sent by the generated clients in the given
+// context and returns a new context. Use the same key when retrying a request so that the service
+// does not process it twice.
+func SetContextIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey, key)
+}
+
+// SetIdempotencyKey sets the Idempotency-Key header of req to the key held by ctx or to a new
+// random key if ctx does not hold one. Requests that already have the header are left unchanged.
+func SetIdempotencyKey(ctx context.Context, req *http.Request) {
+	if req.Header.Get("Idempotency-Key") != "" {
+		return
+	}
+	key := ContextIdempotencyKey(ctx)
+	if key == "" {
+		key = uuid.NewV4().String()
+	}
+	req.Header.Set("Idempotency-Key", key)
+}
@@ -0,0 +1,275 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenExpiryDelta is the default duration before the expiry of a token at which the
+// OAuth2 token sources retrieve a new token.
+var DefaultTokenExpiryDelta = 10 * time.Second
+
+// errTokenFetchPanicked is the error returned to the callers waiting on a token request that
+// panicked.
+var errTokenFetchPanicked = errors.New("oauth2: token request panicked")
+
+type (
+	// ClientCredentialsTokenSource is a token source that retrieves access tokens from an OAuth2
+	// token endpoint using the client credentials grant (RFC 6749 section 4.4). It caches the
+	// token until shortly before it expires. Concurrent calls to Token share the same token
+	// request. The token source must not be copied after first use.
+	ClientCredentialsTokenSource struct {
+		// TokenURL is the URL of the token endpoint.
+		TokenURL string
+		// ClientID is the client identifier.
+		ClientID string
+		// ClientSecret is the client secret.
+		ClientSecret string
+		// Scopes lists the requested scopes, optional.
+		Scopes []string
+		// Client is the HTTP client used to call the token endpoint, defaults to
+		// http.DefaultClient.
+		Client *http.Client
+		// ExpiryDelta is the duration before the expiry of the token at which a new token is
+		// retrieved, defaults to DefaultTokenExpiryDelta. It is capped to half the lifetime of
+		// the token.
+		ExpiryDelta time.Duration
+
+		cache tokenCache
+	}
+
+	// RefreshTokenSource is a token source that retrieves access tokens from an OAuth2 token
+	// endpoint using the refresh token grant (RFC 6749 section 6). It caches the token until
+	// shortly before it expires and uses the new refresh token if the endpoint rotates it.
+	// Concurrent calls to Token share the same token request. The token source must not be
+	// copied after first use.
+	RefreshTokenSource struct {
+		// TokenURL is the URL of the token endpoint.
+		TokenURL string
+		// ClientID is the client identifier, optional for public clients.
+		ClientID string
+		// ClientSecret is the client secret, optional for public clients.
+		ClientSecret string
+		// RefreshToken is the initial refresh token.
+		RefreshToken string
+		// Scopes lists the requested scopes, optional.
+		Scopes []string
+		// Client is the HTTP client used to call the token endpoint, defaults to
+		// http.DefaultClient.
+		Client *http.Client
+		// ExpiryDelta is the duration before the expiry of the token at which a new token is
+		// retrieved, defaults to DefaultTokenExpiryDelta. It is capped to half the lifetime of
+		// the token.
+		ExpiryDelta time.Duration
+
+		cache        tokenCache
+		refreshToken string // Latest refresh token, protected by the cache single flight
+	}
+
+	// OAuth2Token is an access token returned by an OAuth2 token endpoint.
+	OAuth2Token struct {
+		// AccessToken is the token used to sign requests.
+		AccessToken string `json:"access_token"`
+		// TokenType is the token type, e.g. "Bearer".
+		TokenType string `json:"token_type"`
+		// RefreshToken is the refresh token returned with the access token if any.
+		RefreshToken string `json:"refresh_token,omitempty"`
+		// ExpiresIn is the lifetime of the access token in seconds.
+		ExpiresIn int64 `json:"expires_in,omitempty"`
+		// Expiry is the time the access token expires, zero if it does not expire.
+		Expiry time.Time `json:"-"`
+	}
+
+	// TokenError is the error returned by the OAuth2 token sources when the token endpoint
+	// responds with an error (RFC 6749 section 5.2).
+	TokenError struct {
+		// StatusCode is the HTTP status code of the response.
+		StatusCode int
+		// Code is the OAuth2 error code, e.g. "invalid_grant".
+		Code string `json:"error"`
+		// Description is the human readable error description.
+		Description string `json:"error_description"`
+	}
+
+	// tokenCache caches a token and makes sure that concurrent callers share a single request
+	// when the token must be renewed.
+	tokenCache struct {
+		lock     sync.Mutex
+		token    *OAuth2Token
+		inflight *tokenCall
+	}
+
+	// tokenCall is an in-flight token request.
+	tokenCall struct {
+		done  chan struct{}
+		token *OAuth2Token
+		err   error
+	}
+)
+
+// Token returns the cached access token or retrieves a new one if it is about to expire.
+func (s *ClientCredentialsTokenSource) Token() (Token, error) {
+	return s.cache.get(s.ExpiryDelta, func() (*OAuth2Token, error) {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		if len(s.Scopes) > 0 {
+			form.Set("scope", strings.Join(s.Scopes, " "))
+		}
+		return requestToken(s.Client, s.TokenURL, s.ClientID, s.ClientSecret, form)
+	})
+}
+
+// Token returns the cached access token or retrieves a new one if it is about to expire.
+func (s *RefreshTokenSource) Token() (Token, error) {
+	return s.cache.get(s.ExpiryDelta, func() (*OAuth2Token, error) {
+		if s.refreshToken == "" {
+			s.refreshToken = s.RefreshToken
+		}
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {s.refreshToken}}
+		if len(s.Scopes) > 0 {
+			form.Set("scope", strings.Join(s.Scopes, " "))
+		}
+		token, err := requestToken(s.Client, s.TokenURL, s.ClientID, s.ClientSecret, form)
+		if err != nil {
+			return nil, err
+		}
+		if token.RefreshToken != "" {
+			s.refreshToken = token.RefreshToken
+		}
+		return token, nil
+	})
+}
+
+// SetAuthHeader sets the Authorization header to r.
+func (t *OAuth2Token) SetAuthHeader(r *http.Request) {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	r.Header.Set("Authorization", typ+" "+t.AccessToken)
+}
+
+// Valid reports whether Token can be used to properly sign requests.
+func (t *OAuth2Token) Valid() bool {
+	return t.validFor(0)
+}
+
+// validFor reports whether the token is still valid after the given duration.
+func (t *OAuth2Token) validFor(d time.Duration) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(d).Before(t.Expiry)
+}
+
+// renewDelta caps delta to half the lifetime of the token so that tokens that live shorter than
+// the expiry delta are not retrieved again on every call.
+func (t *OAuth2Token) renewDelta(delta time.Duration) time.Duration {
+	if t == nil || t.ExpiresIn <= 0 {
+		return delta
+	}
+	if half := time.Duration(t.ExpiresIn) * time.Second / 2; delta > half {
+		return half
+	}
+	return delta
+}
+
+// Error returns the error message.
+func (e *TokenError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("oauth2: token request failed with status %d", e.StatusCode)
+	}
+	if e.Description == "" {
+		return fmt.Sprintf("oauth2: token request failed: %s", e.Code)
+	}
+	return fmt.Sprintf("oauth2: token request failed: %s: %s", e.Code, e.Description)
+}
+
+// get returns the cached token if it is valid for at least delta (DefaultTokenExpiryDelta if
+// zero) or half its lifetime, whichever is shorter. Otherwise it calls fetch unless another
+// goroutine is already doing so in which case it waits for and returns the result of that call.
+func (c *tokenCache) get(delta time.Duration, fetch func() (*OAuth2Token, error)) (Token, error) {
+	if delta == 0 {
+		delta = DefaultTokenExpiryDelta
+	}
+	c.lock.Lock()
+	if c.token.validFor(c.token.renewDelta(delta)) {
+		token := c.token
+		c.lock.Unlock()
+		return token, nil
+	}
+	if call := c.inflight; call != nil {
+		c.lock.Unlock()
+		<-call.done
+		if call.err != nil {
+			return nil, call.err
+		}
+		return call.token, nil
+	}
+	call := &tokenCall{done: make(chan struct{}), err: errTokenFetchPanicked}
+	c.inflight = call
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		if call.err == nil {
+			c.token = call.token
+		}
+		c.inflight = nil
+		c.lock.Unlock()
+		close(call.done)
+	}()
+
+	call.token, call.err = fetch()
+	if call.err != nil {
+		return nil, call.err
+	}
+	return call.token, nil
+}
+
+// requestToken calls the token endpoint with the given form and client credentials.
+func requestToken(client *http.Client, tokenURL, clientID, clientSecret string, form url.Values) (*OAuth2Token, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{StatusCode: resp.StatusCode}
+		json.Unmarshal(body, tokenErr)
+		return nil, tokenErr
+	}
+	var token OAuth2Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oauth2: invalid token response: %s", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2: token response is missing the access token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
@@ -0,0 +1,213 @@
+package client_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa/client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OAuth2 token sources", func() {
+	var lock sync.Mutex
+	var calls int
+	var forms []map[string]string
+	var expiresIn int
+	var server *httptest.Server
+
+	callCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls
+	}
+
+	BeforeEach(func() {
+		calls = 0
+		forms = nil
+		expiresIn = 3600
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+			lock.Lock()
+			defer lock.Unlock()
+			calls++
+			r.ParseForm()
+			form := map[string]string{}
+			for k := range r.PostForm {
+				form[k] = r.PostForm.Get(k)
+			}
+			forms = append(forms, form)
+			w.Header().Set("Content-Type", "application/json")
+			if id, secret, ok := r.BasicAuth(); ok && (id != "client" || secret != "secret") {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"invalid_client","error_description":"bad credentials"}`))
+				return
+			}
+			if rt := r.PostForm.Get("refresh_token"); r.PostForm.Get("grant_type") == "refresh_token" && rt == "revoked" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":  fmt.Sprintf("token%d", calls),
+				"token_type":    "bearer",
+				"refresh_token": fmt.Sprintf("refresh%d", calls),
+				"expires_in":    expiresIn,
+			})
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("ClientCredentialsTokenSource", func() {
+		var source *client.ClientCredentialsTokenSource
+
+		BeforeEach(func() {
+			source = &client.ClientCredentialsTokenSource{
+				TokenURL:     server.URL,
+				ClientID:     "client",
+				ClientSecret: "secret",
+				Scopes:       []string{"api:read", "api:write"},
+			}
+		})
+
+		It("signs requests with the token", func() {
+			signer := &client.OAuth2Signer{TokenSource: source}
+			req := httptest.NewRequest("GET", "/", nil)
+			Expect(signer.Sign(req)).To(Succeed())
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer token1"))
+			Expect(forms[0]).To(Equal(map[string]string{"grant_type": "client_credentials", "scope": "api:read api:write"}))
+		})
+
+		It("caches the token", func() {
+			signer := &client.JWTSigner{TokenSource: source}
+			for i := 0; i < 3; i++ {
+				Expect(signer.Sign(httptest.NewRequest("GET", "/", nil))).To(Succeed())
+			}
+			Expect(callCount()).To(Equal(1))
+		})
+
+		It("retrieves a single token for concurrent callers", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					token, err := source.Token()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(token.(*client.OAuth2Token).AccessToken).To(Equal("token1"))
+				}()
+			}
+			wg.Wait()
+			Expect(callCount()).To(Equal(1))
+		})
+
+		Context("with a token that lives shorter than the expiry delta", func() {
+			BeforeEach(func() {
+				expiresIn = 1
+			})
+
+			It("caches the token for half its lifetime", func() {
+				source.Token()
+				token, err := source.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token.(*client.OAuth2Token).AccessToken).To(Equal("token1"))
+			})
+
+			It("retrieves a new token when it expires soon", func() {
+				source.Token()
+				time.Sleep(600 * time.Millisecond)
+				token, err := source.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token.Valid()).To(BeTrue())
+				Expect(token.(*client.OAuth2Token).AccessToken).To(Equal("token2"))
+			})
+		})
+
+		Context("with a token request that panics", func() {
+			BeforeEach(func() {
+				source.Client = &http.Client{Transport: panicTransport{}}
+			})
+
+			It("retrieves the token again on the next call", func() {
+				Expect(func() { source.Token() }).To(Panic())
+				source.Client = nil
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					token, err := source.Token()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(token.(*client.OAuth2Token).AccessToken).To(Equal("token1"))
+				}()
+				Eventually(done).Should(BeClosed())
+			})
+		})
+
+		Context("with invalid credentials", func() {
+			BeforeEach(func() {
+				source.ClientSecret = "guess"
+			})
+
+			It("returns the token endpoint error", func() {
+				_, err := source.Token()
+				Expect(err).To(HaveOccurred())
+				Expect(err.(*client.TokenError).StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(err.(*client.TokenError).Code).To(Equal("invalid_client"))
+				Expect(err.Error()).To(Equal("oauth2: token request failed: invalid_client: bad credentials"))
+			})
+		})
+	})
+
+	Context("RefreshTokenSource", func() {
+		var source *client.RefreshTokenSource
+
+		BeforeEach(func() {
+			expiresIn = 1
+			source = &client.RefreshTokenSource{
+				TokenURL:     server.URL,
+				RefreshToken: "initial",
+			}
+		})
+
+		It("uses the rotated refresh tokens", func() {
+			for i := 1; i <= 3; i++ {
+				if i > 1 {
+					time.Sleep(600 * time.Millisecond) // Wait for the token to expire soon
+				}
+				token, err := source.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token.(*client.OAuth2Token).AccessToken).To(Equal(fmt.Sprintf("token%d", i)))
+			}
+			Expect(forms[0]["refresh_token"]).To(Equal("initial"))
+			Expect(forms[1]["refresh_token"]).To(Equal("refresh1"))
+			Expect(forms[2]["refresh_token"]).To(Equal("refresh2"))
+		})
+
+		Context("with a revoked refresh token", func() {
+			BeforeEach(func() {
+				source.RefreshToken = "revoked"
+			})
+
+			It("returns the token endpoint error", func() {
+				_, err := source.Token()
+				Expect(err).To(MatchError("oauth2: token request failed: invalid_grant"))
+			})
+		})
+	})
+})
+
+// panicTransport is a http.RoundTripper that panics.
+type panicTransport struct{}
+
+func (panicTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	panic("boom")
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/goadesign/goa"
+)
+
+// ResponseError is the error returned by the generated response decoders when the response
+// status code does not correspond to a successful response.
+type ResponseError struct {
+	// Status is the response status code.
+	Status int
+	// Header contains the response headers.
+	Header http.Header
+	// Body contains the raw response body.
+	Body []byte
+	// Err is the decoded goa error if the response body contains one.
+	Err *goa.ErrorResponse
+	// Value is the decoded response body for error responses whose media type is defined
+	// in the design, e.g. *app.GoaNotFound.
+	Value interface{}
+}
+
+// NewResponseError reads the body of resp and returns the corresponding error. If v is not nil the
+// body is decoded into v which is then stored in the Value field, otherwise the body is decoded
+// as a goa error if possible.
+func NewResponseError(resp *http.Response, decoder *goa.HTTPDecoder, v interface{}) *ResponseError {
+	e := &ResponseError{Status: resp.StatusCode, Header: resp.Header}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || len(body) == 0 {
+		return e
+	}
+	e.Body = body
+	ct := resp.Header.Get("Content-Type")
+	if v != nil {
+		if err := decoder.Decode(v, bytes.NewReader(body), ct); err == nil {
+			e.Value = v
+		}
+		return e
+	}
+	var er goa.ErrorResponse
+	if err := decoder.Decode(&er, bytes.NewReader(body), ct); err == nil && (er.Code != "" || er.Detail != "") {
+		if er.Status == 0 {
+			er.Status = resp.StatusCode
+		}
+		e.Err = &er
+	}
+	return e
+}
+
+// Error returns the goa error message if there is one, the response status otherwise.
+func (e *ResponseError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+}
+
+// Unwrap returns the decoded goa error if any.
+func (e *ResponseError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// ResponseStatus returns the response status code.
+func (e *ResponseError) ResponseStatus() int { return e.Status }
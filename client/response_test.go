@@ -0,0 +1,87 @@
+package client_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewResponseError", func() {
+	var (
+		status int
+		body   string
+		value  interface{}
+		err    *client.ResponseError
+	)
+
+	BeforeEach(func() {
+		value = nil
+	})
+
+	JustBeforeEach(func() {
+		resp := &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		}
+		decoder := goa.NewHTTPDecoder()
+		decoder.Register(goa.NewJSONDecoder, "application/json")
+		err = client.NewResponseError(resp, decoder, value)
+	})
+
+	Context("with a goa error body", func() {
+		BeforeEach(func() {
+			status = http.StatusNotFound
+			body = `{"id":"abc","code":"not_found","status":404,"detail":"no bottle"}`
+		})
+
+		It("decodes the goa error", func() {
+			Expect(err.Status).To(Equal(http.StatusNotFound))
+			Expect(err.Err).NotTo(BeNil())
+			Expect(err.Err.Code).To(Equal("not_found"))
+			Expect(err.Error()).To(Equal(err.Err.Error()))
+			var goaErr *goa.ErrorResponse
+			Expect(errors.As(err, &goaErr)).To(BeTrue())
+			Expect(goaErr.Detail).To(Equal("no bottle"))
+		})
+	})
+
+	Context("with a typed error body", func() {
+		type conflict struct {
+			Name string `json:"name"`
+		}
+
+		BeforeEach(func() {
+			status = http.StatusConflict
+			body = `{"name":"dup"}`
+			value = new(conflict)
+		})
+
+		It("decodes the body into the given value", func() {
+			Expect(err.Err).To(BeNil())
+			Expect(err.Value).To(Equal(&conflict{Name: "dup"}))
+			Expect(err.Body).To(Equal([]byte(body)))
+		})
+	})
+
+	Context("with an empty body", func() {
+		BeforeEach(func() {
+			status = http.StatusServiceUnavailable
+			body = ""
+		})
+
+		It("reports the status", func() {
+			Expect(err.Err).To(BeNil())
+			Expect(err.Unwrap()).To(BeNil())
+			Expect(err.ResponseStatus()).To(Equal(http.StatusServiceUnavailable))
+			Expect(err.Error()).To(Equal("503 Service Unavailable"))
+		})
+	})
+})
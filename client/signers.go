@@ -44,9 +44,9 @@ type (
 	// OAuth2Signer adds a authorization header to the request using the given OAuth2 token
 	// source to produce the header value.
 	OAuth2Signer struct {
-		// TokenSource is an OAuth2 access token source.
-		// See package golang/oauth2 and its subpackage for implementations of token
-		// sources.
+		// TokenSource is an OAuth2 access token source, e.g. a ClientCredentialsTokenSource
+		// or a RefreshTokenSource. See also package golang/oauth2 and its subpackage for
+		// implementations of token sources.
 		TokenSource TokenSource
 	}
 
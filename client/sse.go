@@ -0,0 +1,106 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxEventLineLength is the default maximum length of the lines of an event stream read by
+// the readers created with NewEventReader.
+var DefaultMaxEventLineLength = 1 << 20
+
+type (
+	// Event is a Server-Sent Event read from an event stream.
+	Event struct {
+		// ID is the last event ID set by the stream.
+		ID string
+		// Name is the event type, "message" if the event does not set one.
+		Name string
+		// Data is the event data, multiple data lines are joined with a newline.
+		Data []byte
+		// Retry is the reconnection time requested by the server if any.
+		Retry time.Duration
+	}
+
+	// EventReader reads Server-Sent Events from a stream, see
+	// https://html.spec.whatwg.org/multipage/server-sent-events.html.
+	EventReader struct {
+		scanner *bufio.Scanner
+		lastID  string
+	}
+)
+
+// NewEventReader returns a reader that parses the events streamed by r. The lines of the stream
+// may not be longer than DefaultMaxEventLineLength, use NewEventReaderSize to change the limit.
+func NewEventReader(r io.Reader) *EventReader {
+	return NewEventReaderSize(r, DefaultMaxEventLineLength)
+}
+
+// NewEventReaderSize returns a reader that parses the events streamed by r whose lines are at most
+// max bytes long. Next returns bufio.ErrTooLong when reading a longer line.
+func NewEventReaderSize(r io.Reader, max int) *EventReader {
+	scanner := bufio.NewScanner(r)
+	size := bufio.MaxScanTokenSize
+	if max < size {
+		size = max
+	}
+	scanner.Buffer(make([]byte, 0, size), max)
+	return &EventReader{scanner: scanner}
+}
+
+// Next blocks until the next event is read and returns it. Comment lines such as the heartbeats
+// sent by goa services are skipped. Next returns io.EOF once the stream is closed.
+func (r *EventReader) Next() (*Event, error) {
+	var (
+		data    bytes.Buffer
+		name    string
+		retry   time.Duration
+		hasData bool
+	)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if !hasData {
+				name = ""
+				continue
+			}
+			if name == "" {
+				name = "message"
+			}
+			return &Event{ID: r.lastID, Name: name, Data: data.Bytes(), Retry: retry}, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "event":
+			name = value
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				r.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
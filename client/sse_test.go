@@ -0,0 +1,67 @@
+package client_test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventReader", func() {
+	var stream string
+	var reader *client.EventReader
+
+	JustBeforeEach(func() {
+		reader = client.NewEventReader(strings.NewReader(stream))
+	})
+
+	Context("with a stream of events", func() {
+		BeforeEach(func() {
+			stream = ":\n\n" +
+				"data: {\"name\":\"foo\"}\n\n" +
+				"id: 42\nevent: update\nretry: 1000\ndata: foo\ndata: bar\n\n" +
+				"data: incomplete"
+		})
+
+		It("reads the events skipping comments", func() {
+			ev, err := reader.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ev.Name).To(Equal("message"))
+			Expect(string(ev.Data)).To(Equal(`{"name":"foo"}`))
+			Expect(ev.ID).To(BeEmpty())
+
+			ev, err = reader.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ev.ID).To(Equal("42"))
+			Expect(ev.Name).To(Equal("update"))
+			Expect(ev.Retry).To(Equal(time.Second))
+			Expect(string(ev.Data)).To(Equal("foo\nbar"))
+
+			_, err = reader.Next()
+			Expect(err).To(Equal(io.EOF))
+		})
+	})
+
+	Context("with events longer than the scanner default buffer", func() {
+		BeforeEach(func() {
+			stream = "data: " + strings.Repeat("a", 100*1024) + "\n\n"
+		})
+
+		It("reads the events", func() {
+			ev, err := reader.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ev.Data).To(HaveLen(100 * 1024))
+		})
+
+		It("returns an error if the lines exceed the maximum length", func() {
+			reader = client.NewEventReaderSize(strings.NewReader(stream), 1024)
+			_, err := reader.Next()
+			Expect(err).To(Equal(bufio.ErrTooLong))
+		})
+	})
+})
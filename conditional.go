@@ -0,0 +1,91 @@
+package goa
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckPreconditions evaluates the conditional headers of req (If-Match, If-Unmodified-Since,
+// If-None-Match and If-Modified-Since) against the current entity tag and modification time of the
+// target resource following RFC 7232 section 6. etag may be given with or without the surrounding
+// double quotes, a weak entity tag must be prefixed with "W/". An empty etag or a zero modTime
+// means the corresponding validator is not available.
+//
+// CheckPreconditions returns 0 if the request should be processed, http.StatusNotModified if the
+// client copy of a GET or HEAD response is up to date and http.StatusPreconditionFailed if a
+// precondition does not hold.
+func CheckPreconditions(req *http.Request, etag string, modTime time.Time) int {
+	etag = FormatETag(etag)
+	if im := req.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if ius := req.Header.Get("If-Unmodified-Since"); ius != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && modTime.Truncate(time.Second).After(t) {
+			return http.StatusPreconditionFailed
+		}
+	}
+	safe := req.Method == "GET" || req.Method == "HEAD"
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if matchETag(inm, etag, true) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if ims := req.Header.Get("If-Modified-Since"); ims != "" && safe && !modTime.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !modTime.Truncate(time.Second).After(t) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// SetValidators sets the ETag and Last-Modified response headers. Empty etag and zero modTime
+// values are ignored.
+func SetValidators(h http.Header, etag string, modTime time.Time) {
+	if etag != "" {
+		h.Set("ETag", FormatETag(etag))
+	}
+	if !modTime.IsZero() {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+}
+
+// FormatETag returns the entity tag enclosed in double quotes as required by the ETag header
+// syntax. Entity tags that are already quoted, weak or not, are returned as is.
+func FormatETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// matchETag returns true if the given If-Match or If-None-Match header value matches etag. Weak
+// comparison ignores the weak indicator, strong comparison never matches weak entity tags.
+func matchETag(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
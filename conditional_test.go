@@ -0,0 +1,147 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckPreconditions", func() {
+	var method string
+	var header http.Header
+	var etag string
+	var modTime time.Time
+	var status int
+
+	BeforeEach(func() {
+		method = "GET"
+		header = make(http.Header)
+		etag = "v1"
+		modTime = time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	})
+
+	JustBeforeEach(func() {
+		req := httptest.NewRequest(method, "/", nil)
+		req.Header = header
+		status = goa.CheckPreconditions(req, etag, modTime)
+	})
+
+	Context("with no conditional header", func() {
+		It("lets the request through", func() {
+			Ω(status).Should(Equal(0))
+		})
+	})
+
+	Context("with a matching If-None-Match header", func() {
+		BeforeEach(func() {
+			header.Set("If-None-Match", `"v0", W/"v1"`)
+		})
+
+		It("returns 304", func() {
+			Ω(status).Should(Equal(http.StatusNotModified))
+		})
+
+		Context("on a PATCH request", func() {
+			BeforeEach(func() {
+				method = "PATCH"
+			})
+
+			It("returns 412", func() {
+				Ω(status).Should(Equal(http.StatusPreconditionFailed))
+			})
+		})
+	})
+
+	Context("with a stale If-None-Match header", func() {
+		BeforeEach(func() {
+			header.Set("If-None-Match", `"v0"`)
+			header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		})
+
+		It("ignores If-Modified-Since", func() {
+			Ω(status).Should(Equal(0))
+		})
+	})
+
+	Context("with an If-Modified-Since header", func() {
+		BeforeEach(func() {
+			header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		})
+
+		It("returns 304 if the resource was not modified", func() {
+			Ω(status).Should(Equal(http.StatusNotModified))
+		})
+
+		Context("and a later modification time", func() {
+			BeforeEach(func() {
+				modTime = modTime.Add(time.Second)
+			})
+
+			It("lets the request through", func() {
+				Ω(status).Should(Equal(0))
+			})
+		})
+	})
+
+	Context("with an If-Match header", func() {
+		BeforeEach(func() {
+			method = "PATCH"
+			header.Set("If-Match", `"v1"`)
+		})
+
+		It("lets the request through if the entity tag matches", func() {
+			Ω(status).Should(Equal(0))
+		})
+
+		Context("and a different entity tag", func() {
+			BeforeEach(func() {
+				etag = "v2"
+			})
+
+			It("returns 412", func() {
+				Ω(status).Should(Equal(http.StatusPreconditionFailed))
+			})
+		})
+
+		Context("and a weak entity tag", func() {
+			BeforeEach(func() {
+				etag = `W/"v1"`
+			})
+
+			It("returns 412", func() {
+				Ω(status).Should(Equal(http.StatusPreconditionFailed))
+			})
+		})
+	})
+
+	Context("with an If-Unmodified-Since header", func() {
+		BeforeEach(func() {
+			method = "PUT"
+			header.Set("If-Unmodified-Since", modTime.Add(-time.Minute).Format(http.TimeFormat))
+		})
+
+		It("returns 412 if the resource was modified", func() {
+			Ω(status).Should(Equal(http.StatusPreconditionFailed))
+		})
+	})
+})
+
+var _ = Describe("SetValidators", func() {
+	It("sets the ETag and Last-Modified headers", func() {
+		h := make(http.Header)
+		modTime := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		goa.SetValidators(h, "v1", modTime)
+		Ω(h.Get("ETag")).Should(Equal(`"v1"`))
+		Ω(h.Get("Last-Modified")).Should(Equal("Sat, 02 Jan 2016 03:04:05 GMT"))
+	})
+
+	It("ignores missing validators", func() {
+		h := make(http.Header)
+		goa.SetValidators(h, "", time.Time{})
+		Ω(h).Should(BeEmpty())
+	})
+})
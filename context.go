@@ -19,6 +19,7 @@ const (
 	logContextKey
 	errKey
 	securityScopesKey
+	routeKey
 )
 
 type (
@@ -62,6 +63,11 @@ func NewContext(ctx context.Context, rw http.ResponseWriter, req *http.Request,
 	response := &ResponseData{ResponseWriter: rw}
 	ctx = context.WithValue(ctx, respKey, response)
 	ctx = context.WithValue(ctx, reqKey, request)
+	if req != nil {
+		if route := ContextRoute(req.Context()); route != nil {
+			ctx = WithRoute(ctx, route)
+		}
+	}
 
 	return ctx
 }
@@ -160,6 +166,13 @@ func (r *ResponseData) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
+// Flush sends any buffered data to the client if the underlying writer supports it.
+func (r *ResponseData) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // Write records the amount of data written and calls the underlying writer.
 func (r *ResponseData) Write(b []byte) (int, error) {
 	if !r.Written() {
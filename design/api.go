@@ -185,6 +185,70 @@ var (
 		AttributeDefinition: &AttributeDefinition{Type: errorMediaType},
 		Name:                "default",
 	}
+
+	// ProblemMediaIdentifier is the media type identifier used for error responses rendered as
+	// RFC 7807 problem details documents.
+	ProblemMediaIdentifier = "application/problem+json"
+
+	// ProblemMedia is the built-in media type for error responses rendered as RFC 7807 problem
+	// details documents. Responses that use ErrorMedia use ProblemMedia instead when the API
+	// design uses the ProblemDetails DSL.
+	ProblemMedia = &MediaTypeDefinition{
+		UserTypeDefinition: &UserTypeDefinition{
+			AttributeDefinition: &AttributeDefinition{
+				Type:        problemMediaType,
+				Description: "Error response media type (RFC 7807)",
+				Example: map[string]interface{}{
+					"type":   "invalid_value",
+					"title":  "Bad Request",
+					"status": 400,
+					"detail": "Value of ID must be an integer",
+					"id":     "3F1FKVRR",
+				},
+			},
+			TypeName: "problem",
+		},
+		Identifier:  ProblemMediaIdentifier,
+		ContentType: ProblemMediaIdentifier,
+		Views:       map[string]*ViewDefinition{"default": problemMediaView},
+	}
+
+	problemMediaType = Object{
+		"type": &AttributeDefinition{
+			Type:        String,
+			Description: "a URI reference that identifies the problem type, the application-specific error code.",
+			Example:     "invalid_value",
+		},
+		"title": &AttributeDefinition{
+			Type:        String,
+			Description: "a short, human-readable summary of the problem type.",
+			Example:     "Bad Request",
+		},
+		"status": &AttributeDefinition{
+			Type:        Integer,
+			Description: "the HTTP status code applicable to this problem.",
+			Example:     400,
+		},
+		"detail": &AttributeDefinition{
+			Type:        String,
+			Description: "a human-readable explanation specific to this occurrence of the problem.",
+			Example:     "Value of ID must be an integer",
+		},
+		"instance": &AttributeDefinition{
+			Type:        String,
+			Description: "a URI reference that identifies the specific occurrence of the problem.",
+		},
+		"id": &AttributeDefinition{
+			Type:        String,
+			Description: "a unique identifier for this particular occurrence of the problem.",
+			Example:     "3F1FKVRR",
+		},
+	}
+
+	problemMediaView = &ViewDefinition{
+		AttributeDefinition: &AttributeDefinition{Type: problemMediaType},
+		Name:                "default",
+	}
 )
 
 func init() {
@@ -200,6 +264,7 @@ func init() {
 		{MIMETypes: GobContentTypes, PackagePath: goa, Function: "NewGobDecoder"},
 	}
 	errorMediaView.Parent = ErrorMedia
+	problemMediaView.Parent = ProblemMedia
 }
 
 // CanonicalIdentifier returns the media type identifier sans suffix
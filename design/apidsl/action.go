@@ -119,10 +119,9 @@ func Action(name string, dsl func()) {
 // Routing used in: Action
 //
 // Routing lists the action route. Each route is defined with a function named after the HTTP method.
-// The route function takes the path as argument. Route paths may use wildcards as described in the
-// [httptreemux](https://godoc.org/github.com/dimfeld/httptreemux) package documentation. These
-// wildcards define parameters using the `:name` or `*name` syntax where `:name` matches a path
-// segment and `*name` is a catch-all that matches the path until the end.
+// The route function takes the path as argument. Route paths may use wildcards that define
+// parameters using the `:name` or `*name` syntax where `:name` matches a path segment and `*name`
+// is a catch-all that matches the path until the end.
 func Routing(routes ...*design.RouteDefinition) {
 	if a, ok := actionDefinition(); ok {
 		for _, r := range routes {
@@ -489,6 +488,102 @@ func MultipartForm() {
 	}
 }
 
+// Stream can be used in: Action, Response
+//
+// Stream indicates that the request body (when used in Action) or the response body (when used
+// in Response) is streamed instead of being decoded or encoded in one go. The optional argument
+// specifies how the stream is encoded: NDJSONStream (the default) streams newline delimited JSON
+// values of the payload or response type while RawStream exposes the body as an io.Reader or
+// io.Writer. SSEStream makes the response a stream of Server-Sent Events whose data are values of
+// the response media type, it can only be used in Response and at most once per action. NDJSON
+// payload streams require a Payload, raw payload streams must not define one. Examples:
+//
+//	Action("import", func() {
+//		Routing(POST("/import"))
+//		Payload(BottlePayload)
+//		Stream() // The request body is a stream of BottlePayload values
+//		Response(NoContent)
+//	})
+//
+//	Action("export", func() {
+//		Routing(GET("/export"))
+//		Response(OK, func() {
+//			Media(BottleMedia)
+//			Stream() // The response body is a stream of BottleMedia values
+//		})
+//	})
+//
+//	Action("upload", func() {
+//		Routing(PUT("/upload"))
+//		Stream(RawStream) // The controller reads the request body directly
+//		Response(NoContent)
+//	})
+//
+//	Action("watch", func() {
+//		Routing(GET("/watch"))
+//		Response(OK, func() {
+//			Media(BottleMedia)
+//			Stream(SSEStream) // The context exposes SendEvent(*BottleMedia)
+//		})
+//	})
+//
+// Note that streamed request bodies are subject to the controller MaxRequestBodyLength limit.
+func Stream(kind ...design.StreamKind) {
+	k := design.NDJSONStream
+	if len(kind) > 0 {
+		k = kind[0]
+	}
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ActionDefinition:
+		def.PayloadStream = k
+	case *design.ResponseDefinition:
+		def.Stream = k
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
+// Conditional can be used in: Action
+//
+// Conditional indicates that the action supports conditional requests as described in RFC 7232.
+// The generated action context exposes CheckPreconditions which evaluates the If-Match,
+// If-None-Match, If-Modified-Since and If-Unmodified-Since request headers against the current
+// entity tag and modification time of the resource and writes a 304 Not Modified or 412
+// Precondition Failed response when the request must not be processed any further. The validators
+// given to CheckPreconditions (or to SetValidators) are sent in the ETag and Last-Modified headers
+// of the OK responses. The generated client methods accept options that set the conditional
+// request headers. Examples:
+//
+//	Action("list", func() {
+//		Routing(GET(""))
+//		Conditional() // Clients may revalidate with If-None-Match or If-Modified-Since
+//		Response(OK, CollectionOf(BottleMedia))
+//	})
+//
+//	Action("update", func() {
+//		Routing(PATCH("/:id"))
+//		Payload(BottlePayload)
+//		Conditional() // Clients may send If-Match to implement optimistic locking
+//		Response(OK, BottleMedia)
+//	})
+//
+// The controller code then looks like:
+//
+//	func (c *BottleController) Update(ctx *app.UpdateBottleContext) error {
+//		b := c.db.Get(ctx.ID)
+//		if !ctx.CheckPreconditions(b.Version, b.UpdatedAt) {
+//			return nil
+//		}
+//		// ... update b
+//		ctx.SetValidators(b.Version, b.UpdatedAt)
+//		return ctx.OK(b.Media())
+//	}
+func Conditional() {
+	if a, ok := actionDefinition(); ok {
+		a.Conditional = true
+	}
+}
+
 // newAttribute creates a new attribute definition using the media type with the given identifier
 // as base type.
 func newAttribute(baseMT string) *design.AttributeDefinition {
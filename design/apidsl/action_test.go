@@ -112,6 +112,77 @@ var _ = Describe("Action", func() {
 		})
 	})
 
+	Context("with a streamed payload", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Routing(POST("/"))
+				Payload(String)
+				Stream()
+			}
+		})
+
+		It("produces a valid action with a NDJSON payload stream", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Validate()).ShouldNot(HaveOccurred())
+			Ω(action.PayloadStream).Should(Equal(NDJSONStream))
+		})
+	})
+
+	Context("with a raw streamed payload and a payload type", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Routing(POST("/"))
+				Payload(String)
+				Stream(RawStream)
+			}
+		})
+
+		It("produces an invalid action", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a streamed response", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Routing(GET("/"))
+				Response(OK, func() {
+					Media("application/json")
+					Stream()
+				})
+			}
+		})
+
+		It("sets the response stream kind", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Responses).Should(HaveKey("OK"))
+			Ω(action.Responses["OK"].Stream).Should(Equal(NDJSONStream))
+		})
+	})
+
+	Context("with a conditional action", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Routing(PATCH("/:id"))
+				Conditional()
+				Response(OK)
+			}
+		})
+
+		It("produces a valid conditional action", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Validate()).ShouldNot(HaveOccurred())
+			Ω(action.Conditional).Should(BeTrue())
+		})
+	})
+
 	Context("with a name and DSL defining a description, route, headers, payload and responses", func() {
 		const typeName = "typeName"
 		const description = "description"
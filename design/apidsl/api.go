@@ -64,6 +64,7 @@ import (
 //			MediaType(arg2)
 //		})
 //              NoExample()                             // Prevent automatic generation of examples
+//		ProblemDetails()			// Render errors as RFC 7807 documents
 //		Trait("Authenticated", func() {		// Traits define DSL that can be run anywhere
 //			Headers(func() {
 //				Header("header")
@@ -575,6 +576,23 @@ func Title(val string) {
 	}
 }
 
+// ProblemDetails used in: API
+//
+// ProblemDetails causes the API to render errors as RFC 7807 problem details documents using the
+// application/problem+json content type. Responses that use ErrorMedia use ProblemMedia instead,
+// the generated documentation and clients describe and decode the problem details shape. The
+// service must set its ProblemDetails field for the errors produced by the ErrorHandler
+// middleware to be rendered the same way:
+//
+//	service := goa.New("cellar")
+//	service.ProblemDetails = true
+//	service.Use(middleware.ErrorHandler(service, true))
+func ProblemDetails() {
+	if a, ok := apiDefinition(); ok {
+		a.ProblemDetails = true
+	}
+}
+
 // Trait can be used in: API
 //
 // Trait defines an API trait. A trait encapsulates arbitrary DSL that gets executed wherever the
@@ -399,6 +399,29 @@ var SupportedValidationFormats = []string{
 	"uri",
 }
 
+// SupportedIntegerFormats lists the bit-width formats supported by the Format DSL for Integer
+// attributes.
+var SupportedIntegerFormats = []string{
+	design.FormatInt32,
+	design.FormatInt64,
+	design.FormatUint32,
+	design.FormatUint64,
+}
+
+// SupportedNumberFormats lists the bit-width formats supported by the Format DSL for Number
+// attributes.
+var SupportedNumberFormats = []string{
+	design.FormatFloat,
+}
+
+// numericFormats returns the formats supported by the given Integer or Number type.
+func numericFormats(t design.DataType) []string {
+	if t.Kind() == design.IntegerKind {
+		return SupportedIntegerFormats
+	}
+	return SupportedNumberFormats
+}
+
 // Format can be used in: Attribute, Header, Param, HashOf, ArrayOf
 //
 // Format adds a "format" validation to the attribute.
@@ -424,10 +447,28 @@ var SupportedValidationFormats = []string{
 // "regexp": RE2 regular expression
 //
 // "rfc1123": RFC1123 date time
+//
+// Format may also be used on Integer and Number attributes to specify the bit-width of the
+// generated Go type. The formats supported for Integer attributes are "int32", "int64", "uint32"
+// and "uint64", the format supported for Number attributes is "float" (float32):
+//
+//	Attribute("count", Integer, func() {
+//		Format("uint32")
+//	})
 func Format(f string) {
 	if a, ok := attributeDefinition(); ok {
-		if a.Type != nil && a.Type.Kind() != design.StringKind {
-			incompatibleAttributeType("format", a.Type.Name(), "a string")
+		if a.Type != nil && (a.Type.Kind() == design.IntegerKind || a.Type.Kind() == design.NumberKind) {
+			if !design.IsNumericFormat(a.Type, f) {
+				dslengine.ReportError("unsupported format %#v for attribute of type %s, supported formats are: %s",
+					f, a.Type.Name(), strings.Join(numericFormats(a.Type), ", "))
+				return
+			}
+			if a.Validation == nil {
+				a.Validation = &dslengine.ValidationDefinition{}
+			}
+			a.Validation.Format = f
+		} else if a.Type != nil && a.Type.Kind() != design.StringKind {
+			incompatibleAttributeType("format", a.Type.Name(), "a string, an integer or a number")
 		} else {
 			supported := false
 			for _, s := range SupportedValidationFormats {
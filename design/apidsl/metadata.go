@@ -67,6 +67,30 @@ import (
 //
 //        Metadata("swagger:extension:x-api", `{"foo":"bar"}`)
 //
+// `ratelimit`: overrides the default limit of the rate limit middleware (see package
+// github.com/goadesign/goa/middleware/ratelimit). The first value is the number of requests
+// allowed per period, the optional second value is the burst size.
+// Applicable to resources and actions.
+//
+//        Metadata("ratelimit", "100/m")
+//        Metadata("ratelimit", "10/s", "20")
+//
+// `request:max_body_length`: overrides the maximum length of the request bodies (see the
+// goa.Controller MaxRequestBodyLength field). The value is a number of bytes optionally followed by
+// one of the KB, MB or GB units, "0" removes the limit. Requests with larger bodies are rejected
+// with a 413 status code.
+// Applicable to resources and actions.
+//
+//        Metadata("request:max_body_length", "10MB")
+//
+// `idempotency`: makes the idempotency middleware record and replay the responses of the action
+// (see package github.com/goadesign/goa/middleware/idempotency) and makes the generated client
+// send an Idempotency-Key header. The optional value overrides how long the responses are kept.
+// Applicable to resources and actions.
+//
+//        Metadata("idempotency")
+//        Metadata("idempotency", "48h")
+//
 // The special key names listed above may be used as follows:
 //
 //        var Account = Type("Account", func() {
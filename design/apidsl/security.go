@@ -233,6 +233,8 @@ func OAuth2Security(name string, dsl ...func()) *design.SecuritySchemeDefinition
 //    JWTSecurity("jwt", func() {
 //        Header("Authorization")
 //        TokenURL("https://example.com/token")
+//        Issuer("https://example.com")
+//        Audience("my_system")
 //        Scope("my_system:write", "Write to the system")
 //        Scope("my_system:read", "Read anything in there")
 //    })
@@ -401,3 +403,32 @@ func TokenURL(tokenURL string) {
 	}
 	dslengine.IncompatibleDSL()
 }
+
+// Issuer can be used in: JWTSecurity
+//
+// Issuer defines the accepted values of the "iss" claim of the JWT tokens. The generated security
+// definition lists the issuers so that the jwt middleware rejects tokens issued by others.
+func Issuer(issuers ...string) {
+	if parent, ok := dslengine.CurrentDefinition().(*design.SecuritySchemeDefinition); ok {
+		if parent.Kind == design.JWTSecurityKind {
+			parent.Issuers = append(parent.Issuers, issuers...)
+			return
+		}
+	}
+	dslengine.IncompatibleDSL()
+}
+
+// Audience can be used in: JWTSecurity
+//
+// Audience defines the accepted values of the "aud" claim of the JWT tokens. The generated
+// security definition lists the audiences so that the jwt middleware rejects tokens intended for
+// other audiences.
+func Audience(audiences ...string) {
+	if parent, ok := dslengine.CurrentDefinition().(*design.SecuritySchemeDefinition); ok {
+		if parent.Kind == design.JWTSecurityKind {
+			parent.Audiences = append(parent.Audiences, audiences...)
+			return
+		}
+	}
+	dslengine.IncompatibleDSL()
+}
@@ -44,6 +44,8 @@ var _ = Describe("Security", func() {
 				Description("desc")
 				Header("Authorization")
 				TokenURL("/token")
+				Issuer("https://example.com")
+				Audience("users", "admin")
 				Scope("user:read", "Read users")
 				Scope("user:write", "Write users")
 			})
@@ -69,6 +71,8 @@ var _ = Describe("Security", func() {
 		Ω(Design.SecuritySchemes[3].Kind).Should(Equal(JWTSecurityKind))
 		Ω(Design.SecuritySchemes[3].TokenURL).Should(Equal("http://example.com/token"))
 		Ω(Design.SecuritySchemes[3].Scopes).Should(HaveLen(2))
+		Ω(Design.SecuritySchemes[3].Issuers).Should(Equal([]string{"https://example.com"}))
+		Ω(Design.SecuritySchemes[3].Audiences).Should(Equal([]string{"users", "admin"}))
 	})
 
 	Context("with basic security", func() {
@@ -1,7 +1,6 @@
 package design
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"path"
@@ -74,6 +73,9 @@ type (
 		Security *SecurityDefinition
 		// NoExamples indicates whether to bypass automatic example generation.
 		NoExamples bool
+		// ProblemDetails indicates whether errors are rendered as RFC 7807 problem details
+		// documents (application/problem+json).
+		ProblemDetails bool
 
 		// rand is the random generator used to generate examples.
 		rand *RandomGenerator
@@ -204,6 +206,8 @@ type (
 		Metadata dslengine.MetadataDefinition
 		// Standard is true if the response definition comes from the goa default responses
 		Standard bool
+		// Stream indicates how the response body is streamed if it is, zero otherwise.
+		Stream StreamKind
 	}
 
 	// ResponseTemplateDefinition defines a response template.
@@ -247,6 +251,11 @@ type (
 		PayloadOptional bool
 		// PayloadOptional is true if the request payload is multipart, false otherwise.
 		PayloadMultipart bool
+		// PayloadStream indicates how the request body is streamed if it is, zero otherwise.
+		PayloadStream StreamKind
+		// Conditional is true if the action supports conditional requests, see
+		// apidsl.Conditional.
+		Conditional bool
 		// Request headers that need to be made available to action
 		Headers *AttributeDefinition
 		// Metadata is a list of key/value pairs
@@ -657,7 +666,8 @@ func (a *APIDefinition) DSL() func() {
 }
 
 // Finalize sets the Consumes and Produces fields to the defaults if empty.
-// Also it records built-in media types that are used by the user design.
+// Also it records built-in media types that are used by the user design. Responses that use
+// ErrorMedia use ProblemMedia instead if ProblemDetails is true.
 func (a *APIDefinition) Finalize() {
 	if len(a.Consumes) == 0 {
 		a.Consumes = DefaultDecoders
@@ -665,38 +675,50 @@ func (a *APIDefinition) Finalize() {
 	if len(a.Produces) == 0 {
 		a.Produces = DefaultEncoders
 	}
-	a.IterateResources(func(r *ResourceDefinition) error {
-		returnsError := func(resp *ResponseDefinition) bool {
-			if resp.MediaType == ErrorMediaIdentifier {
-				if a.MediaTypes == nil {
-					a.MediaTypes = make(map[string]*MediaTypeDefinition)
-				}
-				a.MediaTypes[CanonicalIdentifier(ErrorMediaIdentifier)] = ErrorMedia
-				return true
-			}
-			return false
+	recordError := func(resp *ResponseDefinition) {
+		if a.ProblemDetails && resp.MediaType == ErrorMediaIdentifier {
+			resp.MediaType = ProblemMediaIdentifier
+			resp.Type = ProblemMedia
 		}
-		for _, resp := range a.Responses {
-			if returnsError(resp) {
-				return errors.New("done")
-			}
+		var mt *MediaTypeDefinition
+		switch resp.MediaType {
+		case ErrorMediaIdentifier:
+			mt = ErrorMedia
+		case ProblemMediaIdentifier:
+			mt = ProblemMedia
+		default:
+			return
 		}
+		if a.MediaTypes == nil {
+			a.MediaTypes = make(map[string]*MediaTypeDefinition)
+		}
+		a.MediaTypes[CanonicalIdentifier(mt.Identifier)] = mt
+	}
+	for _, resp := range a.Responses {
+		recordError(resp)
+	}
+	a.IterateResources(func(r *ResourceDefinition) error {
 		for _, resp := range r.Responses {
-			if returnsError(resp) {
-				return errors.New("done")
-			}
+			recordError(resp)
 		}
 		return r.IterateActions(func(action *ActionDefinition) error {
 			for _, resp := range action.Responses {
-				if returnsError(resp) {
-					return errors.New("done")
-				}
+				recordError(resp)
 			}
 			return nil
 		})
 	})
 }
 
+// ErrorMedia returns the built-in media type used by error responses: ProblemMedia if the API
+// renders errors as RFC 7807 problem details documents, ErrorMedia otherwise.
+func (a *APIDefinition) ErrorMedia() *MediaTypeDefinition {
+	if a.ProblemDetails {
+		return ProblemMedia
+	}
+	return ErrorMedia
+}
+
 // NewResourceDefinition creates a resource definition but does not
 // execute the DSL.
 func NewResourceDefinition(name string, dsl func()) *ResourceDefinition {
@@ -1044,6 +1066,40 @@ func (a *AttributeDefinition) IsNonZero(attName string) bool {
 	return a.NonZeroAttributes[attName]
 }
 
+// NumericFormat returns the bit-width format of Integer and Number attributes defined with the
+// Format DSL, the empty string if the attribute does not define one.
+func (a *AttributeDefinition) NumericFormat() string {
+	if a.Type == nil || a.Validation == nil {
+		return ""
+	}
+	if IsNumericFormat(a.Type, a.Validation.Format) {
+		return a.Validation.Format
+	}
+	return ""
+}
+
+// NumericRange returns the effective minimum and maximum values of Integer and Number
+// attributes: the tightest of the Minimum and Maximum validations and of the range of values
+// that the attribute format can represent. Format bounds that cannot be represented exactly with
+// a float64 (64-bit integer maximums and single precision float bounds) are ignored.
+func (a *AttributeDefinition) NumericRange() (min, max *float64) {
+	if a.Validation != nil {
+		min, max = a.Validation.Minimum, a.Validation.Maximum
+	}
+	fmin, fmax, ok := NumericFormatRange(a.NumericFormat())
+	if !ok {
+		return
+	}
+	const maxExact = 1 << 53
+	if fmin >= -maxExact && (min == nil || *min < fmin) {
+		min = &fmin
+	}
+	if fmax <= maxExact && (max == nil || *max > fmax) {
+		max = &fmax
+	}
+	return
+}
+
 // IsPrimitivePointer returns true if the field generated for the given attribute should be a
 // pointer to a primitive type. The target attribute must be an object.
 func (a *AttributeDefinition) IsPrimitivePointer(attName string) bool {
@@ -1424,6 +1480,7 @@ func (r *ResponseDefinition) Dup() *ResponseDefinition {
 		Description: r.Description,
 		MediaType:   r.MediaType,
 		ViewName:    r.ViewName,
+		Stream:      r.Stream,
 	}
 	if r.Headers != nil {
 		res.Headers = DupAtt(r.Headers)
@@ -1449,6 +1506,9 @@ func (r *ResponseDefinition) Merge(other *ResponseDefinition) {
 		r.MediaType = other.MediaType
 		r.ViewName = other.ViewName
 	}
+	if r.Stream == 0 {
+		r.Stream = other.Stream
+	}
 	if other.Headers != nil {
 		otherHeaders := other.Headers.Type.ToObject()
 		if len(otherHeaders) > 0 {
@@ -1584,6 +1644,16 @@ func (a *ActionDefinition) WebSocket() bool {
 	return true
 }
 
+// EventStream returns the action response that streams Server-Sent Events if any, nil otherwise.
+func (a *ActionDefinition) EventStream() *ResponseDefinition {
+	for _, r := range a.Responses {
+		if r.Stream == SSEStream {
+			return r
+		}
+	}
+	return nil
+}
+
 // Finalize inherits security scheme and action responses from parent and top level design.
 func (a *ActionDefinition) Finalize() {
 	// Inherit security scheme
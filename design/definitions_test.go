@@ -169,6 +169,45 @@ var _ = Describe("Finalize ActionDefinition", func() {
 	})
 })
 
+var _ = Describe("Finalize APIDefinition", func() {
+	var api *design.APIDefinition
+	var resp *design.ResponseDefinition
+
+	BeforeEach(func() {
+		resp = &design.ResponseDefinition{Name: "BadRequest", Status: 400, MediaType: design.ErrorMediaIdentifier}
+		action := &design.ActionDefinition{Name: "show", Responses: map[string]*design.ResponseDefinition{"BadRequest": resp}}
+		resource := &design.ResourceDefinition{Name: "bottle", Actions: map[string]*design.ActionDefinition{"show": action}}
+		action.Parent = resource
+		api = &design.APIDefinition{Resources: map[string]*design.ResourceDefinition{"bottle": resource}}
+	})
+
+	JustBeforeEach(func() {
+		api.Finalize()
+	})
+
+	It("records the error media type", func() {
+		Ω(resp.MediaType).Should(Equal(design.ErrorMediaIdentifier))
+		Ω(api.MediaTypeWithIdentifier(design.ErrorMediaIdentifier)).Should(Equal(design.ErrorMedia))
+		Ω(api.ErrorMedia()).Should(Equal(design.ErrorMedia))
+	})
+
+	Context("with problem details", func() {
+		BeforeEach(func() {
+			api.ProblemDetails = true
+		})
+
+		It("uses the problem media type for error responses", func() {
+			Ω(resp.MediaType).Should(Equal(design.ProblemMediaIdentifier))
+			Ω(resp.Type).Should(Equal(design.ProblemMedia))
+			Ω(design.ProblemMedia.ContentType).Should(Equal(design.ProblemMediaIdentifier))
+			Ω(api.MediaTypeWithIdentifier(design.ProblemMediaIdentifier)).Should(Equal(design.ProblemMedia))
+			Ω(api.MediaTypeWithIdentifier(design.ErrorMediaIdentifier)).Should(BeNil())
+			Ω(api.ErrorMedia()).Should(Equal(design.ProblemMedia))
+			Ω(design.ProblemMedia.IsError()).Should(BeTrue())
+		})
+	})
+})
+
 var _ = Describe("FullPath", func() {
 
 	Context("Given a base resource and a resource with an action with a route", func() {
@@ -61,9 +61,32 @@ func (eg *exampleGenerator) Generate(seen []string) interface{} {
 		if example == nil {
 			example = eg.a.Type.GenerateExample(eg.r, seen)
 		}
+		return eg.fitNumericFormat(example)
+	}
+	return eg.fitNumericFormat(eg.a.Type.GenerateExample(eg.r, seen))
+}
+
+// fitNumericFormat makes sure that random integer examples fit in the range of values of the
+// attribute bit-width format.
+func (eg *exampleGenerator) fitNumericFormat(example interface{}) interface{} {
+	v, ok := example.(int)
+	if !ok {
 		return example
 	}
-	return eg.a.Type.GenerateExample(eg.r, seen)
+	switch eg.a.NumericFormat() {
+	case FormatInt32:
+		return v % (math.MaxInt32 + 1)
+	case FormatUint32:
+		if v < 0 {
+			v = -v
+		}
+		return int(int64(v) % (math.MaxUint32 + 1))
+	case FormatUint64:
+		if v < 0 {
+			v = -v
+		}
+	}
+	return v
 }
 
 func (eg *exampleGenerator) ExampleLength() int {
@@ -140,7 +163,7 @@ func (eg *exampleGenerator) generateValidatedEnumExample() interface{} {
 }
 
 func (eg *exampleGenerator) hasFormatValidation() bool {
-	return eg.a.Validation != nil && eg.a.Validation.Format != ""
+	return eg.a.Validation != nil && eg.a.Validation.Format != "" && eg.a.NumericFormat() == ""
 }
 
 // generateFormatExample returns a random example based on the format the user asks.
@@ -69,6 +69,10 @@ type SecuritySchemeDefinition struct {
 	TokenURL string `json:"token_url,omitempty"`
 	// AuthorizationURL holds URL for retrieving authorization codes with oauth2
 	AuthorizationURL string `json:"authorization_url,omitempty"`
+	// Issuers lists the accepted values of the JWT "iss" claim.
+	Issuers []string `json:"issuers,omitempty"`
+	// Audiences lists the accepted values of the JWT "aud" claim.
+	Audiences []string `json:"audiences,omitempty"`
 	// Metadata is a list of key/value pairs
 	Metadata dslengine.MetadataDefinition
 }
@@ -0,0 +1,16 @@
+package design
+
+// StreamKind identifies how a streamed request or response body is encoded.
+type StreamKind int
+
+const (
+	// NDJSONStream means the body is a stream of newline delimited JSON values, see
+	// http://ndjson.org.
+	NDJSONStream StreamKind = iota + 1
+	// RawStream means the body is exposed as a raw io.Reader or io.Writer.
+	RawStream
+	// SSEStream means the response body is a stream of Server-Sent Events, see
+	// https://html.spec.whatwg.org/multipage/server-sent-events.html. It only applies to
+	// responses.
+	SSEStream
+)
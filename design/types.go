@@ -11,6 +11,7 @@ package design
 
 import (
 	"fmt"
+	"math"
 	"mime"
 	"reflect"
 	"sort"
@@ -195,6 +196,56 @@ const (
 	File = Primitive(FileKind)
 )
 
+// Bit-width formats of Integer and Number attributes, see the Format DSL. The generated code
+// represents attributes that use these formats with the Go type of the same name, or float32 for
+// FormatFloat, instead of int and float64.
+const (
+	// FormatInt32 is the format of 32-bit signed integers.
+	FormatInt32 = "int32"
+
+	// FormatInt64 is the format of 64-bit signed integers.
+	FormatInt64 = "int64"
+
+	// FormatUint32 is the format of 32-bit unsigned integers.
+	FormatUint32 = "uint32"
+
+	// FormatUint64 is the format of 64-bit unsigned integers.
+	FormatUint64 = "uint64"
+
+	// FormatFloat is the format of single precision floating point numbers.
+	FormatFloat = "float"
+)
+
+// NumericFormatRange returns the smallest and largest values that can be represented with the
+// given Integer or Number format. ok is false if format is not a numeric format.
+func NumericFormatRange(format string) (min, max float64, ok bool) {
+	switch format {
+	case FormatInt32:
+		return math.MinInt32, math.MaxInt32, true
+	case FormatInt64:
+		return math.MinInt64, math.MaxInt64, true
+	case FormatUint32:
+		return 0, math.MaxUint32, true
+	case FormatUint64:
+		return 0, math.MaxUint64, true
+	case FormatFloat:
+		return -math.MaxFloat32, math.MaxFloat32, true
+	}
+	return 0, 0, false
+}
+
+// IsNumericFormat returns true if format is one of the bit-width formats supported by the
+// given Integer or Number type.
+func IsNumericFormat(t DataType, format string) bool {
+	switch format {
+	case FormatInt32, FormatInt64, FormatUint32, FormatUint64:
+		return t.Kind() == IntegerKind
+	case FormatFloat:
+		return t.Kind() == NumberKind
+	}
+	return false
+}
+
 // DataType implementation
 
 // Kind implements DataKind.
@@ -747,14 +798,16 @@ func NewMediaTypeDefinition(name, identifier string, dsl func()) *MediaTypeDefin
 // Kind implements DataKind.
 func (m *MediaTypeDefinition) Kind() Kind { return MediaTypeKind }
 
-// IsError returns true if the media type is implemented via a goa struct.
+// IsError returns true if the media type is implemented via a goa struct, that is if it is
+// ErrorMedia or ProblemMedia.
 func (m *MediaTypeDefinition) IsError() bool {
 	base, params, err := mime.ParseMediaType(m.Identifier)
 	if err != nil {
 		panic("invalid media type identifier " + m.Identifier) // bug
 	}
 	delete(params, "view")
-	return mime.FormatMediaType(base, params) == ErrorMedia.Identifier
+	id := mime.FormatMediaType(base, params)
+	return id == ErrorMedia.Identifier || id == ProblemMedia.Identifier
 }
 
 // ComputeViews returns the media type views recursing as necessary if the media type is a
@@ -347,6 +347,36 @@ func (a *ActionDefinition) Validate() *dslengine.ValidationErrors {
 			verr.Add(a, "Payload %s contains an invalid type, action payloads cannot contain a file", a.Payload.TypeName)
 		}
 	}
+	switch a.PayloadStream {
+	case 0:
+	case NDJSONStream:
+		if a.Payload == nil {
+			verr.Add(a, "NDJSON payload streams require a payload type")
+		}
+	case RawStream:
+		if a.Payload != nil {
+			verr.Add(a, "raw payload streams cannot define a payload type")
+		}
+	case SSEStream:
+		verr.Add(a, "event streams can only be used in responses")
+	default:
+		verr.Add(a, "invalid payload stream kind %d", a.PayloadStream)
+	}
+	if a.PayloadStream != 0 && a.PayloadMultipart {
+		verr.Add(a, "payload streams cannot be multipart forms")
+	}
+	events := 0
+	for _, r := range a.Responses {
+		if r.Stream == SSEStream {
+			events++
+		}
+	}
+	if events > 1 {
+		verr.Add(a, "actions cannot define more than one event stream response")
+	}
+	if a.Conditional && a.Parent != nil && a.WebSocket() {
+		verr.Add(a, "websocket actions cannot be conditional")
+	}
 	if a.Parent == nil {
 		verr.Add(a, "missing parent resource")
 	}
@@ -481,6 +511,9 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 			verr.Add(parent, "%sdefault value %#v is not one of the accepted values: %#v", ctx, a.DefaultValue, a.Validation.Values)
 		}
 	}
+	if format := a.NumericFormat(); format != "" {
+		verr.Merge(a.validateNumericFormat(ctx, parent, format))
+	}
 	o := a.Type.ToObject()
 	if o != nil {
 		for _, n := range a.AllRequired() {
@@ -509,6 +542,39 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 	return verr.AsError()
 }
 
+// validateNumericFormat checks that the validations, default value and example of a numeric
+// attribute fit in the range of values its bit-width format can represent.
+func (a *AttributeDefinition) validateNumericFormat(ctx string, parent dslengine.Definition, format string) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	min, max, _ := NumericFormatRange(format)
+	check := func(desc string, val interface{}) {
+		var f float64
+		switch v := val.(type) {
+		case int:
+			f = float64(v)
+		case float64:
+			f = v
+		default:
+			return
+		}
+		if f < min || f > max {
+			verr.Add(parent, "%s%s %v does not fit in the range of %s values", ctx, desc, val, format)
+		}
+	}
+	if m := a.Validation.Minimum; m != nil {
+		check("minimum", *m)
+	}
+	if m := a.Validation.Maximum; m != nil {
+		check("maximum", *m)
+	}
+	for _, v := range a.Validation.Values {
+		check("enum value", v)
+	}
+	check("default value", a.DefaultValue)
+	check("example", a.Example)
+	return verr.AsError()
+}
+
 // Validate checks that the response definition is consistent: its status is set and the media
 // type definition if any is valid.
 func (r *ResponseDefinition) Validate() *dslengine.ValidationErrors {
@@ -519,6 +585,19 @@ func (r *ResponseDefinition) Validate() *dslengine.ValidationErrors {
 	if r.Status == 0 {
 		verr.Add(r, "response status not defined")
 	}
+	switch r.Stream {
+	case 0, RawStream:
+	case NDJSONStream:
+		if r.Type == nil && r.MediaType == "" {
+			verr.Add(r, "NDJSON response streams require a media type")
+		}
+	case SSEStream:
+		if r.Type == nil && r.MediaType == "" {
+			verr.Add(r, "event stream responses require a media type")
+		}
+	default:
+		verr.Add(r, "invalid response stream kind %d", r.Stream)
+	}
 	return verr.AsError()
 }
 
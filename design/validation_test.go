@@ -217,6 +217,55 @@ var _ = Describe("Validation", func() {
 			})
 		})
 
+		Context("with a valid numeric format", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, Integer, func() {
+						Format(FormatUint32)
+						Maximum(10)
+					})
+				}
+			})
+
+			It("records the format and effective range", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				Ω(att.NumericFormat()).Should(Equal(FormatUint32))
+				min, max := att.NumericRange()
+				Ω(*min).Should(Equal(0.0))
+				Ω(*max).Should(Equal(10.0))
+			})
+		})
+
+		Context("with a minimum outside of the numeric format range", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, Integer, func() {
+						Format(FormatInt32)
+						Minimum(-1 << 40)
+					})
+				}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring("does not fit in the range of int32 values"))
+			})
+		})
+
+		Context("with a numeric format on an incompatible type", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, Number, func() {
+						Format(FormatInt64)
+					})
+				}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+			})
+		})
+
 		Context("with a valid min length validation", func() {
 			BeforeEach(func() {
 				dsl = func() {
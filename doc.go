@@ -110,5 +110,8 @@ input (Consumes) and output (Produces). goagen uses that information to registed
 packages with the service encoders and decoders via their Register methods. The service exposes the
 DecodeRequest and EncodeResponse that implement a simple content type negotiation algorithm for
 picking the right encoder for the "Content-Type" (decoder) or "Accept" (encoder) request header.
+The encoder honors the q-values, wildcards and parameters of the Accept header media ranges, sets
+the response "Content-Type" and "Vary" headers and responds with 406 Not Acceptable when none of
+the registered content types is acceptable.
 */
 package goa
@@ -1,12 +1,15 @@
 package goa
 
 import (
+	"bytes"
 	"encoding/gob"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -59,14 +62,23 @@ type (
 	// HTTPDecoder is a Decoder that decodes HTTP request or response bodies given a set of
 	// known Content-Type to decoder mapping.
 	HTTPDecoder struct {
+		// MaxDepth is the maximum nesting depth of the objects and arrays of JSON bodies.
+		// Bodies that exceed it are rejected with ErrNestingTooDeep. Set to 0 to remove the
+		// limit altogether, the default.
+		MaxDepth int
+		// DisallowUnknownFields causes JSON bodies that contain fields that do not exist in
+		// the decoded type to be rejected with ErrUnknownField.
+		DisallowUnknownFields bool
+
 		pools map[string]*decoderPool // Registered decoders
 	}
 
 	// HTTPEncoder is a Encoder that encodes HTTP request or response bodies given a set of
 	// known Content-Type to encoder mapping.
 	HTTPEncoder struct {
-		pools        map[string]*encoderPool // Registered encoders
-		contentTypes []string                // List of content types for type negotiation
+		pools        map[string]*encoderPool      // Registered encoders
+		params       map[string]map[string]string // Registered content type parameters
+		contentTypes []string                     // List of content types for type negotiation
 	}
 )
 
@@ -91,7 +103,8 @@ func NewGobDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
 // NewHTTPEncoder creates an encoder that maps HTTP content types to low level encoders.
 func NewHTTPEncoder() *HTTPEncoder {
 	return &HTTPEncoder{
-		pools: make(map[string]*encoderPool),
+		pools:  make(map[string]*encoderPool),
+		params: make(map[string]map[string]string),
 	}
 }
 
@@ -102,7 +115,8 @@ func NewHTTPDecoder() *HTTPDecoder {
 	}
 }
 
-// Decode uses registered Decoders to unmarshal a body based on the contentType.
+// Decode uses registered Decoders to unmarshal a body based on the contentType. Content types
+// with a +json suffix such as application/problem+json default to the application/json decoder.
 func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType string) error {
 	now := time.Now()
 	defer MeasureSince([]string{"goa", "decode", contentType}, now)
@@ -116,12 +130,25 @@ func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType st
 		}
 	}
 	p = decoder.pools[contentType]
+	if p == nil && isJSON(contentType) {
+		p = decoder.pools["application/json"]
+	}
 	if p == nil {
 		p = decoder.pools["*/*"]
 	}
 	if p == nil {
 		return nil
 	}
+	if (decoder.MaxDepth > 0 || decoder.DisallowUnknownFields) && isJSON(contentType) {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if err := checkJSON(data, v, decoder.MaxDepth, decoder.DisallowUnknownFields); err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
 
 	// the decoderPool will handle whether or not a pool is actually in use
 	d := p.Get(body)
@@ -143,6 +170,11 @@ func (decoder *HTTPDecoder) Register(f DecoderFunc, contentTypes ...string) {
 	}
 }
 
+// isJSON returns true if the given media type identifies JSON documents.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
 // newDecodePool checks to see if the DecoderFunc returns reusable decoders and if so, creates a
 // pool.
 func newDecodePool(f DecoderFunc) *decoderPool {
@@ -182,25 +214,23 @@ func (p *decoderPool) Put(d Decoder) {
 	p.pool.Put(d)
 }
 
-// Encode uses the registered encoders and given content type to marshal and write the given value
-// using the given writer.
+// Encode uses the registered encoders and given Accept header value to marshal and write the
+// given value using the given writer. See Negotiate for a description of how the encoder is
+// selected.
 func (encoder *HTTPEncoder) Encode(v interface{}, resp io.Writer, accept string) error {
-	now := time.Now()
-	if accept == "" {
-		accept = "*/*"
-	}
-	var contentType string
-	for _, t := range encoder.contentTypes {
-		if accept == "*/*" || accept == t {
-			contentType = accept
-			break
-		}
+	contentType, err := encoder.Negotiate(accept)
+	if err != nil {
+		return err
 	}
+	return encoder.encode(v, resp, contentType)
+}
+
+// encode marshals and writes the given value using the encoder registered for the given
+// negotiated content type.
+func (encoder *HTTPEncoder) encode(v interface{}, resp io.Writer, contentType string) error {
+	now := time.Now()
 	defer MeasureSince([]string{"goa", "encode", contentType}, now)
-	p := encoder.pools[contentType]
-	if p == nil && contentType != "*/*" {
-		p = encoder.pools["*/*"]
-	}
+	p := encoder.pool(contentType)
 	if p == nil {
 		return fmt.Errorf("No encoder registered for %s and no default encoder", contentType)
 	}
@@ -216,21 +246,25 @@ func (encoder *HTTPEncoder) Encode(v interface{}, resp io.Writer, accept string)
 }
 
 // Register sets a specific encoder to be used for the specified content types. If an encoder is
-// already registered, it is overwritten.
+// already registered, it is overwritten. Content types registered first are preferred when the
+// Accept header gives the same preference to multiple content types. The parameters of the
+// content types are matched against the parameters of the Accept header media ranges.
 func (encoder *HTTPEncoder) Register(f EncoderFunc, contentTypes ...string) {
 	p := newEncodePool(f)
 	for _, contentType := range contentTypes {
-		mediaType, _, err := mime.ParseMediaType(contentType)
+		mediaType, params, err := mime.ParseMediaType(contentType)
 		if err != nil {
 			mediaType = contentType
 		}
+		if _, ok := encoder.pools[mediaType]; !ok && mediaType != "*/*" {
+			encoder.contentTypes = append(encoder.contentTypes, mediaType)
+		}
 		encoder.pools[mediaType] = p
-	}
-
-	// Rebuild a unique index of registered content encoders to be used in EncodeResponse
-	encoder.contentTypes = make([]string, 0, len(encoder.pools))
-	for contentType := range encoder.pools {
-		encoder.contentTypes = append(encoder.contentTypes, contentType)
+		if len(params) > 0 {
+			encoder.params[mediaType] = params
+		} else {
+			delete(encoder.params, mediaType)
+		}
 	}
 }
 
@@ -0,0 +1,237 @@
+package goa
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// acceptRange is a media range listed in an Accept header.
+	acceptRange struct {
+		typ, sub string
+		params   map[string]string
+		q        float64
+	}
+
+	// acceptCandidate is a content type that may be used to encode a response.
+	acceptCandidate struct {
+		contentType string
+		q           float64
+		specificity int
+		order       int
+	}
+)
+
+// Negotiate returns the registered content type that best matches the given Accept header value
+// as described in RFC 7231 section 5.3.2: media ranges are ordered by q-value, a range applies
+// to the content types it matches most specifically (type/subtype and parameters, then type/*,
+// then */*) and a q-value of 0 makes a content type not acceptable. An empty header accepts any
+// content type. Media ranges that use a structured syntax suffix such as application/vnd.api+json
+// are only acceptable if they are registered, see NegotiateFor.
+//
+// Negotiate returns "*/*" if the best match is only accepted through the */* media range and a
+// default encoder is registered so that the default encoder is used. It returns an
+// ErrNotAcceptable error if no registered content type is acceptable.
+func (encoder *HTTPEncoder) Negotiate(accept string) (string, error) {
+	return encoder.NegotiateFor(accept, "")
+}
+
+// NegotiateFor works like Negotiate but also accepts the media range that is equal to declared,
+// the media type the action declares for the response, if it uses a structured syntax suffix
+// (e.g. application/vnd.api+json). The response is then encoded by the encoder registered for the
+// suffix (application/json) and the returned content type is the media range. Other media ranges
+// that use a suffix are ignored so that for example a browser asking for application/xhtml+xml
+// does not get the output of the XML encoder labeled as XHTML.
+func (encoder *HTTPEncoder) NegotiateFor(accept, declared string) (string, error) {
+	if len(encoder.pools) == 0 {
+		return "", fmt.Errorf("no encoder registered")
+	}
+	if accept == "" {
+		accept = "*/*"
+	}
+	ranges := parseAccept(accept)
+	if mt, _, err := mime.ParseMediaType(declared); err == nil {
+		declared = mt
+	} else {
+		declared = ""
+	}
+	_, hasDefault := encoder.pools["*/*"]
+	var candidates []*acceptCandidate
+	for i, ct := range encoder.contentTypes {
+		if c := matchAccept(ranges, ct, encoder.params[ct]); c != nil {
+			c.order = i
+			candidates = append(candidates, c)
+		}
+	}
+	for i, r := range ranges {
+		idx := strings.LastIndex(r.sub, "+")
+		if idx < 0 || r.q <= 0 {
+			continue
+		}
+		ct := r.typ + "/" + r.sub
+		if !strings.EqualFold(ct, declared) {
+			continue
+		}
+		if _, ok := encoder.pools[ct]; ok {
+			continue
+		}
+		if _, ok := encoder.pools["application/"+r.sub[idx+1:]]; ok {
+			candidates = append(candidates, &acceptCandidate{
+				contentType: ct,
+				q:           r.q,
+				specificity: 3,
+				order:       len(encoder.contentTypes) + i,
+			})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.q != cj.q {
+			return ci.q > cj.q
+		}
+		if ci.specificity != cj.specificity {
+			return ci.specificity > cj.specificity
+		}
+		return ci.order < cj.order
+	})
+	if len(candidates) == 0 || candidates[0].q <= 0 {
+		if hasDefault && len(encoder.contentTypes) == 0 {
+			return "*/*", nil
+		}
+		return "", ErrNotAcceptable("no acceptable content type", "accept", accept,
+			"available", strings.Join(encoder.contentTypes, ", "))
+	}
+	if hasDefault && candidates[0].specificity == 1 {
+		return "*/*", nil
+	}
+	return candidates[0].contentType, nil
+}
+
+// pool returns the encoder pool used to encode the given negotiated content type.
+func (encoder *HTTPEncoder) pool(contentType string) *encoderPool {
+	if p := encoder.specificPool(contentType); p != nil {
+		return p
+	}
+	return encoder.pools["*/*"]
+}
+
+// specificPool returns the encoder pool registered for the given media type or for its structured
+// syntax suffix, nil if there is none.
+func (encoder *HTTPEncoder) specificPool(mediaType string) *encoderPool {
+	if mediaType == "*/*" {
+		return nil
+	}
+	if p, ok := encoder.pools[mediaType]; ok {
+		return p
+	}
+	if idx := strings.LastIndex(mediaType, "+"); idx >= 0 {
+		return encoder.pools["application/"+mediaType[idx+1:]]
+	}
+	return nil
+}
+
+// responseContentType returns the value of the Content-Type header of a response encoded with
+// the negotiated content type given the value ct already set by the handler. The handler value
+// is kept unless it is empty or identifies a content type encoded with a different encoder.
+func (encoder *HTTPEncoder) responseContentType(ct, negotiated string) string {
+	if negotiated == "*/*" || negotiated == "" {
+		return ct
+	}
+	if ct == "" {
+		return negotiated
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return negotiated
+	}
+	if p := encoder.specificPool(mt); p != nil && p != encoder.pool(negotiated) {
+		return negotiated
+	}
+	return ct
+}
+
+// matchAccept returns the quality of the content type ct with parameters params given the media
+// ranges of an Accept header, nil if no range matches ct.
+func matchAccept(ranges []*acceptRange, ct string, params map[string]string) *acceptCandidate {
+	elems := strings.SplitN(ct, "/", 2)
+	if len(elems) != 2 {
+		return nil
+	}
+	typ, sub := elems[0], elems[1]
+	var best *acceptCandidate
+	for _, r := range ranges {
+		var spec int
+		switch {
+		case r.typ == "*" && r.sub == "*":
+			spec = 1
+		case r.typ == typ && r.sub == "*":
+			spec = 2
+		case r.typ == typ && r.sub == sub:
+			if !matchParams(r.params, params) {
+				continue
+			}
+			spec = 3 + len(r.params)
+		default:
+			continue
+		}
+		if best == nil || spec > best.specificity {
+			best = &acceptCandidate{contentType: ct, q: r.q, specificity: spec}
+		}
+	}
+	return best
+}
+
+// matchParams returns true if the media range parameters are all defined by the content type.
+// The charset parameter matches UTF-8 for content types that do not define it.
+func matchParams(rparams, params map[string]string) bool {
+	for k, v := range rparams {
+		if pv, ok := params[k]; ok {
+			if !strings.EqualFold(pv, v) {
+				return false
+			}
+			continue
+		}
+		if k == "charset" && strings.EqualFold(v, "utf-8") {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// parseAccept parses the media ranges listed in an Accept header value. Invalid ranges are
+// ignored.
+func parseAccept(accept string) []*acceptRange {
+	var ranges []*acceptRange
+	for _, elem := range strings.Split(accept, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+		mt, params, err := mime.ParseMediaType(elem)
+		if err != nil {
+			continue
+		}
+		elems := strings.SplitN(mt, "/", 2)
+		if len(elems) != 2 || elems[0] == "*" && elems[1] != "*" {
+			continue
+		}
+		r := &acceptRange{typ: elems[0], sub: elems[1], q: 1}
+		if q, ok := params["q"]; ok {
+			v, err := strconv.ParseFloat(q, 64)
+			if err != nil || v < 0 || v > 1 {
+				continue
+			}
+			r.q = v
+			delete(params, "q")
+		}
+		if len(params) > 0 {
+			r.params = params
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
@@ -0,0 +1,171 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// jsonChecker walks the tokens of a JSON document alongside the type of the value it is decoded
+// into to enforce the HTTPDecoder MaxDepth and DisallowUnknownFields settings.
+type jsonChecker struct {
+	dec      *json.Decoder
+	maxDepth int
+	strict   bool
+}
+
+var (
+	// jsonUnmarshalerType is the type of the json.Unmarshaler interface.
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+	// jsonFieldsCache caches the JSON fields of struct types, see jsonFields.
+	jsonFieldsCache sync.Map // reflect.Type -> map[string]reflect.Type
+)
+
+// checkJSON checks that the JSON document data does not nest objects and arrays deeper than
+// maxDepth and, if strict is true, that it only contains fields that exist in the type of v. A
+// maxDepth of 0 means no limit. Syntax errors are left for the decoder to report.
+func checkJSON(data []byte, v interface{}, maxDepth int, strict bool) error {
+	c := &jsonChecker{dec: json.NewDecoder(bytes.NewReader(data)), maxDepth: maxDepth, strict: strict}
+	c.dec.UseNumber()
+	var t reflect.Type
+	if v != nil {
+		t = reflect.TypeOf(v)
+	}
+	err := c.value(t, "", 0)
+	if _, ok := err.(ServiceError); ok {
+		return err
+	}
+	return nil
+}
+
+// value checks the next value read from the document, path is the path to the value and depth
+// the number of objects and arrays that contain it. t is the type of the Go value the JSON
+// value is decoded into, nil if any value is accepted.
+func (c *jsonChecker) value(t reflect.Type, path string, depth int) error {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	depth++
+	if c.maxDepth > 0 && depth > c.maxDepth {
+		msg := fmt.Sprintf("JSON document nesting exceeds the maximum depth of %d", c.maxDepth)
+		return ErrNestingTooDeep(msg, "field", path, "max_depth", c.maxDepth)
+	}
+	t = jsonTarget(t)
+	switch delim {
+	case '{':
+		for c.dec.More() {
+			tok, err := c.dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := tok.(string)
+			fpath := key
+			if path != "" {
+				fpath = path + "." + key
+			}
+			var ft reflect.Type
+			if t != nil {
+				switch t.Kind() {
+				case reflect.Struct:
+					var ok bool
+					if ft, ok = jsonFields(t)[strings.ToLower(key)]; !ok && c.strict {
+						return ErrUnknownField(fmt.Sprintf("unknown field %q", fpath), "field", fpath)
+					}
+				case reflect.Map:
+					ft = t.Elem()
+				}
+			}
+			if err := c.value(ft, fpath, depth); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var et reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+		for i := 0; c.dec.More(); i++ {
+			if err := c.value(et, fmt.Sprintf("%s[%d]", path, i), depth); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = c.dec.Token() // closing delimiter
+	return err
+}
+
+// jsonTarget dereferences t and returns nil if t accepts any JSON value, that is if it is an
+// interface or implements json.Unmarshaler.
+func jsonTarget(t reflect.Type) reflect.Type {
+	for t != nil {
+		if t.Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Ptr:
+			t = t.Elem()
+		case reflect.Interface:
+			return nil
+		default:
+			return t
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the types of the fields of the struct type t indexed by lower case JSON
+// name. Fields of embedded structs are included the same way encoding/json promotes them.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if fields, ok := jsonFieldsCache.Load(t); ok {
+		return fields.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type)
+	collectJSONFields(t, fields)
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// collectJSONFields adds the JSON fields of the struct type t to fields. Fields that are already
+// present take precedence so that outer fields shadow the fields of embedded structs.
+func collectJSONFields(t reflect.Type, fields map[string]reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if f.PkgPath != "" && !f.Anonymous {
+			continue // unexported
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := fields[strings.ToLower(name)]; !ok {
+			fields[strings.ToLower(name)] = f.Type
+		}
+	}
+	for _, et := range embedded {
+		collectJSONFields(et, fields)
+	}
+}
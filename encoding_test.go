@@ -0,0 +1,279 @@
+package goa_test
+
+import (
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	decodedBase struct {
+		ID string `json:"id"`
+	}
+
+	decodedItem struct {
+		Name string `json:"name,omitempty"`
+	}
+
+	decodedPayload struct {
+		decodedBase
+		Title   *string                `json:"title,omitempty"`
+		Items   []*decodedItem         `json:"items,omitempty"`
+		Labels  map[string]decodedItem `json:"labels,omitempty"`
+		Extra   interface{}            `json:"extra,omitempty"`
+		Ignored string                 `json:"-"`
+	}
+)
+
+var _ = Describe("HTTPDecoder", func() {
+	var decoder *goa.HTTPDecoder
+	var body string
+	var payload *decodedPayload
+	var err error
+
+	BeforeEach(func() {
+		decoder = goa.NewHTTPDecoder()
+		decoder.Register(goa.NewJSONDecoder, "application/json")
+		payload = nil
+	})
+
+	JustBeforeEach(func() {
+		err = decoder.Decode(&payload, strings.NewReader(body), "application/json; charset=utf-8")
+	})
+
+	Context("with a maximum depth", func() {
+		BeforeEach(func() {
+			decoder.MaxDepth = 3
+		})
+
+		Context("and a body within the limit", func() {
+			BeforeEach(func() {
+				body = `{"items": [{"name": "a"}]}`
+			})
+
+			It("decodes the body", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(payload.Items).Should(HaveLen(1))
+				Ω(payload.Items[0].Name).Should(Equal("a"))
+			})
+		})
+
+		Context("and a body nested too deep", func() {
+			BeforeEach(func() {
+				body = `{"extra": {"a": [{"b": 1}]}}`
+			})
+
+			It("returns an error with the field path", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(400))
+				resp := err.(*goa.ErrorResponse)
+				Ω(resp.Code).Should(Equal("nesting_too_deep"))
+				Ω(resp.Meta).Should(HaveKeyWithValue("field", "extra.a[0]"))
+				Ω(resp.Meta).Should(HaveKeyWithValue("max_depth", 3))
+			})
+		})
+	})
+
+	Context("disallowing unknown fields", func() {
+		BeforeEach(func() {
+			decoder.DisallowUnknownFields = true
+		})
+
+		Context("with known fields", func() {
+			BeforeEach(func() {
+				body = `{"ID": "1", "title": "t", "labels": {"x": {"name": "l"}}, "extra": {"any": {"thing": true}}}`
+			})
+
+			It("decodes the body", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(payload.ID).Should(Equal("1"))
+				Ω(*payload.Title).Should(Equal("t"))
+				Ω(payload.Labels["x"].Name).Should(Equal("l"))
+			})
+		})
+
+		Context("with an unknown nested field", func() {
+			BeforeEach(func() {
+				body = `{"items": [{"name": "a"}, {"nme": "b"}]}`
+			})
+
+			It("returns an error with the field path", func() {
+				Ω(err).Should(HaveOccurred())
+				resp := err.(*goa.ErrorResponse)
+				Ω(resp.Code).Should(Equal("unknown_field"))
+				Ω(resp.Status).Should(Equal(400))
+				Ω(resp.Meta).Should(HaveKeyWithValue("field", "items[1].nme"))
+			})
+		})
+
+		Context("with an ignored field", func() {
+			BeforeEach(func() {
+				body = `{"ignored": "x"}`
+			})
+
+			It("returns an error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(*goa.ErrorResponse).Meta).Should(HaveKeyWithValue("field", "ignored"))
+			})
+		})
+
+		Context("with an invalid body", func() {
+			BeforeEach(func() {
+				body = `{"title": `
+			})
+
+			It("returns the decoder error", func() {
+				Ω(err).Should(HaveOccurred())
+				_, ok := err.(goa.ServiceError)
+				Ω(ok).Should(BeFalse())
+			})
+		})
+	})
+})
+
+var _ = Describe("HTTPEncoder", func() {
+	var encoder *goa.HTTPEncoder
+	var accept string
+	var contentType string
+	var err error
+
+	BeforeEach(func() {
+		encoder = goa.NewHTTPEncoder()
+		encoder.Register(goa.NewJSONEncoder, "application/json")
+		encoder.Register(goa.NewXMLEncoder, "application/xml", "text/xml; charset=utf-8")
+		accept = ""
+	})
+
+	JustBeforeEach(func() {
+		contentType, err = encoder.Negotiate(accept)
+	})
+
+	Context("with no Accept header", func() {
+		It("picks the first registered content type", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/json"))
+		})
+	})
+
+	Context("with q-values", func() {
+		BeforeEach(func() {
+			accept = "application/json;q=0.9, application/xml"
+		})
+
+		It("picks the preferred content type", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/xml"))
+		})
+	})
+
+	Context("with a type wildcard", func() {
+		BeforeEach(func() {
+			accept = "text/*, application/json;q=0.5"
+		})
+
+		It("picks a matching content type", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("text/xml"))
+		})
+	})
+
+	Context("with a more specific range excluding a content type", func() {
+		BeforeEach(func() {
+			accept = "application/*, application/json;q=0"
+		})
+
+		It("uses the most specific range", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/xml"))
+		})
+	})
+
+	Context("with media type parameters", func() {
+		BeforeEach(func() {
+			accept = "text/xml; charset=iso-8859-1, application/json;q=0.1"
+		})
+
+		It("matches the registered parameters", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/json"))
+		})
+	})
+
+	Context("with a structured syntax suffix", func() {
+		BeforeEach(func() {
+			accept = "application/vnd.goa.example+json"
+		})
+
+		It("does not accept the media range", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(406))
+		})
+
+		It("uses the encoder registered for the suffix for the declared media type", func() {
+			contentType, err = encoder.NegotiateFor(accept, "application/vnd.goa.example+json; type=collection")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/vnd.goa.example+json"))
+		})
+	})
+
+	Context("with a browser Accept header", func() {
+		BeforeEach(func() {
+			accept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+		})
+
+		It("does not serve XML as XHTML", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/xml"))
+		})
+
+		It("ignores suffix ranges that differ from the declared media type", func() {
+			contentType, err = encoder.NegotiateFor(accept, "application/vnd.goa.example+json")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/xml"))
+		})
+	})
+
+	Context("with no acceptable content type", func() {
+		BeforeEach(func() {
+			accept = "text/html, application/json;q=0"
+		})
+
+		It("returns a not acceptable error", func() {
+			Ω(err).Should(HaveOccurred())
+			resp := err.(*goa.ErrorResponse)
+			Ω(resp.Status).Should(Equal(406))
+			Ω(resp.Code).Should(Equal("not_acceptable"))
+			Ω(resp.Meta).Should(HaveKeyWithValue("available", "application/json, application/xml, text/xml"))
+		})
+	})
+
+	Context("with a default encoder", func() {
+		BeforeEach(func() {
+			encoder.Register(goa.NewJSONEncoder, "*/*")
+		})
+
+		Context("and a */* Accept header", func() {
+			BeforeEach(func() {
+				accept = "*/*"
+			})
+
+			It("uses the default encoder", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(contentType).Should(Equal("*/*"))
+			})
+		})
+
+		Context("and an unknown Accept header", func() {
+			BeforeEach(func() {
+				accept = "text/html"
+			})
+
+			It("returns a not acceptable error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(406))
+			})
+		})
+	})
+})
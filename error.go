@@ -26,6 +26,10 @@ previously called middleware or action handler into HTTP responses. If the error
 error class then the corresponding content including the HTTP status is used otherwise an internal
 error is returned. Errors that bubble up all the way to the top (i.e. not handled by the error
 middleware) also generate an internal error response.
+
+Services may render errors as RFC 7807 problem details documents (application/problem+json)
+instead by setting their ProblemDetails field, see Problem. The error code is then rendered as the
+problem type, the detail as the problem detail and the ID and metadata as extension members.
 */
 package goa
 
@@ -58,6 +62,14 @@ var (
 	// MaxRequestBodyLength bytes.
 	ErrRequestBodyTooLarge = NewErrorClass("request_too_large", 413)
 
+	// ErrNestingTooDeep is the error produced when a JSON request body nests objects or
+	// arrays deeper than the decoder MaxDepth.
+	ErrNestingTooDeep = NewErrorClass("nesting_too_deep", 400)
+
+	// ErrUnknownField is the error produced when a JSON request body contains a field that
+	// does not exist in the payload type and the decoder disallows unknown fields.
+	ErrUnknownField = NewErrorClass("unknown_field", 400)
+
 	// ErrNoAuthMiddleware is the error produced when no auth middleware is mounted for a
 	// security scheme defined in the design.
 	ErrNoAuthMiddleware = NewErrorClass("no_auth_middleware", 500)
@@ -73,6 +85,17 @@ var (
 	// handler but not the HTTP method.
 	ErrMethodNotAllowed = NewErrorClass("method_not_allowed", 405)
 
+	// ErrNotAcceptable is the error returned to requests whose Accept header does not match any
+	// of the content types the service can encode.
+	ErrNotAcceptable = NewErrorClass("not_acceptable", 406)
+
+	// ErrConflict is the error returned to requests that conflict with another request being
+	// processed, e.g. a retry sent while the original request is still running.
+	ErrConflict = NewErrorClass("conflict", 409)
+
+	// ErrTooManyRequests is the error returned to requests rejected by a rate limiter.
+	ErrTooManyRequests = NewErrorClass("too_many_requests", 429)
+
 	// ErrInternal is the class of error used for uncaught errors.
 	ErrInternal = NewErrorClass("internal", 500)
 )
@@ -0,0 +1,110 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type (
+	// HookPoint is the name of a location in the code produced by a generator where plugins may
+	// inject code. Generators document the hook points they expose together with the template
+	// data given to the hooks and the kind of code expected (declarations, struct fields or
+	// statements).
+	HookPoint string
+
+	// Hook is code injected by a plugin at a generator hook point.
+	Hook struct {
+		// Name identifies the hook in error messages.
+		Name string
+		// Imports lists the packages used by the injected code.
+		Imports []*ImportSpec
+		// Code returns the code injected at the hook point given the template data of the
+		// generated section. It may return the empty string to leave the section unchanged.
+		Code func(data interface{}) (string, error)
+	}
+)
+
+var (
+	// hooks contains the registered hooks indexed by hook point.
+	hooks = make(map[HookPoint][]*Hook)
+	// hooksMu protects hooks.
+	hooksMu sync.RWMutex
+)
+
+// RegisterHook registers a hook that injects code at the given hook point. Hooks registered at
+// the same point run in registration order. Plugins typically call RegisterHook from an init
+// function or from their Generate function prior to calling the generator they extend.
+func RegisterHook(point HookPoint, hook *Hook) {
+	if hook == nil || hook.Code == nil {
+		panic("codegen: invalid hook registered for " + string(point)) // bug
+	}
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks[point] = append(hooks[point], hook)
+}
+
+// ResetHooks removes all the registered hooks.
+func ResetHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = make(map[HookPoint][]*Hook)
+}
+
+// RunHooks returns the code produced by the hooks registered at the given point concatenated in
+// registration order. Each hook output is terminated by a newline.
+func RunHooks(point HookPoint, data interface{}) (string, error) {
+	hooksMu.RLock()
+	registered := hooks[point]
+	hooksMu.RUnlock()
+	var code []string
+	for _, h := range registered {
+		c, err := h.Code(data)
+		if err != nil {
+			return "", fmt.Errorf("hook %s at %s: %s", h.Name, point, err)
+		}
+		if c == "" {
+			continue
+		}
+		if !strings.HasSuffix(c, "\n") {
+			c += "\n"
+		}
+		code = append(code, c)
+	}
+	return strings.Join(code, ""), nil
+}
+
+// HookImports appends the imports of the hooks registered at the given points to imports
+// skipping the packages already imported.
+func HookImports(imports []*ImportSpec, points ...HookPoint) []*ImportSpec {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	for _, point := range points {
+		for _, h := range hooks[point] {
+			for _, imp := range h.Imports {
+				imports = appendImport(imports, imp)
+			}
+		}
+	}
+	return imports
+}
+
+// appendImport appends imp to imports unless a spec with the same path and name already exists.
+func appendImport(imports []*ImportSpec, imp *ImportSpec) []*ImportSpec {
+	for _, i := range imports {
+		if i.Path == imp.Path && i.Name == imp.Name {
+			return imports
+		}
+	}
+	return append(imports, imp)
+}
+
+// WriteHooks writes the code produced by the hooks registered at the given point to the file.
+func (f *SourceFile) WriteHooks(point HookPoint, data interface{}) error {
+	code, err := RunHooks(point, data)
+	if err != nil || code == "" {
+		return err
+	}
+	_, err = f.Write([]byte("\n" + code))
+	return err
+}
@@ -0,0 +1,78 @@
+package codegen_test
+
+import (
+	"errors"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hooks", func() {
+	const point codegen.HookPoint = "test:point"
+
+	AfterEach(func() {
+		codegen.ResetHooks()
+	})
+
+	Context("with no registered hook", func() {
+		It("produces no code", func() {
+			code, err := codegen.RunHooks(point, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(BeEmpty())
+		})
+	})
+
+	Context("with registered hooks", func() {
+		BeforeEach(func() {
+			codegen.RegisterHook(point, &codegen.Hook{
+				Name:    "first",
+				Imports: []*codegen.ImportSpec{codegen.SimpleImport("fmt")},
+				Code: func(data interface{}) (string, error) {
+					return "a := " + data.(string), nil
+				},
+			})
+			codegen.RegisterHook(point, &codegen.Hook{
+				Name:    "second",
+				Imports: []*codegen.ImportSpec{codegen.SimpleImport("fmt"), codegen.SimpleImport("time")},
+				Code: func(interface{}) (string, error) {
+					return "b := a\n", nil
+				},
+			})
+		})
+
+		It("concatenates the code in registration order", func() {
+			code, err := codegen.RunHooks(point, "1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal("a := 1\nb := a\n"))
+		})
+
+		It("returns the hooks imports", func() {
+			imports := codegen.HookImports([]*codegen.ImportSpec{codegen.SimpleImport("time")}, point)
+			Ω(imports).Should(HaveLen(2))
+			Ω(imports[0].Path).Should(Equal("time"))
+			Ω(imports[1].Path).Should(Equal("fmt"))
+		})
+
+		It("is available to templates", func() {
+			code, err := codegen.RunHooks("test:other", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(BeEmpty())
+			Ω(codegen.DefaultFuncMap).Should(HaveKey("hook"))
+		})
+	})
+
+	Context("with a failing hook", func() {
+		BeforeEach(func() {
+			codegen.RegisterHook(point, &codegen.Hook{
+				Name: "failing",
+				Code: func(interface{}) (string, error) { return "", errors.New("boom") },
+			})
+		})
+
+		It("returns an error identifying the hook", func() {
+			_, err := codegen.RunHooks(point, nil)
+			Ω(err).Should(MatchError("hook failing at test:point: boom"))
+		})
+	})
+})
@@ -73,6 +73,9 @@ func GoTypeDef(ds design.DataStructure, tabs int, jsonTags, private bool) string
 	t := def.Type
 	switch actual := t.(type) {
 	case design.Primitive:
+		if name := goFormatType(def); name != "" {
+			return name
+		}
 		return GoTypeName(t, nil, tabs, private)
 	case *design.Array:
 		d := GoTypeDef(actual.ElemType, tabs, jsonTags, private)
@@ -197,7 +200,7 @@ func GoTypeName(t design.DataType, required []string, tabs int, private bool) st
 	case design.Primitive:
 		return GoNativeType(t)
 	case *design.Array:
-		return "[]" + GoTypeRef(actual.ElemType.Type, actual.ElemType.AllRequired(), tabs+1, private)
+		return "[]" + GoTypeRefAtt(actual.ElemType, tabs+1, private)
 	case design.Object:
 		att := &design.AttributeDefinition{Type: actual}
 		if len(required) > 0 {
@@ -208,8 +211,8 @@ func GoTypeName(t design.DataType, required []string, tabs int, private bool) st
 	case *design.Hash:
 		return fmt.Sprintf(
 			"map[%s]%s",
-			GoTypeRef(actual.KeyType.Type, actual.KeyType.AllRequired(), tabs+1, private),
-			GoTypeRef(actual.ElemType.Type, actual.ElemType.AllRequired(), tabs+1, private),
+			GoTypeRefAtt(actual.KeyType, tabs+1, private),
+			GoTypeRefAtt(actual.ElemType, tabs+1, private),
 		)
 	case *design.UserTypeDefinition:
 		return Goify(actual.TypeName, !private)
@@ -248,11 +251,11 @@ func GoNativeType(t design.DataType) string {
 			panic(fmt.Sprintf("goa bug: unknown primitive type %#v", actual))
 		}
 	case *design.Array:
-		return "[]" + GoNativeType(actual.ElemType.Type)
+		return "[]" + GoNativeTypeAtt(actual.ElemType)
 	case design.Object:
 		return "map[string]interface{}"
 	case *design.Hash:
-		return fmt.Sprintf("map[%s]%s", GoNativeType(actual.KeyType.Type), GoNativeType(actual.ElemType.Type))
+		return fmt.Sprintf("map[%s]%s", GoNativeTypeAtt(actual.KeyType), GoNativeTypeAtt(actual.ElemType))
 	case *design.MediaTypeDefinition:
 		return GoNativeType(actual.Type)
 	case *design.UserTypeDefinition:
@@ -262,6 +265,38 @@ func GoNativeType(t design.DataType) string {
 	}
 }
 
+// GoTypeRefAtt returns the Go code that refers to the Go type of the given attribute. It behaves
+// like GoTypeRef but takes into account the bit-width format of Integer and Number attributes.
+func GoTypeRefAtt(att *design.AttributeDefinition, tabs int, private bool) string {
+	if name := goFormatType(att); name != "" {
+		return name
+	}
+	return GoTypeRef(att.Type, att.AllRequired(), tabs, private)
+}
+
+// GoNativeTypeAtt returns the Go built-in type from which values of the given attribute can be
+// initialized. It behaves like GoNativeType but takes into account the bit-width format of
+// Integer and Number attributes.
+func GoNativeTypeAtt(att *design.AttributeDefinition) string {
+	if name := goFormatType(att); name != "" {
+		return name
+	}
+	return GoNativeType(att.Type)
+}
+
+// goFormatType returns the Go type of Integer and Number attributes that define a bit-width
+// format, the empty string otherwise.
+func goFormatType(att *design.AttributeDefinition) string {
+	switch format := att.NumericFormat(); format {
+	case "":
+		return ""
+	case design.FormatFloat:
+		return "float32"
+	default:
+		return format
+	}
+}
+
 // GoTypeDesc returns the description of a type.  If no description is defined
 // for the type, one will be generated.
 func GoTypeDesc(t design.DataType, upper bool) string {
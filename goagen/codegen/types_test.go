@@ -214,6 +214,18 @@ var _ = Describe("code generation", func() {
 					Ω(st).Should(Equal(expected))
 				})
 
+				Context("with numeric formats", func() {
+					BeforeEach(func() {
+						object["foo"].Validation = &dslengine.ValidationDefinition{Format: FormatUint64}
+						object["bar"] = &AttributeDefinition{Type: Number, Validation: &dslengine.ValidationDefinition{Format: FormatFloat}}
+					})
+
+					It("uses the sized Go types", func() {
+						Ω(st).Should(ContainSubstring("	Foo *uint64 `"))
+						Ω(st).Should(ContainSubstring("	Bar *float32 `"))
+					})
+				})
+
 				Context("using struct tags metadata", func() {
 					tn1 := "struct:tag:foo"
 					tv11 := "bar"
@@ -355,13 +355,17 @@ func ValidationChecker(att *design.AttributeDefinition, nonzero, required, hasDe
 
 func validationsCode(att *design.AttributeDefinition, data map[string]interface{}) (res []string) {
 	validation := att.Validation
+	// Bit-width formats are enforced by the Go type, so are range checks that cover all the
+	// values of the type.
+	numFormat := att.NumericFormat()
+	fmin, fmax, hasRange := design.NumericFormatRange(numFormat)
 	if values := validation.Values; values != nil {
 		data["values"] = values
 		if val := RunTemplate(enumValT, data); val != "" {
 			res = append(res, val)
 		}
 	}
-	if format := validation.Format; format != "" {
+	if format := validation.Format; format != "" && numFormat == "" {
 		data["format"] = format
 		if val := RunTemplate(formatValT, data); val != "" {
 			res = append(res, val)
@@ -373,7 +377,7 @@ func validationsCode(att *design.AttributeDefinition, data map[string]interface{
 			res = append(res, val)
 		}
 	}
-	if min := validation.Minimum; min != nil {
+	if min := validation.Minimum; min != nil && (!hasRange || *min > fmin) {
 		if att.Type == design.Integer {
 			data["min"] = renderInteger(*min)
 		} else {
@@ -385,7 +389,7 @@ func validationsCode(att *design.AttributeDefinition, data map[string]interface{
 			res = append(res, val)
 		}
 	}
-	if max := validation.Maximum; max != nil {
+	if max := validation.Maximum; max != nil && (!hasRange || *max < fmax) {
 		if att.Type == design.Integer {
 			data["max"] = renderInteger(*max)
 		} else {
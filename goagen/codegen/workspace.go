@@ -69,6 +69,8 @@ var (
 		"gotypename":          GoTypeName,
 		"gotypedesc":          GoTypeDesc,
 		"gotyperef":           GoTypeRef,
+		"gotyperefatt":        GoTypeRefAtt,
+		"hook":                RunHooks,
 		"join":                strings.Join,
 		"recursivePublicizer": RecursivePublicizer,
 		"tabs":                Tabs,
@@ -1,5 +1,11 @@
 /*
 Package genapp provides the generator for the handlers, context data structures and tests of a goa
 application. It generates the glue between user code and the low level router.
+
+Plugins may inject code in the generated contexts, controllers and security files by registering
+hooks with codegen.RegisterHook at the hook points defined by this package (HookContextFields,
+HookMountAction etc.). The hooks must be registered before the generator runs: either from the init
+function of a package given to "goagen app --hooks" or from the Generate function of a plugin prior
+to calling genapp.Generate.
 */
 package genapp
@@ -12,6 +12,10 @@ import (
 	"github.com/goadesign/goa/goagen/utils"
 )
 
+// routeMetadataKeys lists the design metadata keys that are copied to the generated routes so
+// that they are available to middlewares at runtime via goa.ContextRoute.
+var routeMetadataKeys = []string{"ratelimit", "request:max_body_length", "idempotency"}
+
 //NewGenerator returns an initialized instance of an Application Generator
 func NewGenerator(options ...Option) *Generator {
 	g := &Generator{}
@@ -145,6 +149,7 @@ func (g *Generator) generateContexts() (err error) {
 	title := fmt.Sprintf("%s: Application Contexts", g.API.Context())
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("io"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
@@ -163,6 +168,8 @@ func (g *Generator) generateContexts() (err error) {
 		})
 	})
 
+	imports = codegen.HookImports(imports, HookContextFields, HookContextNew, HookContextDecls)
+
 	g.genfiles = append(g.genfiles, ctxFile)
 	if err = ctxWr.WriteHeader(title, g.Target, imports); err != nil {
 		return
@@ -196,17 +203,19 @@ func (g *Generator) generateContexts() (err error) {
 				}
 			}
 			ctxData := ContextTemplateData{
-				Name:         ctxName,
-				ResourceName: r.Name,
-				ActionName:   a.Name,
-				Payload:      a.Payload,
-				Params:       params,
-				Headers:      headers,
-				Routes:       a.Routes,
-				Responses:    non101,
-				API:          g.API,
-				DefaultPkg:   g.Target,
-				Security:     a.Security,
+				Name:          ctxName,
+				ResourceName:  r.Name,
+				ActionName:    a.Name,
+				Payload:       a.Payload,
+				PayloadStream: a.PayloadStream,
+				Conditional:   a.Conditional,
+				Params:        params,
+				Headers:       headers,
+				Routes:        a.Routes,
+				Responses:     non101,
+				API:           g.API,
+				DefaultPkg:    g.Target,
+				Security:      a.Security,
 			}
 			return ctxWr.Execute(&ctxData)
 		})
@@ -271,6 +280,7 @@ func (g *Generator) generateControllers() (err error) {
 	for _, packagePath := range packagePaths {
 		imports = append(imports, codegen.SimpleImport(packagePath))
 	}
+	imports = codegen.HookImports(imports, HookMountStart, HookMountAction, HookControllerDecls)
 	if err = ctlWr.WriteHeader(title, g.Target, imports); err != nil {
 		return err
 	}
@@ -310,12 +320,17 @@ func (g *Generator) generateControllers() (err error) {
 			action := map[string]interface{}{
 				"Name":             codegen.Goify(a.Name, true),
 				"DesignName":       a.Name,
+				"Resource":         data.Resource,
+				"Definition":       a,
 				"Routes":           a.Routes,
 				"Context":          context,
 				"Unmarshal":        unmarshal,
 				"Payload":          a.Payload,
 				"PayloadOptional":  a.PayloadOptional,
 				"PayloadMultipart": a.PayloadMultipart,
+				"PayloadStream":    a.PayloadStream,
+				"EventStream":      a.EventStream() != nil,
+				"Metadata":         routeMetadata(a),
 				"Security":         a.Security,
 			}
 			data.Actions = append(data.Actions, action)
@@ -364,6 +379,7 @@ func (g *Generator) generateSecurity() (err error) {
 		codegen.SimpleImport("context"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 	}
+	imports = codegen.HookImports(imports, HookSecurityDecls)
 	if err = secWr.WriteHeader(title, g.Target, imports); err != nil {
 		return err
 	}
@@ -510,3 +526,23 @@ func (g *Generator) generateUserTypes() (err error) {
 	})
 	return
 }
+
+// routeMetadata returns the action metadata listed in routeMetadataKeys, the action inherits the
+// values defined on its resource.
+func routeMetadata(a *design.ActionDefinition) map[string][]string {
+	var md map[string][]string
+	for _, key := range routeMetadataKeys {
+		vals, ok := a.Metadata[key]
+		if !ok && a.Parent != nil {
+			vals, ok = a.Parent.Metadata[key]
+		}
+		if !ok {
+			continue
+		}
+		if md == nil {
+			md = make(map[string][]string)
+		}
+		md[key] = vals
+	}
+	return md
+}
@@ -412,7 +412,7 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		}
 		return ctrl.Get(rctx)
 	}
-	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("get", h, nil))
+	service.Mount(&goa.Route{Method: "GET", Path: "/:id", Controller: "Widget", Action: "get"}, ctrl.MuxHandler("get", h, nil))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 `
@@ -478,7 +478,7 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		}
 		return ctrl.Get(rctx)
 	}
-	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("get", h, unmarshalGetWidgetPayload))
+	service.Mount(&goa.Route{Method: "GET", Path: "/:id", Controller: "Widget", Action: "get"}, ctrl.MuxHandler("get", h, unmarshalGetWidgetPayload))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 
@@ -515,7 +515,7 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		}
 		return ctrl.Get(rctx)
 	}
-	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("get", h, unmarshalGetWidgetPayload))
+	service.Mount(&goa.Route{Method: "GET", Path: "/:id", Controller: "Widget", Action: "get"}, ctrl.MuxHandler("get", h, unmarshalGetWidgetPayload))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 
@@ -554,7 +554,7 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		}
 		return ctrl.Get(rctx)
 	}
-	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("get", h, unmarshalGetWidgetPayload))
+	service.Mount(&goa.Route{Method: "GET", Path: "/:id", Controller: "Widget", Action: "get"}, ctrl.MuxHandler("get", h, unmarshalGetWidgetPayload))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 
@@ -44,6 +44,7 @@ type TestMethod struct {
 	QueryParams       []*ObjectType
 	Headers           []*ObjectType
 	Payload           *ObjectType
+	PayloadStream     bool
 	reservedNames     map[string]bool
 }
 
@@ -126,7 +127,7 @@ func (g *Generator) generateResourceTest() error {
 				}
 				for routeIndex, route := range action.Routes {
 					mediaType := design.Design.MediaTypeWithIdentifier(response.MediaType)
-					if mediaType == nil {
+					if mediaType == nil || response.Stream != 0 {
 						methods = append(methods, g.createTestMethod(res, action, response, route, routeIndex, nil, nil))
 					} else {
 						if err := mediaType.IterateViews(func(view *design.ViewDefinition) error {
@@ -194,21 +195,13 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 		returnType.Validatable = validate != ""
 	}
 
-	comment = "runs the method " + actionName + " of the given controller with the given parameters"
-	if action.Payload != nil {
-		comment += " and payload"
-	}
-	comment += ".\n// It returns the response writer so it's possible to inspect the response headers"
-	if hasReturnValue {
-		comment += " and the media type struct written to the response"
-	}
-	comment += "."
-
 	path = pathParams(action, route)
 	query = queryParams(action)
 	header = headers(action, resource.Headers)
 
-	if action.Payload != nil {
+	if action.PayloadStream != 0 {
+		payload = &ObjectType{Name: "payload", Type: "io.Reader"}
+	} else if action.Payload != nil {
 		payload = &ObjectType{}
 		payload.Name = "payload"
 		payload.Type = fmt.Sprintf("%s.%s", g.Target, codegen.Goify(action.Payload.TypeName, true))
@@ -222,6 +215,16 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 		}
 	}
 
+	comment = "runs the method " + actionName + " of the given controller with the given parameters"
+	if payload != nil {
+		comment += " and payload"
+	}
+	comment += ".\n// It returns the response writer so it's possible to inspect the response headers"
+	if hasReturnValue {
+		comment += " and the media type struct written to the response"
+	}
+	comment += "."
+
 	return &TestMethod{
 		Name:              fmt.Sprintf("%s%s%s%s%s", actionName, ctrlName, respQualifier, routeQualifier, viewQualifier),
 		ActionName:        actionName,
@@ -231,8 +234,9 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 		QueryParams:       query,
 		Headers:           header,
 		Payload:           payload,
+		PayloadStream:     action.PayloadStream != 0,
 		ReturnType:        returnType,
-		ReturnsErrorMedia: mediaType == design.ErrorMedia,
+		ReturnsErrorMedia: mediaType != nil && mediaType.IsError(),
 		ControllerName:    fmt.Sprintf("%s.%sController", g.Target, ctrlName),
 		ContextVarName:    fmt.Sprintf("%sCtx", varName),
 		ContextType:       fmt.Sprintf("%s.New%s%sContext", g.Target, actionName, ctrlName),
@@ -323,7 +327,7 @@ func attToObject(name string, parent, att *design.AttributeDefinition) *ObjectTy
 	obj := &ObjectType{}
 	obj.Label = name
 	obj.Name = codegen.Goify(name, false)
-	obj.Type = codegen.GoTypeRef(att.Type, nil, 0, false)
+	obj.Type = codegen.GoTypeRefAtt(att, 0, false)
 	if att.Type.IsPrimitive() && parent.IsPrimitivePointer(name) {
 		obj.Pointer = "*"
 	}
@@ -405,7 +409,7 @@ func {{ $test.Name }}(t goatest.TInterface, ctx context.Context, service *goa.Se
 		Path: fmt.Sprintf({{ printf "%q" $test.FullPath }}{{ range $param := $test.Params }}, {{ $param.Name }}{{ end }}),
 {{ if $test.QueryParams }}		RawQuery: {{ $query }}.Encode(),
 {{ end }}	}
-	{{ $req := $test.Escape "req" }}{{ $req }}, {{ $err := $test.Escape "err" }}{{ $err }}:= http.NewRequest("{{ $test.RouteVerb }}", {{ $u }}.String(), nil)
+	{{ $req := $test.Escape "req" }}{{ $req }}, {{ $err := $test.Escape "err" }}{{ $err }}:= http.NewRequest("{{ $test.RouteVerb }}", {{ $u }}.String(), {{ if $test.PayloadStream }}{{ $test.Payload.Name }}{{ else }}nil{{ end }})
 	if {{ $err }} != nil {
 		panic("invalid test " + {{ $err }}.Error()) // bug
 	}
@@ -432,7 +436,7 @@ func {{ $test.Name }}(t goatest.TInterface, ctx context.Context, service *goa.Se
 {{ if not $test.ReturnsErrorMedia }}		t.Errorf("unexpected parameter validation error: %+v", {{ $e }})
 {{ end }}{{ if $test.ReturnType }}		return nil, {{ if $test.ReturnsErrorMedia }}{{ $e }}{{ else }}nil{{ end }}{{ else }}return nil{{ end }}
 	}
-	{{ if $test.Payload }}{{ $test.ContextVarName }}.Payload = {{ $test.Payload.Name }}{{ end }}
+	{{ if and $test.Payload (not $test.PayloadStream) }}{{ $test.ContextVarName }}.Payload = {{ $test.Payload.Name }}{{ end }}
 
 	// Perform action
 	{{ $err }} = ctrl.{{ $test.ActionName}}({{ $test.ContextVarName }})
@@ -16,6 +16,45 @@ import (
 // WildcardRegex is the regex used to capture path parameters.
 var WildcardRegex = regexp.MustCompile("(?:[^/]*/:([^/]+))+")
 
+// Hook points exposed by the app generator, see codegen.RegisterHook. The code produced by the
+// hooks registered at a point must be valid Go in the location described below, the hook imports
+// are added to the corresponding file.
+const (
+	// HookContextFields adds fields to the action context struct.
+	// Hook data: *ContextTemplateData
+	HookContextFields codegen.HookPoint = "app:context:fields"
+
+	// HookContextNew adds statements to the action context factory function right before it
+	// returns. The statements may use the rctx (context being built), req (*goa.RequestData),
+	// service (*goa.Service) and err (validation error) variables.
+	// Hook data: *ContextTemplateData
+	HookContextNew codegen.HookPoint = "app:context:new"
+
+	// HookContextDecls adds declarations after the code generated for each action context,
+	// e.g. additional context methods.
+	// Hook data: *ContextTemplateData
+	HookContextDecls codegen.HookPoint = "app:context:decls"
+
+	// HookMountStart adds statements at the beginning of the Mount<Resource>Controller
+	// functions. The statements may use the service and ctrl variables.
+	// Hook data: *ControllerTemplateData
+	HookMountStart codegen.HookPoint = "app:mount:start"
+
+	// HookMountAction adds statements to the Mount<Resource>Controller functions right before
+	// an action handler is mounted, after the security and CORS handlers have been applied.
+	// The statements may wrap the handler held by the h (goa.Handler) variable.
+	// Hook data: map[string]interface{}, the action data as described in ControllerTemplateData
+	HookMountAction codegen.HookPoint = "app:mount:action"
+
+	// HookControllerDecls adds declarations after the code generated for each controller.
+	// Hook data: *ControllerTemplateData
+	HookControllerDecls codegen.HookPoint = "app:controller:decls"
+
+	// HookSecurityDecls adds declarations at the end of security.go.
+	// Hook data: []*design.SecuritySchemeDefinition
+	HookSecurityDecls codegen.HookPoint = "app:security:decls"
+)
+
 type (
 	// ContextsWriter generate codes for a goa application contexts.
 	ContextsWriter struct {
@@ -74,24 +113,26 @@ type (
 	// ContextTemplateData contains all the information used by the template to render the context
 	// code for an action.
 	ContextTemplateData struct {
-		Name         string // e.g. "ListBottleContext"
-		ResourceName string // e.g. "bottles"
-		ActionName   string // e.g. "list"
-		Params       *design.AttributeDefinition
-		Payload      *design.UserTypeDefinition
-		Headers      *design.AttributeDefinition
-		Routes       []*design.RouteDefinition
-		Responses    map[string]*design.ResponseDefinition
-		API          *design.APIDefinition
-		DefaultPkg   string
-		Security     *design.SecurityDefinition
+		Name          string // e.g. "ListBottleContext"
+		ResourceName  string // e.g. "bottles"
+		ActionName    string // e.g. "list"
+		Params        *design.AttributeDefinition
+		Payload       *design.UserTypeDefinition
+		PayloadStream design.StreamKind
+		Conditional   bool
+		Headers       *design.AttributeDefinition
+		Routes        []*design.RouteDefinition
+		Responses     map[string]*design.ResponseDefinition
+		API           *design.APIDefinition
+		DefaultPkg    string
+		Security      *design.SecurityDefinition
 	}
 
 	// ControllerTemplateData contains the information required to generate an action handler.
 	ControllerTemplateData struct {
 		API            *design.APIDefinition          // API definition
 		Resource       string                         // Lower case plural resource name, e.g. "bottles"
-		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "DesignName", "Routes", "Context" and "Unmarshal"
+		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "DesignName", "Resource", "Definition", "Routes", "Context" and "Unmarshal"
 		FileServers    []*design.FileServerDefinition // File servers
 		Encoders       []*EncoderTemplateData         // Encoder data
 		Decoders       []*EncoderTemplateData         // Decoder data
@@ -127,7 +168,7 @@ type (
 
 // IsPathParam returns true if the given parameter name corresponds to a path parameter for all
 // the context action routes. Such parameter is required but does not need to be validated as
-// httptreemux takes care of that.
+// the mux takes care of that.
 func (c *ContextTemplateData) IsPathParam(param string) bool {
 	params := c.Params
 	pp := false
@@ -172,6 +213,16 @@ func (c *ContextTemplateData) MustValidate(name string) bool {
 	return c.Params.IsRequired(name) && !c.IsPathParam(name)
 }
 
+// HasEventStream returns true if one of the action responses streams Server-Sent Events.
+func (c *ContextTemplateData) HasEventStream() bool {
+	for _, resp := range c.Responses {
+		if resp.Stream == design.SSEStream {
+			return true
+		}
+	}
+	return false
+}
+
 // IterateResponses iterates through the responses sorted by status code.
 func (c *ContextTemplateData) IterateResponses(it func(*design.ResponseDefinition) error) error {
 	m := make(map[int]*design.ResponseDefinition, len(c.Responses))
@@ -217,10 +268,21 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 		"isPathParam":        data.IsPathParam,
 		"valueTypeOf":        valueTypeOf,
 		"fromString":         fromString,
+		"parseNumber":        parseNumber,
+		"convertNumber":      convertNumber,
 	}
 	if err := w.ExecuteTemplate("new", ctxNewT, fn, data); err != nil {
 		return err
 	}
+	if data.PayloadStream != 0 {
+		fn := template.FuncMap{
+			"finalizeCode":   w.Finalizer.Code,
+			"validationCode": w.Validator.Code,
+		}
+		if err := w.ExecuteTemplate("payloadStream", ctxPayloadStreamT, fn, data); err != nil {
+			return err
+		}
+	}
 	if data.Payload != nil {
 		found := false
 		for _, t := range design.Design.Types {
@@ -239,10 +301,27 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 			}
 		}
 	}
+	if data.Conditional {
+		if err := w.ExecuteTemplate("conditional", ctxConditionalT, nil, data); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteHooks(HookContextDecls, data); err != nil {
+		return err
+	}
 	return data.IterateResponses(func(resp *design.ResponseDefinition) error {
 		respData := map[string]interface{}{
-			"Context":  data,
-			"Response": resp,
+			"Context":     data,
+			"Response":    resp,
+			"Conditional": data.Conditional && resp.Status == 200,
+		}
+		if resp.Stream == design.SSEStream {
+			return w.executeEventStream(resp, respData, fn)
+		}
+		if resp.Stream != 0 {
+			respData["NDJSON"] = resp.Stream == design.NDJSONStream
+			respData["ContentType"] = streamContentType(resp)
+			return w.ExecuteTemplate("response", ctxStreamRespT, nil, respData)
 		}
 		var mt *design.MediaTypeDefinition
 		if resp.Type != nil {
@@ -293,6 +372,45 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 	})
 }
 
+// executeEventStream generates the SendEvent and CloseEvents context methods of a Server-Sent
+// Events response.
+func (w *ContextsWriter) executeEventStream(resp *design.ResponseDefinition, respData map[string]interface{}, fn template.FuncMap) error {
+	mt, ok := resp.Type.(*design.MediaTypeDefinition)
+	if resp.Type != nil && !ok {
+		respData["Type"] = resp.Type
+	} else {
+		if mt == nil {
+			mt = design.Design.MediaTypeWithIdentifier(resp.MediaType)
+		}
+		if mt != nil {
+			view := resp.ViewName
+			if view == "" {
+				view = design.DefaultView
+			}
+			projected, _, err := mt.Project(view)
+			if err != nil {
+				return err
+			}
+			respData["Projected"] = projected
+		}
+	}
+	return w.ExecuteTemplate("response", ctxEventStreamT, fn, respData)
+}
+
+// streamContentType returns the content type of the given streamed response.
+func streamContentType(resp *design.ResponseDefinition) string {
+	if resp.Stream == design.NDJSONStream {
+		return "application/x-ndjson"
+	}
+	if mt := design.Design.MediaTypeWithIdentifier(resp.MediaType); mt != nil && mt.ContentType != "" {
+		return mt.ContentType
+	}
+	if resp.MediaType != "" {
+		return resp.MediaType
+	}
+	return "application/octet-stream"
+}
+
 // NewControllersWriter returns a handlers code writer.
 // Handlers provide the glue between the underlying request data and the user controller.
 func NewControllersWriter(filename string) (*ControllersWriter, error) {
@@ -341,10 +459,15 @@ func (w *ControllersWriter) Execute(data []*ControllerTemplateData) error {
 			"validationCode": w.Validator.Code,
 			"valueTypeOf":    valueTypeOf,
 			"fromString":     fromString,
+			"parseNumber":    parseNumber,
+			"convertNumber":  convertNumber,
 		}
 		if err := w.ExecuteTemplate("unmarshal", unmarshalT, fn, d); err != nil {
 			return err
 		}
+		if err := w.WriteHooks(HookControllerDecls, d); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -361,7 +484,10 @@ func NewSecurityWriter(filename string) (*SecurityWriter, error) {
 
 // Execute adds the different security schemes and middleware supporting functions.
 func (w *SecurityWriter) Execute(schemes []*design.SecuritySchemeDefinition) error {
-	return w.ExecuteTemplate("security_schemes", securitySchemesT, nil, schemes)
+	if err := w.ExecuteTemplate("security_schemes", securitySchemesT, nil, schemes); err != nil {
+		return err
+	}
+	return w.WriteHooks(HookSecurityDecls, schemes)
 }
 
 // NewResourcesWriter returns a contexts code writer.
@@ -466,10 +592,8 @@ func valueTypeOf(prefix string, att *design.AttributeDefinition) string {
 	switch att.Type.Kind() {
 	case design.BooleanKind:
 		return prefix + "bool"
-	case design.IntegerKind:
-		return prefix + "int"
-	case design.NumberKind:
-		return prefix + "float"
+	case design.IntegerKind, design.NumberKind:
+		return prefix + codegen.GoNativeTypeAtt(att)
 	case design.StringKind:
 		return prefix + "string"
 	case design.ArrayKind:
@@ -486,10 +610,13 @@ func fromString(att *design.AttributeDefinition, varName string) string {
 	switch att.Type.Kind() {
 	case design.BooleanKind:
 		return "strconv.ParseBool(" + varName + ")"
-	case design.IntegerKind:
-		return "strconv.Atoi(" + varName + ")"
-	case design.NumberKind:
-		return "strconv.ParseFloat(" + varName + ")"
+	case design.IntegerKind, design.NumberKind:
+		if att.NumericFormat() != "" {
+			// Wrap the conversion so that the expression yields the attribute type.
+			return fmt.Sprintf("func() (%s, error) { v, err := %s; return %s, err }()",
+				codegen.GoNativeTypeAtt(att), parseNumber(att, varName), convertNumber(att, "v"))
+		}
+		return parseNumber(att, varName)
 	case design.StringKind:
 		return varName + ", (error)(nil)"
 	case design.ArrayKind:
@@ -499,6 +626,38 @@ func fromString(att *design.AttributeDefinition, varName string) string {
 	return "(" + valueTypeOf("", att) + ")(nil), (error)(nil)"
 }
 
+// parseNumber returns the Go code that parses the string held by varName into a value that can
+// be converted to the type of the given Integer or Number attribute with convertNumber.
+func parseNumber(att *design.AttributeDefinition, varName string) string {
+	switch att.NumericFormat() {
+	case design.FormatInt32:
+		return "strconv.ParseInt(" + varName + ", 10, 32)"
+	case design.FormatInt64:
+		return "strconv.ParseInt(" + varName + ", 10, 64)"
+	case design.FormatUint32:
+		return "strconv.ParseUint(" + varName + ", 10, 32)"
+	case design.FormatUint64:
+		return "strconv.ParseUint(" + varName + ", 10, 64)"
+	case design.FormatFloat:
+		return "strconv.ParseFloat(" + varName + ", 32)"
+	}
+	if att.Type.Kind() == design.IntegerKind {
+		return "strconv.Atoi(" + varName + ")"
+	}
+	return "strconv.ParseFloat(" + varName + ", 64)"
+}
+
+// convertNumber returns the Go code that converts the value returned by the parseNumber code to
+// the type of the given Integer or Number attribute.
+func convertNumber(att *design.AttributeDefinition, varName string) string {
+	switch format := att.NumericFormat(); format {
+	case "", design.FormatInt64, design.FormatUint64:
+		return varName
+	default:
+		return codegen.GoNativeTypeAtt(att) + "(" + varName + ")"
+	}
+}
+
 const (
 	// ctxT generates the code for the context data type.
 	// template input: *ContextTemplateData
@@ -508,11 +667,15 @@ type {{ .Name }} struct {
 	*goa.ResponseData
 	*goa.RequestData
 {{ if .Headers }}{{ range $name, $att := .Headers.Type.ToObject }}{{ if not ($.HasParamAndHeader $name) }}{{/*
-*/}}	{{ goifyatt $att $name true }} {{ if and $att.Type.IsPrimitive ($.Headers.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperef .Type nil 0 false }}
+*/}}	{{ goifyatt $att $name true }} {{ if and $att.Type.IsPrimitive ($.Headers.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperefatt $att 0 false }}
 {{ end }}{{ end }}{{ end }}{{ if .Params }}{{ range $name, $att := .Params.Type.ToObject }}{{/*
-*/}}	{{ goifyatt $att $name true }} {{ if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperef .Type nil 0 false }}
-{{ end }}{{ end }}{{ if .Payload }}	Payload {{ gotyperef .Payload nil 0 false }}
-{{ end }}}
+*/}}	{{ goifyatt $att $name true }} {{ if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperefatt $att 0 false }}
+{{ end }}{{ end }}{{ if .Payload }}{{ if .PayloadStream }}	payloadDecoder *goa.NDJSONDecoder
+{{ else }}	Payload {{ gotyperef .Payload nil 0 false }}
+{{ end }}{{ end }}{{ if .HasEventStream }}	eventStream *goa.EventStream
+{{ end }}{{ if .Conditional }}	etag         string
+	lastModified time.Time
+{{ end }}{{ hook "app:context:fields" . }}}
 `
 	// coerceT generates the code that coerces the generic deserialized
 	// data to the actual type.
@@ -531,11 +694,11 @@ type {{ .Name }} struct {
 
 */}}{{/* IntegerType */}}{{/*
 */}}{{ $tmp := tempvar }}{{/*
-*/}}{{ tabs .Depth }}if {{ .VarName }}, err2 := strconv.Atoi(raw{{ goify .Name true }}); err2 == nil {
-{{ if .Pointer }}{{ $tmp2 := tempvar }}{{ tabs .Depth }}	{{ $tmp2 }} := {{ .VarName }}
+*/}}{{ tabs .Depth }}if {{ .VarName }}, err2 := {{ parseNumber .Attribute (printf "raw%s" (goify .Name true)) }}; err2 == nil {
+{{ if .Pointer }}{{ $tmp2 := tempvar }}{{ tabs .Depth }}	{{ $tmp2 }} := {{ convertNumber .Attribute .VarName }}
 {{ tabs .Depth }}	{{ $tmp }} := &{{ $tmp2 }}
 {{ tabs .Depth }}	{{ .Pkg }} = {{ $tmp }}
-{{ else }}{{ tabs .Depth }}	{{ .Pkg }} = {{ .VarName }}
+{{ else }}{{ tabs .Depth }}	{{ .Pkg }} = {{ convertNumber .Attribute .VarName }}
 {{ end }}{{ tabs .Depth }}} else {
 {{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "integer"))
 {{ tabs .Depth }}}
@@ -543,10 +706,13 @@ type {{ .Name }} struct {
 
 */}}{{/* NumberType */}}{{/*
 */}}{{ $varName := or (and (not .Pointer) .VarName) tempvar }}{{/*
-*/}}{{ tabs .Depth }}if {{ .VarName }}, err2 := strconv.ParseFloat(raw{{ goify .Name true }}, 64); err2 == nil {
-{{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
+*/}}{{ tabs .Depth }}if {{ .VarName }}, err2 := {{ parseNumber .Attribute (printf "raw%s" (goify .Name true)) }}; err2 == nil {
+{{ if .Pointer }}{{ if .Attribute.NumericFormat }}{{ $tmp := tempvar }}{{ tabs .Depth }}	{{ $tmp }} := {{ convertNumber .Attribute .VarName }}
+{{ tabs .Depth }}	{{ $varName }} := &{{ $tmp }}
+{{ else }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
-{{ tabs .Depth }}} else {
+{{ else }}{{ tabs .Depth }}	{{ .Pkg }} = {{ convertNumber .Attribute .VarName }}
+{{ end }}{{ tabs .Depth }}} else {
 {{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "number"))
 {{ tabs .Depth }}}
 {{ else if eq .Attribute.Type.Kind 4 }}{{/*
@@ -662,7 +828,90 @@ func New{{ .Name }}(ctx context.Context, r *http.Request, service *goa.Service)
 	}{{ end }}{{/*
 */}}{{ else }}{{ $validation := validationChecker $att ($.Params.IsNonZero $name) ($.Params.IsRequired $name) ($.Params.HasDefaultValue $name) (printf "rctx.%s" (goifyatt $att $name true)) $name 2 false }}{{/*
 */}}{{ if $validation }}{{ $validation }}{{ end }}{{ end }}	}
-{{ end }}{{ end }}{{/* if .Params */}}	return &rctx, err
+{{ end }}{{ end }}{{/* if .Params */}}{{ hook "app:context:new" . }}	return &rctx, err
+}
+`
+
+	// ctxPayloadStreamT generates the methods that give access to streamed request bodies.
+	// template input: *ContextTemplateData
+	ctxPayloadStreamT = `{{ if .Payload }}// NextPayload decodes and validates the next value of the request body stream. It returns
+// io.EOF once the stream is exhausted.
+func (ctx *{{ .Name }}) NextPayload() (res {{ gotyperef .Payload nil 0 false }}, err error) {
+	if ctx.payloadDecoder == nil {
+		ctx.payloadDecoder = goa.NewNDJSONDecoder(ctx.Request.Body)
+	}
+	{{ if .Payload.IsObject }}payload := &{{ gotypename .Payload nil 1 true }}{}
+	if err = ctx.payloadDecoder.Decode(payload); err != nil {
+		return
+	}{{ $assignment := finalizeCode .Payload.AttributeDefinition "payload" 1 }}{{ if $assignment }}
+	payload.Finalize(){{ end }}{{ else }}var payload {{ gotypename .Payload nil 1 false }}
+	if err = ctx.payloadDecoder.Decode(&payload); err != nil {
+		return
+	}{{ end }}{{ $validation := validationCode .Payload.AttributeDefinition false false false "payload" "raw" 1 true }}{{ if $validation }}
+	if err = payload.Validate(); err != nil {
+		return
+	}{{ end }}
+	return payload{{ if .Payload.IsObject }}.Publicize(){{ end }}, nil
+}
+{{ else }}// PayloadReader returns the request body stream.
+func (ctx *{{ .Name }}) PayloadReader() io.Reader {
+	return ctx.Request.Body
+}
+{{ end }}`
+
+	// ctxConditionalT generates the helpers of actions that support conditional requests.
+	// template input: *ContextTemplateData
+	ctxConditionalT = `// CheckPreconditions evaluates the request conditional headers against the given entity tag and
+// last modification time of the resource, either may be empty. It writes a 304 Not Modified or
+// 412 Precondition Failed response and returns false if the request must not be processed further.
+// The validators are sent with the OK response unless overridden with SetValidators.
+func (ctx *{{ .Name }}) CheckPreconditions(etag string, modTime time.Time) bool {
+	ctx.SetValidators(etag, modTime)
+	if status := goa.CheckPreconditions(ctx.Request, etag, modTime); status != 0 {
+		goa.SetValidators(ctx.ResponseData.Header(), etag, modTime)
+		ctx.ResponseData.WriteHeader(status)
+		return false
+	}
+	return true
+}
+
+// SetValidators sets the entity tag and last modification time sent in the ETag and Last-Modified
+// headers of the OK response, e.g. after the resource has been updated.
+func (ctx *{{ .Name }}) SetValidators(etag string, modTime time.Time) {
+	ctx.etag = etag
+	ctx.lastModified = modTime
+}
+`
+
+	// ctxStreamRespT generates the response helpers for streamed responses.
+	// template input: map[string]interface{}
+	ctxStreamRespT = `// {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }} and returns the {{ if .NDJSON }}encoder{{ else }}writer{{ end }}
+// used to stream the response body. The response is flushed after each {{ if .NDJSON }}value{{ else }}write{{ end }}.
+func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}() {{ if .NDJSON }}*goa.NDJSONEncoder{{ else }}io.Writer{{ end }} {
+	if ctx.ResponseData.Header().Get("Content-Type") == "" {
+		ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
+	}
+	ctx.ResponseData.WriteHeader({{ .Response.Status }})
+	return goa.{{ if .NDJSON }}NewNDJSONEncoder{{ else }}NewFlushWriter{{ end }}(ctx.ResponseData)
+}
+`
+
+	// ctxEventStreamT generates the helpers for responses that stream Server-Sent Events.
+	// template input: map[string]interface{}
+	ctxEventStreamT = `// SendEvent sends a Server-Sent Event whose data is the JSON encoding of r. The first call sends
+// the response headers with status code {{ .Response.Status }} and starts sending heartbeats.
+func (ctx *{{ .Context.Name }}) SendEvent(r {{ if .Projected }}{{ gotyperef .Projected .Projected.AllRequired 0 false }}{{ else if .Type }}{{ gotyperef .Type nil 0 false }}{{ else }}interface{}{{ end }}) error {
+	if ctx.eventStream == nil {
+		ctx.eventStream = goa.NewEventStream(ctx.Context, ctx.ResponseData, {{ .Response.Status }})
+	}
+	return ctx.eventStream.Send(r)
+}
+
+// CloseEvents stops the event stream heartbeats, it is called once the action returns.
+func (ctx *{{ .Context.Name }}) CloseEvents() {
+	if ctx.eventStream != nil {
+		ctx.eventStream.Close()
+	}
 }
 `
 
@@ -676,6 +925,7 @@ func (ctx *{{ .Context.Name }}) {{ goify .RespName true }}(r {{ gotyperef .Proje
 {{ if .Projected.Type.IsArray }}	if r == nil {
 		r = {{ gotyperef .Projected .Projected.AllRequired 0 false }}{}
 	}
+{{ end }}{{ if .Conditional }}	goa.SetValidators(ctx.ResponseData.Header(), ctx.etag, ctx.lastModified)
 {{ end }}	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
 }
 `
@@ -687,7 +937,8 @@ func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}(r {{ gotyperef .
 	if ctx.ResponseData.Header().Get("Content-Type") == "" {
 		ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
 	}
-	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
+{{ if .Conditional }}	goa.SetValidators(ctx.ResponseData.Header(), ctx.etag, ctx.lastModified)
+{{ end }}	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
 }
 `
 
@@ -699,6 +950,7 @@ func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}({{ if .Response.
 {{ if .Response.MediaType }}	if ctx.ResponseData.Header().Get("Content-Type") == "" {
 		ctx.ResponseData.Header().Set("Content-Type", "{{ .Response.MediaType }}")
 	}
+{{ end }}{{ if .Conditional }}	goa.SetValidators(ctx.ResponseData.Header(), ctx.etag, ctx.lastModified)
 {{ end }}	ctx.ResponseData.WriteHeader({{ .Response.Status }}){{ if .Response.MediaType }}
 	_, err := ctx.ResponseData.Write(resp)
 	return err{{ else }}
@@ -776,8 +1028,8 @@ func initService(service *goa.Service) {
 func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Controller) {
 	initService(service)
 	var h goa.Handler
-{{ $res := .Resource }}{{ if .Origins }}{{ range .PreflightPaths }}{{/*
-*/}}	service.Mux.Handle("OPTIONS", {{ printf "%q" . }}, ctrl.MuxHandler("preflight", handle{{ $res }}Origin(cors.HandlePreflight()), nil))
+{{ hook "app:mount:start" . }}{{ $res := .Resource }}{{ if .Origins }}{{ range .PreflightPaths }}{{/*
+*/}}	service.Mount(&goa.Route{Method: "OPTIONS", Path: {{ printf "%q" . }}, Controller: {{ printf "%q" $res }}, Action: "preflight"}, ctrl.MuxHandler("preflight", handle{{ $res }}Origin(cors.HandlePreflight()), nil))
 {{ end }}{{ end }}{{ range .Actions }}{{ $action := . }}
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 		// Check if there was an error loading the request
@@ -789,23 +1041,24 @@ func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Co
 		if err != nil {
 			return err
 		}
-{{ if .Payload }}		// Build the payload
+{{ if and .Payload (not .PayloadStream) }}		// Build the payload
 		if rawPayload := goa.ContextRequest(ctx).Payload; rawPayload != nil {
 			rctx.Payload = rawPayload.({{ gotyperef .Payload nil 1 false }})
 {{ if not .PayloadOptional }}		} else {
 			return goa.MissingPayloadError()
 {{ end }}		}
+{{ end }}{{ if .EventStream }}		defer rctx.CloseEvents()
 {{ end }}		return ctrl.{{ .Name }}(rctx)
 	}
 {{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
 {{ end }}{{ if $.Origins }}	h = handle{{ $res }}Origin(h)
-{{ end }}{{ range .Routes }}	service.Mux.Handle("{{ .Verb }}", {{ printf "%q" .FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.DesignName }}, h, {{ if $action.Payload }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
+{{ end }}{{ hook "app:mount:action" $action }}{{ range .Routes }}	service.Mount(&goa.Route{Method: "{{ .Verb }}", Path: {{ printf "%q" .FullPath }}, Controller: {{ printf "%q" $res }}, Action: {{ printf "%q" $action.DesignName }}{{ with $action.Metadata }}, Metadata: {{ printf "%#v" . }}{{ end }}}, ctrl.MuxHandler({{ printf "%q" $action.DesignName }}, h, {{ if and $action.Payload (not $action.PayloadStream) }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "action", {{ printf "%q" $action.Name }}, "route", {{ printf "%q" (printf "%s %s" .Verb .FullPath) }}{{ with $action.Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
 {{ end }}{{ end }}{{ range .FileServers }}
 	h = ctrl.FileHandler({{ printf "%q" .RequestPath }}, {{ printf "%q" .FilePath }})
 {{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
 {{ end }}{{ if $.Origins }}	h = handle{{ $res }}Origin(h)
-{{ end }}	service.Mux.Handle("GET", "{{ .RequestPath }}", ctrl.MuxHandler("serve", h, nil))
+{{ end }}	service.Mount(&goa.Route{Method: "GET", Path: "{{ .RequestPath }}", Controller: {{ printf "%q" $res }}, Action: "serve"}, ctrl.MuxHandler("serve", h, nil))
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "files", {{ printf "%q" .FilePath }}, "route", {{ printf "%q" (printf "GET %s" .RequestPath) }}{{ with .Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
 {{ end }}}
 `
@@ -844,7 +1097,7 @@ func handle{{ .Resource }}Origin(h goa.Handler) goa.Handler {
 
 	// unmarshalT generates the code for an action payload unmarshal function.
 	// template input: *ControllerTemplateData
-	unmarshalT = `{{ define "Coerce" }}` + coerceT + `{{ end }}` + `{{ range .Actions }}{{ if .Payload }}
+	unmarshalT = `{{ define "Coerce" }}` + coerceT + `{{ end }}` + `{{ range .Actions }}{{ if and .Payload (not .PayloadStream) }}
 // {{ .Unmarshal }} unmarshals the request body into the context request data Payload field.
 func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Request) error {
 	{{ if .PayloadMultipart}}var err error
@@ -973,7 +1226,9 @@ func {{ $funcName }}() *goa.{{ .Context }} {
 */}}{{ else if eq .Context "JWTSecurity" }}{{/*
 */}}		In:   {{ if eq .In "header" }}goa.LocHeader{{ else }}goa.LocQuery{{ end }},
 		Name:             {{ printf "%q" .Name }},
-		TokenURL:         {{ printf "%q" .TokenURL }},{{ with .Scopes }}
+		TokenURL:         {{ printf "%q" .TokenURL }},{{ with .Issuers }}
+		Issuers:          []string{ {{ range $i, $iss := . }}{{ if $i }}, {{ end }}{{ printf "%q" $iss }}{{ end }} },{{ end }}{{ with .Audiences }}
+		Audiences:        []string{ {{ range $i, $aud := . }}{{ if $i }}, {{ end }}{{ printf "%q" $aud }}{{ end }} },{{ end }}{{ with .Scopes }}
 		Scopes: map[string]string{
 {{ range $k, $v := . }}			{{ printf "%q" $k }}: {{ printf "%q" $v }},
 {{ end }}{{/*
@@ -52,6 +52,8 @@ var _ = Describe("ContextsWriter", func() {
 		Context("with data", func() {
 			var params, headers *design.AttributeDefinition
 			var payload *design.UserTypeDefinition
+			var payloadStream design.StreamKind
+			var conditional bool
 			var responses map[string]*design.ResponseDefinition
 			var routes []*design.RouteDefinition
 
@@ -61,6 +63,8 @@ var _ = Describe("ContextsWriter", func() {
 				params = nil
 				headers = nil
 				payload = nil
+				payloadStream = 0
+				conditional = false
 				responses = nil
 				routes = nil
 				data = nil
@@ -68,16 +72,18 @@ var _ = Describe("ContextsWriter", func() {
 
 			JustBeforeEach(func() {
 				data = &genapp.ContextTemplateData{
-					Name:         "ListBottleContext",
-					ResourceName: "bottles",
-					ActionName:   "list",
-					Params:       params,
-					Payload:      payload,
-					Headers:      headers,
-					Responses:    responses,
-					Routes:       routes,
-					API:          design.Design,
-					DefaultPkg:   "",
+					Name:          "ListBottleContext",
+					ResourceName:  "bottles",
+					ActionName:    "list",
+					Params:        params,
+					Payload:       payload,
+					PayloadStream: payloadStream,
+					Conditional:   conditional,
+					Headers:       headers,
+					Responses:     responses,
+					Routes:        routes,
+					API:           design.Design,
+					DefaultPkg:    "",
 				}
 			})
 
@@ -94,6 +100,33 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with hooks", func() {
+				BeforeEach(func() {
+					codegen.RegisterHook(genapp.HookContextFields, &codegen.Hook{
+						Name: "fields",
+						Code: func(interface{}) (string, error) { return "\tTenant string", nil },
+					})
+					codegen.RegisterHook(genapp.HookContextNew, &codegen.Hook{
+						Name: "new",
+						Code: func(interface{}) (string, error) { return "\trctx.Tenant = req.Header.Get(\"X-Tenant\")", nil },
+					})
+				})
+
+				AfterEach(func() {
+					codegen.ResetHooks()
+				})
+
+				It("injects the hooks code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\t*goa.RequestData\n\tTenant string\n}"))
+					Ω(written).Should(ContainSubstring("\trctx.Tenant = req.Header.Get(\"X-Tenant\")\n\treturn &rctx, err"))
+				})
+			})
+
 			Context("with a media type setting a ContentType", func() {
 				var contentType = "application/json"
 
@@ -136,6 +169,44 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with an error response rendered as problem details", func() {
+				BeforeEach(func() {
+					resp := &design.ResponseDefinition{
+						Name:      "BadRequest",
+						Status:    400,
+						MediaType: design.ErrorMediaIdentifier,
+						Type:      design.ErrorMedia,
+					}
+					action := &design.ActionDefinition{
+						Name:      "list",
+						Responses: map[string]*design.ResponseDefinition{"BadRequest": resp},
+					}
+					resource := &design.ResourceDefinition{
+						Name:    "bottles",
+						Actions: map[string]*design.ActionDefinition{"list": action},
+					}
+					action.Parent = resource
+					design.Design = &design.APIDefinition{
+						Resources:      map[string]*design.ResourceDefinition{"bottles": resource},
+						ProblemDetails: true,
+					}
+					design.Design.Finalize()
+					design.ProjectedMediaTypes = make(map[string]*design.MediaTypeDefinition)
+					responses = action.Responses
+				})
+
+				It("sets the problem details Content-Type header", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("func (ctx *ListBottleContext) BadRequest(r error) error {"))
+					Ω(written).Should(ContainSubstring(`ctx.ResponseData.Header().Set("Content-Type", "application/problem+json")`))
+					Ω(written).ShouldNot(ContainSubstring(`ctx.ResponseData.Header().Set("Content-Type", "")`))
+				})
+			})
+
 			Context("with a collection media type", func() {
 				BeforeEach(func() {
 					elemType := &design.MediaTypeDefinition{
@@ -262,6 +333,34 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with params using bit-width formats", func() {
+				BeforeEach(func() {
+					params = &design.AttributeDefinition{
+						Type: design.Object{
+							"count": {Type: design.Integer, Validation: &dslengine.ValidationDefinition{Format: design.FormatInt32}},
+							"size":  {Type: design.Integer, Validation: &dslengine.ValidationDefinition{Format: design.FormatUint32}},
+							"ratio": {Type: design.Number, Validation: &dslengine.ValidationDefinition{Format: design.FormatFloat}},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"size"}},
+					}
+				})
+
+				It("writes fields and parsing code using the sized Go types", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(sizedContext))
+					Ω(written).Should(ContainSubstring("if count, err2 := strconv.ParseInt(rawCount, 10, 32); err2 == nil {"))
+					Ω(written).Should(ContainSubstring(" := int32(count)\n"))
+					Ω(written).Should(ContainSubstring("if size, err2 := strconv.ParseUint(rawSize, 10, 32); err2 == nil {"))
+					Ω(written).Should(ContainSubstring("rctx.Size = uint32(size)\n"))
+					Ω(written).Should(ContainSubstring("if ratio, err2 := strconv.ParseFloat(rawRatio, 32); err2 == nil {"))
+					Ω(written).Should(ContainSubstring(" := float32(ratio)\n"))
+				})
+			})
+
 			Context("with an string param", func() {
 				var (
 					strParam   *design.AttributeDefinition
@@ -837,6 +936,132 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a streamed payload", func() {
+				BeforeEach(func() {
+					design.Design = new(design.APIDefinition)
+					payload = &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{Type: design.String},
+						TypeName:            "ListBottlePayload",
+					}
+					payloadStream = design.NDJSONStream
+				})
+
+				It("writes the payload decoder", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring("payloadDecoder *goa.NDJSONDecoder"))
+					Ω(written).ShouldNot(ContainSubstring("Payload ListBottlePayload"))
+					Ω(written).Should(ContainSubstring(payloadStreamContext))
+				})
+			})
+
+			Context("with a raw streamed payload", func() {
+				BeforeEach(func() {
+					design.Design = new(design.APIDefinition)
+					payloadStream = design.RawStream
+				})
+
+				It("writes the payload reader", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(payloadReaderContext))
+				})
+			})
+
+			Context("with a streamed response", func() {
+				BeforeEach(func() {
+					design.Design = new(design.APIDefinition)
+					responses = map[string]*design.ResponseDefinition{"OK": {
+						Name:   "OK",
+						Status: 200,
+						Stream: design.NDJSONStream,
+					}}
+				})
+
+				It("writes the response encoder helper", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(streamResponseContext))
+				})
+			})
+
+			Context("with an event stream response", func() {
+				BeforeEach(func() {
+					mediaType := &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{"foo": {Type: design.String}},
+							},
+							TypeName: "GoaEvent",
+						},
+						Identifier: "application/vnd.goa.event",
+					}
+					defView := &design.ViewDefinition{
+						AttributeDefinition: mediaType.AttributeDefinition,
+						Name:                "default",
+						Parent:              mediaType,
+					}
+					mediaType.Views = map[string]*design.ViewDefinition{"default": defView}
+					design.Design = new(design.APIDefinition)
+					design.Design.MediaTypes = map[string]*design.MediaTypeDefinition{
+						design.CanonicalIdentifier(mediaType.Identifier): mediaType,
+					}
+					design.ProjectedMediaTypes = make(map[string]*design.MediaTypeDefinition)
+					responses = map[string]*design.ResponseDefinition{"OK": {
+						Name:      "OK",
+						Status:    200,
+						MediaType: mediaType.Identifier,
+						Stream:    design.SSEStream,
+					}}
+				})
+
+				It("writes the event stream helpers", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring("eventStream *goa.EventStream"))
+					Ω(written).Should(ContainSubstring(eventStreamContext))
+				})
+			})
+
+			Context("with a conditional action", func() {
+				BeforeEach(func() {
+					conditional = true
+					responses = map[string]*design.ResponseDefinition{
+						"OK":       {Name: "OK", Status: 200},
+						"NotFound": {Name: "NotFound", Status: 404},
+					}
+				})
+
+				It("writes the precondition helpers and sets the validators in OK", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(conditionalContext))
+					Ω(written).Should(ContainSubstring(conditionalContextHelpers))
+					Ω(written).Should(ContainSubstring(conditionalContextOK))
+					Ω(written).Should(ContainSubstring(conditionalContextNotFound))
+				})
+			})
+
 			Context("with a object payload", func() {
 				BeforeEach(func() {
 					design.Design = new(design.APIDefinition)
@@ -999,10 +1224,12 @@ var _ = Describe("ControllersWriter", func() {
 			var payloads []*design.UserTypeDefinition
 			var encoders, decoders []*genapp.EncoderTemplateData
 			var origins []*design.CORSDefinition
+			var metadata map[string][]string
 
 			var data []*genapp.ControllerTemplateData
 
 			BeforeEach(func() {
+				metadata = nil
 				multipart = false
 				actions = nil
 				verbs = nil
@@ -1044,6 +1271,7 @@ var _ = Describe("ControllersWriter", func() {
 						"Unmarshal":        unmarshal,
 						"Payload":          payload,
 						"PayloadMultipart": multipart,
+						"Metadata":         metadata,
 					}
 				}
 				if len(as) > 0 {
@@ -1088,6 +1316,65 @@ var _ = Describe("ControllersWriter", func() {
 				})
 			})
 
+			Context("with route metadata", func() {
+				BeforeEach(func() {
+					actions = []string{"list"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					metadata = map[string][]string{"ratelimit": {"10/s", "20"}}
+				})
+
+				It("sets the route metadata", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`Action: "list", Metadata: map[string][]string{"ratelimit":[]string{"10/s", "20"}}}, ctrl.MuxHandler("list", h, nil))`))
+				})
+			})
+
+			Context("with hooks", func() {
+				BeforeEach(func() {
+					actions = []string{"list"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					codegen.RegisterHook(genapp.HookMountStart, &codegen.Hook{
+						Name: "start",
+						Code: func(data interface{}) (string, error) {
+							return "\tservice.LogInfo(\"mounting\", \"ctrl\", \"" + data.(*genapp.ControllerTemplateData).Resource + "\")", nil
+						},
+					})
+					codegen.RegisterHook(genapp.HookMountAction, &codegen.Hook{
+						Name: "wrap",
+						Code: func(data interface{}) (string, error) {
+							return "\th = audit.Wrap(\"" + data.(map[string]interface{})["DesignName"].(string) + "\", h)", nil
+						},
+					})
+					codegen.RegisterHook(genapp.HookControllerDecls, &codegen.Hook{
+						Name: "decls",
+						Code: func(interface{}) (string, error) { return "var audited = true", nil },
+					})
+				})
+
+				AfterEach(func() {
+					codegen.ResetHooks()
+				})
+
+				It("injects the hooks code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\tvar h goa.Handler\n\tservice.LogInfo(\"mounting\", \"ctrl\", \"Bottles\")\n"))
+					Ω(written).Should(ContainSubstring("\t}\n\th = audit.Wrap(\"list\", h)\n\tservice.Mount("))
+					Ω(written).Should(ContainSubstring("\nvar audited = true\n"))
+				})
+			})
+
 			Context("with actions that take a payload", func() {
 				BeforeEach(func() {
 					actions = []string{"list"}
@@ -1659,6 +1946,17 @@ type ListBottleContext struct {
 	*goa.RequestData
 	Param *int
 }
+`
+
+	sizedContext = `
+type ListBottleContext struct {
+	context.Context
+	*goa.ResponseData
+	*goa.RequestData
+	Count *int32
+	Ratio *float32
+	Size uint32
+}
 `
 
 	intContextFactory = `
@@ -2356,6 +2654,95 @@ func NewListBottleContext(ctx context.Context, r *http.Request, service *goa.Ser
 	return &rctx, err
 }
 `
+	payloadStreamContext = `
+func (ctx *ListBottleContext) NextPayload() (res ListBottlePayload, err error) {
+	if ctx.payloadDecoder == nil {
+		ctx.payloadDecoder = goa.NewNDJSONDecoder(ctx.Request.Body)
+	}
+	var payload ListBottlePayload
+	if err = ctx.payloadDecoder.Decode(&payload); err != nil {
+		return
+	}
+	return payload, nil
+}
+`
+
+	payloadReaderContext = `
+func (ctx *ListBottleContext) PayloadReader() io.Reader {
+	return ctx.Request.Body
+}
+`
+
+	streamResponseContext = `
+func (ctx *ListBottleContext) OK() *goa.NDJSONEncoder {
+	if ctx.ResponseData.Header().Get("Content-Type") == "" {
+		ctx.ResponseData.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	ctx.ResponseData.WriteHeader(200)
+	return goa.NewNDJSONEncoder(ctx.ResponseData)
+}
+`
+
+	eventStreamContext = `
+func (ctx *ListBottleContext) SendEvent(r *GoaEvent) error {
+	if ctx.eventStream == nil {
+		ctx.eventStream = goa.NewEventStream(ctx.Context, ctx.ResponseData, 200)
+	}
+	return ctx.eventStream.Send(r)
+}
+
+// CloseEvents stops the event stream heartbeats, it is called once the action returns.
+func (ctx *ListBottleContext) CloseEvents() {
+	if ctx.eventStream != nil {
+		ctx.eventStream.Close()
+	}
+}
+`
+
+	conditionalContext = `
+type ListBottleContext struct {
+	context.Context
+	*goa.ResponseData
+	*goa.RequestData
+	etag         string
+	lastModified time.Time
+}
+`
+
+	conditionalContextHelpers = `
+func (ctx *ListBottleContext) CheckPreconditions(etag string, modTime time.Time) bool {
+	ctx.SetValidators(etag, modTime)
+	if status := goa.CheckPreconditions(ctx.Request, etag, modTime); status != 0 {
+		goa.SetValidators(ctx.ResponseData.Header(), etag, modTime)
+		ctx.ResponseData.WriteHeader(status)
+		return false
+	}
+	return true
+}
+
+// SetValidators sets the entity tag and last modification time sent in the ETag and Last-Modified
+// headers of the OK response, e.g. after the resource has been updated.
+func (ctx *ListBottleContext) SetValidators(etag string, modTime time.Time) {
+	ctx.etag = etag
+	ctx.lastModified = modTime
+}
+`
+
+	conditionalContextOK = `
+func (ctx *ListBottleContext) OK() error {
+	goa.SetValidators(ctx.ResponseData.Header(), ctx.etag, ctx.lastModified)
+	ctx.ResponseData.WriteHeader(200)
+	return nil
+}
+`
+
+	conditionalContextNotFound = `
+func (ctx *ListBottleContext) NotFound() error {
+	ctx.ResponseData.WriteHeader(404)
+	return nil
+}
+`
+
 	payloadObjContext = `
 type ListBottleContext struct {
 	context.Context
@@ -2440,11 +2827,11 @@ func unmarshalListBottlePayload(ctx context.Context, service *goa.Service, req *
 
 	payloadMultipartObjUnmarshalRatios = `
 	rawRatios := req.Form["ratios[]"]
-	tmpRatios := make([]float, len(rawRatios))
+	tmpRatios := make([]float64, len(rawRatios))
 	for i := 0; i < len(rawRatios); i++ {
-		tmp, err2 := strconv.ParseFloat(rawRatios[i])
+		tmp, err2 := strconv.ParseFloat(rawRatios[i], 64)
 		if err2 != nil {
-			err = goa.MergeErrors(err, goa.InvalidParamTypeError("ratios", rawRatios, "[]float"))
+			err = goa.MergeErrors(err, goa.InvalidParamTypeError("ratios", rawRatios, "[]float64"))
 			break
 		}
 		tmpRatios[i] = tmp
@@ -2495,7 +2882,7 @@ type PublicController interface {
 }
 `
 
-	fileServerOptionsHandler = `service.Mux.Handle("OPTIONS", "/public/star\\*star/*filepath", ctrl.MuxHandler("preflight", handlePublicOrigin(cors.HandlePreflight()), nil))`
+	fileServerOptionsHandler = `service.Mount(&goa.Route{Method: "OPTIONS", Path: "/public/star\\*star/*filepath", Controller: "Public", Action: "preflight"}, ctrl.MuxHandler("preflight", handlePublicOrigin(cors.HandlePreflight()), nil))`
 
 	simpleController = `// BottlesController is the controller interface for the Bottles actions.
 type BottlesController interface {
@@ -2506,7 +2893,7 @@ type BottlesController interface {
 
 	originsIntegration = `}
 	h = handleBottlesOrigin(h)
-	service.Mux.Handle`
+	service.Mount`
 
 	originsHandler = `// handleBottlesOrigin applies the CORS response headers corresponding to the origin.
 func handleBottlesOrigin(h goa.Handler) goa.Handler {
@@ -2607,7 +2994,7 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 		}
 		return ctrl.List(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("list", h, nil))
+	service.Mount(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Controller: "Bottles", Action: "list"}, ctrl.MuxHandler("list", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 }
 `
@@ -2628,7 +3015,7 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 		}
 		return ctrl.List(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("list", h, nil))
+	service.Mount(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Controller: "Bottles", Action: "list"}, ctrl.MuxHandler("list", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 }
 `
@@ -2657,7 +3044,7 @@ type BottlesController interface {
 		}
 		return ctrl.List(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("list", h, nil))
+	service.Mount(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Controller: "Bottles", Action: "list"}, ctrl.MuxHandler("list", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -2672,7 +3059,7 @@ type BottlesController interface {
 		}
 		return ctrl.Show(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles/:id", ctrl.MuxHandler("show", h, nil))
+	service.Mount(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles/:id", Controller: "Bottles", Action: "show"}, ctrl.MuxHandler("show", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "Show", "route", "GET /accounts/:accountID/bottles/:id")
 }
 `
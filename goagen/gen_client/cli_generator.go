@@ -187,10 +187,12 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 		Actions      map[string][]*design.ActionDefinition
 		Package      string
 		HasDownloads bool
+		NumericFlags []numericFlag
 	}{
 		Actions:      actions,
 		Package:      g.Target,
 		HasDownloads: hasDownloads,
+		NumericFlags: numericFlags,
 	}
 	if err = file.ExecuteTemplate("registerCmds", registerCmdsT, funcs, data); err != nil {
 		return err
@@ -240,6 +242,7 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 				"Resource":        action.Parent,
 				"Package":         g.Target,
 				"HasMultiContent": len(g.API.Consumes) > 1,
+				"EventStream":     action.EventStream() != nil,
 			}
 			var err error
 			if action.WebSocket() {
@@ -347,6 +350,9 @@ func joinNames(useNil bool, atts ...*design.AttributeDefinition) string {
 // resolve non required, non array Param/QueryParam for access via CII flags.
 // Some types need convertion from string to 'Type' before calling rich client Commands.
 func flagTypeVal(a *design.AttributeDefinition, key string, field string) string {
+	if a.NumericFormat() != "" {
+		return "%s"
+	}
 	switch a.Type {
 	case design.Integer:
 		return `intFlagVal("` + key + `", ` + field + ")"
@@ -364,6 +370,9 @@ func flagTypeVal(a *design.AttributeDefinition, key string, field string) string
 // Special types like Number/UUID need to be converted from String
 // %s maps to specialTypeResult.Temps
 func flagRequiredTypeVal(a *design.AttributeDefinition, field string) string {
+	if a.NumericFormat() != "" {
+		return "*%s"
+	}
 	switch a.Type {
 	case design.Number, design.Boolean, design.UUID, design.DateTime, design.Any:
 		return "*%s"
@@ -376,6 +385,9 @@ func flagRequiredTypeVal(a *design.AttributeDefinition, field string) string {
 // Special types like Number/UUID need to be converted from String
 // %s maps to specialTypeResult.Temps
 func flagTypeArrayVal(a *design.AttributeDefinition, field string) string {
+	if isArrayOfNumericFormat(a.Type) {
+		return "%s"
+	}
 	switch a.Type.ToArray().ElemType.Type {
 	case design.Number, design.Boolean, design.UUID, design.DateTime, design.Any:
 		return "%s"
@@ -428,9 +440,15 @@ func handleSpecialTypes(atts ...*design.AttributeDefinition) specialTypeResult {
 		for _, n := range keys {
 			a := obj[n]
 			field := fmt.Sprintf("cmd.%s", codegen.Goify(n, true))
-			typ := cmdFieldType(a.Type, true)
+			typ := cmdFieldType(a, true)
 			var typeHandler, nilVal string
-			if !a.Type.IsArray() {
+			if a.NumericFormat() != "" {
+				nilVal = `""`
+				typeHandler = codegen.GoNativeTypeAtt(a) + "Val"
+			} else if isArrayOfNumericFormat(a.Type) {
+				nilVal = "nil"
+				typeHandler = codegen.GoNativeTypeAtt(a.Type.ToArray().ElemType) + "Array"
+			} else if !a.Type.IsArray() {
 				nilVal = `""`
 				switch a.Type {
 				case design.Number:
@@ -554,8 +572,27 @@ func signerArgs(sec *design.SecuritySchemeDefinition) string {
 	}
 }
 
+// numericFlag describes the Go type of a numeric bit-width format and the code that parses flag
+// values into it.
+type numericFlag struct {
+	Type, Parse string
+}
+
+// numericFlags lists the parsers generated for flags of integer or number attributes that define
+// a bit-width format.
+var numericFlags = []numericFlag{
+	{"int32", "strconv.ParseInt(val, 10, 32)"},
+	{"int64", "strconv.ParseInt(val, 10, 64)"},
+	{"uint32", "strconv.ParseUint(val, 10, 32)"},
+	{"uint64", "strconv.ParseUint(val, 10, 64)"},
+	{"float32", "strconv.ParseFloat(val, 32)"},
+}
+
 // flagType returns the flag type for the given (basic type) attribute definition.
 func flagType(att *design.AttributeDefinition) string {
+	if att.NumericFormat() != "" {
+		return "String"
+	}
 	switch att.Type.Kind() {
 	case design.IntegerKind:
 		return "Int"
@@ -590,7 +627,7 @@ func flagType(att *design.AttributeDefinition) string {
 }
 
 func defaultVal(att *design.AttributeDefinition) string {
-	if att.Type.Kind() == design.IntegerKind {
+	if att.Type.Kind() == design.IntegerKind && att.NumericFormat() == "" {
 		return fmt.Sprintf("%v", att.DefaultValue)
 	}
 	return fmt.Sprintf("%q", fmt.Sprintf("%v", att.DefaultValue))
@@ -701,11 +738,11 @@ const commandTypesTmpl = `{{ $cmdName := goify (printf "%s%sCommand" .Name (titl
 {{ if .Payload }}		Payload string
 		ContentType string
 {{ end }}{{ $params := defaultRouteParams . }}{{ if $params }}{{ range $name, $att := $params.Type.ToObject }}{{ if $att.Description }}		{{ multiComment $att.Description }}
-{{ end }}		{{ goify $name true }} {{ cmdFieldType $att.Type false }}
+{{ end }}		{{ goify $name true }} {{ cmdFieldType $att false }}
 {{ end }}{{ end }}{{ $params := .QueryParams }}{{ if $params }}{{ range $name, $att := $params.Type.ToObject }}{{ if $att.Description }}		{{ multiComment $att.Description }}
-{{ end }}		{{ goify $name true }} {{ cmdFieldType $att.Type false}}
+{{ end }}		{{ goify $name true }} {{ cmdFieldType $att false}}
 {{ end }}{{ end }}{{ $headers := .Headers }}{{ if $headers }}{{ range $name, $att := $headers.Type.ToObject }}{{ if $att.Description }}		{{ multiComment $att.Description }}
-{{ end }}		{{ goify $name true }} {{ cmdFieldType $att.Type false}}
+{{ end }}		{{ goify $name true }} {{ cmdFieldType $att false}}
 {{ end }}{{ end }}		PrettyPrint bool
 	}
 
@@ -798,11 +835,11 @@ func (cmd *{{ $cmdName }}) RegisterFlags(cc *cobra.Command, c *{{ .Package }}.Cl
 {{ if .Action.Payload }}	cc.Flags().StringVar(&cmd.Payload, "payload", "", "Request body encoded in JSON")
 	cc.Flags().StringVar(&cmd.ContentType, "content", "", "Request content type override, e.g. 'application/x-www-form-urlencoded'")
 {{ end }}{{ $pparams := defaultRouteParams .Action }}{{ if $pparams }}{{ range $pname, $pparam := $pparams.Type.ToObject }}{{ $tmp := goify $pname false }}{{/*
-*/}}{{ if not $pparam.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $pparam.Type false }}
+*/}}{{ if not $pparam.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $pparam false }}
 {{ end }}	cc.Flags().{{ flagType $pparam }}Var(&cmd.{{ goify $pname true }}, "{{ $pname }}", {{/*
 */}}{{ if $pparam.DefaultValue }}{{ defaultVal $pparam }}{{ else }}{{ $tmp }}{{ end }}, ` + "`" + `{{ escapeBackticks $pparam.Description }}` + "`" + `)
 {{ end }}{{ end }}{{ $params := .Action.QueryParams }}{{ if $params }}{{ range $name, $param := $params.Type.ToObject }}{{ $tmp := goify $name false }}{{/*
-*/}}{{ if not $param.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $param.Type false }}
+*/}}{{ if not $param.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $param false }}
 {{ end }}	cc.Flags().{{ flagType $param }}Var(&cmd.{{ goify $name true }}, "{{ $name }}", {{/*
 */}}{{ if $param.DefaultValue }}{{ defaultVal $param }}{{ else }}{{ $tmp }}{{ end }}, ` + "`" + `{{ escapeBackticks $param.Description }}` + "`" + `)
 {{ end }}{{ end }}{{ $headers := .Action.Headers }}{{ if $headers }}{{ range $name, $header := $headers.Type.ToObject }}{{/*
@@ -839,8 +876,9 @@ func (cmd *{{ $cmdName }}) Run(c *{{ .Package }}.Client, args []string) error {
 		return err
 	}
 
-	goaclient.HandleResponse(c.Client, resp, cmd.PrettyPrint)
-	return nil
+{{ if .EventStream }}	goaclient.HandleEventStream(c.Client, resp, cmd.PrettyPrint)
+{{ else }}	goaclient.HandleResponse(c.Client, resp, cmd.PrettyPrint)
+{{ end }}	return nil
 }
 `
 
@@ -1032,4 +1070,29 @@ func boolArray(ins []string) ([]bool, error) {
 		vals = append(vals, *val)
 	}
 	return vals, nil
-}`
+}
+{{ range .NumericFlags }}
+func {{ .Type }}Val(val string) (*{{ .Type }}, error) {
+	t, err := {{ .Parse }}
+	if err != nil {
+		return nil, err
+	}
+	v := {{ .Type }}(t)
+	return &v, nil
+}
+
+func {{ .Type }}Array(ins []string) ([]{{ .Type }}, error) {
+	if ins == nil {
+		return nil, nil
+	}
+	var vals []{{ .Type }}
+	for _, id := range ins {
+		val, err := {{ .Type }}Val(id)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, *val)
+	}
+	return vals, nil
+}
+{{ end }}`
@@ -387,6 +387,70 @@ var _ = Describe("Generate", func() {
 			})
 		})
 	})
+	Context("with an action with params using bit-width formats", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			format := func(f string) *dslengine.ValidationDefinition {
+				return &dslengine.ValidationDefinition{Format: f}
+			}
+			design.Design = &design.APIDefinition{
+				Name:     "testapi",
+				Consumes: design.DefaultEncoders,
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"show": {
+								Name: "show",
+								QueryParams: &design.AttributeDefinition{
+									Type: design.Object{
+										"count": &design.AttributeDefinition{Type: design.Integer, Validation: format(design.FormatInt32)},
+										"size":  &design.AttributeDefinition{Type: design.Integer, Validation: format(design.FormatUint32)},
+										"ratio": &design.AttributeDefinition{Type: design.Number, Validation: format(design.FormatFloat)},
+										"sizes": &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.Integer, Validation: format(design.FormatUint32)}}},
+									},
+								},
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			showAct := fooRes.Actions["show"]
+			showAct.Parent = fooRes
+			showAct.Routes[0].Parent = showAct
+		})
+
+		It("parses the flags into the sized Go types", func() {
+			Ω(genErr).Should(BeNil())
+			c, err := ioutil.ReadFile(filepath.Join(outDir, "tool", "cli", "commands.go"))
+			content := string(c)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("cc.Flags().StringVar(&cmd.Count, "))
+			Ω(content).Should(ContainSubstring(", err = int32Val(cmd.Count)"))
+			Ω(content).Should(ContainSubstring(", err = uint32Val(cmd.Size)"))
+			Ω(content).Should(ContainSubstring(", err = float32Val(cmd.Ratio)"))
+			Ω(content).Should(ContainSubstring("cc.Flags().StringSliceVar(&cmd.Sizes, "))
+			Ω(content).Should(ContainSubstring(", err = uint32Array(cmd.Sizes)"))
+			Ω(content).Should(ContainSubstring("func int32Val(val string) (*int32, error) {\n\tt, err := strconv.ParseInt(val, 10, 32)"))
+			Ω(content).Should(ContainSubstring("func uint32Val(val string) (*uint32, error) {\n\tt, err := strconv.ParseUint(val, 10, 32)"))
+			Ω(content).Should(ContainSubstring("func float32Val(val string) (*float32, error) {\n\tt, err := strconv.ParseFloat(val, 32)"))
+		})
+
+		It("uses the sized Go types in the client methods", func() {
+			Ω(genErr).Should(BeNil())
+			c, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			content := string(c)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("count *int32, ratio *float32, size *uint32, sizes []uint32"))
+		})
+	})
 	Context("with an action with a special typed UUID path param", func() {
 		BeforeEach(func() {
 			codegen.TempCount = 0
@@ -6,6 +6,12 @@ service.
 The generated code includes a client package with:
 
     * One client method per resource action
+    * One request builder (New<Action><Resource>Request) and one response decoder
+      (Decode<Action><Resource>Response) per resource action, these make it possible to send the
+      requests with a custom transport
+    * Methods of conditional actions accept goaclient.RequestOption values such as
+      goaclient.IfMatch or goaclient.IfNoneMatch which set the conditional request headers, their
+      response decoders return no error for 304 Not Modified responses
     * Helper functions to build the corresponding request paths
     * Structs for the action payloads and dependent types
     * Structs for the action media types and corresponding decoder functions
@@ -1,8 +1,10 @@
 package genclient
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -347,6 +349,7 @@ func (g *Generator) generateResourceClient(pkgDir string, res *design.ResourceDe
 		codegen.SimpleImport("time"),
 		codegen.SimpleImport("context"),
 		codegen.SimpleImport("golang.org/x/net/websocket"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 		codegen.NewImport("uuid", "github.com/goadesign/goa/uuid"),
 	}
 	title := fmt.Sprintf("%s: %s Resource Client", g.API.Context(), res.Name)
@@ -464,6 +467,8 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		clientsTmpl   = template.Must(template.New("clients").Funcs(funcs).Parse(clientsTmpl))
 		requestsTmpl  = template.Must(template.New("requests").Funcs(funcs).Parse(requestsTmpl))
 		clientsWSTmpl = template.Must(template.New("clientsws").Funcs(funcs).Parse(clientsWSTmpl))
+		eventsTmpl    = template.Must(template.New("events").Funcs(funcs).Parse(eventsTmpl))
+		decodeTmpl    = template.Must(template.New("decode").Funcs(funcs).Parse(decodeTmpl))
 	)
 	if action.Payload != nil {
 		params = append(params, "payload "+codegen.GoTypeRef(action.Payload, action.Payload.AllRequired(), 1, false))
@@ -479,11 +484,11 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		// Update closure
 		for _, p := range reqData {
 			names = append(names, p.VarName)
-			params = append(params, p.VarName+" "+cmdFieldType(p.Attribute.Type, false))
+			params = append(params, p.VarName+" "+cmdFieldType(p.Attribute, false))
 		}
 		for _, p := range optData {
 			names = append(names, p.VarName)
-			params = append(params, p.VarName+" "+cmdFieldType(p.Attribute.Type, p.Attribute.Type.IsPrimitive()))
+			params = append(params, p.VarName+" "+cmdFieldType(p.Attribute, p.Attribute.Type.IsPrimitive()))
 		}
 		return append(reqData, optData...)
 	}
@@ -507,6 +512,8 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		ParamNames         string
 		CanonicalScheme    string
 		Signer             string
+		Conditional        bool
+		Idempotent         bool
 		QueryParams        []*paramData
 		Headers            []*paramData
 	}{
@@ -523,6 +530,8 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		ParamNames:         strings.Join(names, ", "),
 		CanonicalScheme:    action.CanonicalScheme(),
 		Signer:             signer,
+		Conditional:        action.Conditional,
+		Idempotent:         idempotent(action),
 		QueryParams:        queryParams,
 		Headers:            headers,
 	}
@@ -532,7 +541,163 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 	if err := clientsTmpl.Execute(file, data); err != nil {
 		return err
 	}
-	return requestsTmpl.Execute(file, data)
+	if err := requestsTmpl.Execute(file, data); err != nil {
+		return err
+	}
+	if resp := action.EventStream(); resp != nil {
+		evData, err := eventStreamData(resp)
+		if err != nil {
+			return err
+		}
+		evData["Client"] = data
+		return eventsTmpl.Execute(file, evData)
+	}
+	decData, err := responseDecoderData(action)
+	if err != nil || decData == nil {
+		return err
+	}
+	decData["Client"] = data
+	return decodeTmpl.Execute(file, decData)
+}
+
+// idempotent returns true if the action or its resource define the "idempotency" metadata used
+// by the idempotency middleware, in which case the client sends an Idempotency-Key header.
+func idempotent(action *design.ActionDefinition) bool {
+	if _, ok := action.Metadata["idempotency"]; ok {
+		return true
+	}
+	if action.Parent != nil {
+		_, ok := action.Parent.Metadata["idempotency"]
+		return ok
+	}
+	return false
+}
+
+// responseDecoderData computes the data used to render the response decoder of the given
+// action. It returns nil if the action responses are streamed.
+func responseDecoderData(action *design.ActionDefinition) (map[string]interface{}, error) {
+	var (
+		cases    []map[string]interface{}
+		results  []string
+		statuses = make(map[int]bool)
+	)
+	if action.Conditional {
+		// 304 Not Modified responses to conditional requests are not errors and have no body.
+		cases = append(cases, map[string]interface{}{"Status": http.StatusNotModified, "Name": "NotModified", "Error": false})
+		statuses[http.StatusNotModified] = true
+	}
+	err := action.IterateResponses(func(resp *design.ResponseDefinition) error {
+		if resp.Stream != 0 {
+			return errStreamed
+		}
+		if statuses[resp.Status] {
+			return nil
+		}
+		statuses[resp.Status] = true
+		c := map[string]interface{}{"Status": resp.Status, "Name": resp.Name}
+		success := resp.Status >= 200 && resp.Status < 300
+		c["Error"] = !success
+		cases = append(cases, c)
+		var mt *design.MediaTypeDefinition
+		if resp.Type != nil {
+			var ok bool
+			if mt, ok = resp.Type.(*design.MediaTypeDefinition); !ok {
+				if resp.Type.IsPrimitive() {
+					return nil
+				}
+				if !success {
+					c["Type"] = codegen.GoTypeName(resp.Type, nil, 0, false)
+					return nil
+				}
+				c["DecodeType"] = codegen.GoTypeName(resp.Type, nil, 0, false)
+				c["IsObject"] = resp.Type.IsObject()
+				c["Result"] = codegen.GoTypeRef(resp.Type, nil, 0, false)
+			}
+		} else if resp.MediaType != "" {
+			mt = design.Design.MediaTypeWithIdentifier(resp.MediaType)
+		}
+		if mt != nil {
+			view := resp.ViewName
+			if view == "" {
+				view = design.DefaultView
+			}
+			projected, _, err := mt.Project(view)
+			if err != nil {
+				return err
+			}
+			if projected.Type.IsPrimitive() {
+				return nil
+			}
+			if !success {
+				if !mt.IsError() {
+					c["Type"] = decodeGoTypeName(projected, projected.AllRequired(), 0, false)
+				}
+				return nil
+			}
+			c["Decode"] = "Decode" + typeName(projected)
+			c["Result"] = decodeGoTypeRef(projected, projected.AllRequired(), 0, false)
+		}
+		if c["Result"] == nil {
+			return nil
+		}
+		for _, r := range results {
+			if r == c["Result"] {
+				return nil
+			}
+		}
+		results = append(results, c["Result"].(string))
+		return nil
+	})
+	if err == errStreamed {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i]["Status"].(int) < cases[j]["Status"].(int)
+	})
+	data := map[string]interface{}{"Cases": cases}
+	switch len(results) {
+	case 0:
+	case 1:
+		data["Result"] = results[0]
+	default:
+		data["Result"] = "interface{}"
+		data["Multi"] = true
+	}
+	return data, nil
+}
+
+// eventStreamData computes the data used to render the event iterator of the given Server-Sent
+// Events response.
+func eventStreamData(resp *design.ResponseDefinition) (map[string]interface{}, error) {
+	data := map[string]interface{}{"Status": resp.Status}
+	mt, ok := resp.Type.(*design.MediaTypeDefinition)
+	if resp.Type != nil && !ok {
+		data["Type"] = codegen.GoTypeRef(resp.Type, nil, 0, false)
+		data["TypeName"] = codegen.GoTypeName(resp.Type, nil, 0, false)
+		data["IsObject"] = resp.Type.IsObject()
+		return data, nil
+	}
+	if mt == nil {
+		mt = design.Design.MediaTypeWithIdentifier(resp.MediaType)
+	}
+	if mt == nil {
+		return data, nil
+	}
+	view := resp.ViewName
+	if view == "" {
+		view = design.DefaultView
+	}
+	projected, _, err := mt.Project(view)
+	if err != nil {
+		return nil, err
+	}
+	data["Type"] = decodeGoTypeRef(projected, projected.AllRequired(), 0, false)
+	data["TypeName"] = decodeGoTypeName(projected, projected.AllRequired(), 0, false)
+	data["IsObject"] = projected.IsObject()
+	return data, nil
 }
 
 // fileServerMethod returns the name of the client method for downloading assets served by the given
@@ -698,7 +863,7 @@ func join(att *design.AttributeDefinition, usePointers bool, pos ...[]string) st
 	for i, n := range keys {
 		a := obj[n]
 		elems[i] = fmt.Sprintf("%s %s", codegen.Goify(n, false),
-			cmdFieldType(a.Type, usePointers && !a.IsRequired(n)))
+			cmdFieldType(a, usePointers && !a.IsRequired(n)))
 	}
 	return strings.Join(elems, ", ")
 }
@@ -745,25 +910,26 @@ func decodeGoTypeName(t design.DataType, required []string, tabs int, private bo
 	return codegen.GoTypeName(t, required, tabs, private)
 }
 
-// cmdFieldType computes the Go type name used to store command flags of the given design type.
-func cmdFieldType(t design.DataType, point bool) string {
+// cmdFieldType computes the Go type name used to store command flags of the given attribute.
+func cmdFieldType(att *design.AttributeDefinition, point bool) string {
 	var pointer, suffix string
-	if point && !t.IsArray() {
+	if point && !att.Type.IsArray() {
 		pointer = "*"
 	}
-	suffix = codegen.GoNativeType(t)
+	suffix = codegen.GoNativeTypeAtt(att)
 	return pointer + suffix
 }
 
-// cmdFieldTypeString computes the Go type name used to store command flags of the given design type. Complex types are String
-func cmdFieldTypeString(t design.DataType, point bool) string {
+// cmdFieldTypeString computes the Go type name used to store command flags of the given attribute. Complex types are String
+func cmdFieldTypeString(att *design.AttributeDefinition, point bool) string {
 	var pointer, suffix string
+	t := att.Type
 	if point && !t.IsArray() {
 		pointer = "*"
 	}
-	if t.Kind() == design.UUIDKind || t.Kind() == design.DateTimeKind || t.Kind() == design.AnyKind || t.Kind() == design.NumberKind || t.Kind() == design.BooleanKind {
+	if t.Kind() == design.UUIDKind || t.Kind() == design.DateTimeKind || t.Kind() == design.AnyKind || t.Kind() == design.NumberKind || t.Kind() == design.BooleanKind || att.NumericFormat() != "" {
 		suffix = "string"
-	} else if isArrayOfType(t, design.UUIDKind, design.DateTimeKind, design.AnyKind, design.NumberKind, design.BooleanKind) {
+	} else if isArrayOfType(t, design.UUIDKind, design.DateTimeKind, design.AnyKind, design.NumberKind, design.BooleanKind) || isArrayOfNumericFormat(t) {
 		suffix = "[]string"
 	} else {
 		suffix = codegen.GoNativeType(t)
@@ -771,6 +937,12 @@ func cmdFieldTypeString(t design.DataType, point bool) string {
 	return pointer + suffix
 }
 
+// isArrayOfNumericFormat returns true if the given type is an array of integers or numbers that
+// define a bit-width format.
+func isArrayOfNumericFormat(t design.DataType) bool {
+	return t.IsArray() && t.ToArray().ElemType.NumericFormat() != ""
+}
+
 func isArrayOfType(array design.DataType, kinds ...design.Kind) bool {
 	if !array.IsArray() {
 		return false
@@ -794,10 +966,19 @@ func toString(name, target string, att *design.AttributeDefinition) string {
 	case design.Primitive:
 		switch actual.Kind() {
 		case design.IntegerKind:
+			switch att.NumericFormat() {
+			case design.FormatInt32, design.FormatInt64:
+				return fmt.Sprintf("%s := strconv.FormatInt(int64(%s), 10)", target, name)
+			case design.FormatUint32, design.FormatUint64:
+				return fmt.Sprintf("%s := strconv.FormatUint(uint64(%s), 10)", target, name)
+			}
 			return fmt.Sprintf("%s := strconv.Itoa(%s)", target, name)
 		case design.BooleanKind:
 			return fmt.Sprintf("%s := strconv.FormatBool(%s)", target, name)
 		case design.NumberKind:
+			if att.NumericFormat() == design.FormatFloat {
+				return fmt.Sprintf("%s := strconv.FormatFloat(float64(%s), 'f', -1, 32)", target, name)
+			}
 			return fmt.Sprintf("%s := strconv.FormatFloat(%s, 'f', -1, 64)", target, name)
 		case design.StringKind:
 			return fmt.Sprintf("%s := %s", target, name)
@@ -928,6 +1109,10 @@ func initParams(att *design.AttributeDefinition) ([]*paramData, []*paramData) {
 	return reqParamData, optParamData
 }
 
+// errStreamed is used by responseDecoderData to stop iterating on the action responses when one
+// is streamed.
+var errStreamed = errors.New("streamed response")
+
 // paramData is the data structure holding the information needed to generate query params and
 // headers handling code.
 type paramData struct {
@@ -980,8 +1165,8 @@ func {{ $funcName }}({{ pathParams .Route }}) string {
 	clientsTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{ $desc := .Description }}{{/*
 */}}{{ if $desc }}{{ multiComment $desc }}{{ else }}{{/*
 */}}// {{ $funcName }} makes a request to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource{{ end }}
-func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if and .HasPayload .HasMultiContent }}, contentType string{{ end }}) (*http.Response, error) {
-	req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}{{ if and .HasPayload .HasMultiContent }}, contentType{{ end }})
+func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if and .HasPayload .HasMultiContent }}, contentType string{{ end }}{{ if .Conditional }}, opts ...goaclient.RequestOption{{ end }}) (*http.Response, error) {
+	req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}{{ if and .HasPayload .HasMultiContent }}, contentType{{ end }}{{ if .Conditional }}, opts...{{ end }})
 	if err != nil {
 		return nil, err
 	}
@@ -1026,6 +1211,55 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 	cfg.Header["{{ $header.Name }}"] = []string{ {{ $tmp }} }
 {{ end }}	return websocket.DialConfig(cfg)
 }
+`
+
+	eventsTmpl = `{{ $funcName := goify (printf "%s%s" .Client.Name (title .Client.ResourceName)) true }}{{ $iter := printf "%sEvents" $funcName }}{{/*
+*/}}// {{ $iter }} iterates over the Server-Sent Events streamed by the {{ .Client.Name }} action of the {{ .Client.ResourceName }} resource.
+type {{ $iter }} struct {
+	c      *Client
+	resp   *http.Response
+	reader *goaclient.EventReader
+}
+
+// {{ $iter }} makes a request to the {{ .Client.Name }} action endpoint of the {{ .Client.ResourceName }} resource and
+// returns an iterator over the streamed events. The iterator must be closed once done.
+func (c *Client) {{ $iter }}(ctx context.Context, path string{{ if .Client.Params }}, {{ .Client.Params }}{{ end }}{{ if and .Client.HasPayload .Client.HasMultiContent }}, contentType string{{ end }}) (*{{ $iter }}, error) {
+	resp, err := c.{{ $funcName }}(ctx, path{{ if .Client.ParamNames }}, {{ .Client.ParamNames }}{{ end }}{{ if and .Client.HasPayload .Client.HasMultiContent }}, contentType{{ end }})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != {{ .Status }} {
+		defer resp.Body.Close()
+		var body string
+		if b, err := ioutil.ReadAll(resp.Body); err == nil && len(b) > 0 {
+			body = ": " + string(b)
+		}
+		return nil, fmt.Errorf("%s%s", resp.Status, body)
+	}
+	return &{{ $iter }}{c: c, resp: resp, reader: goaclient.NewEventReader(resp.Body)}, nil
+}
+
+// Next blocks until the next event is received and returns its decoded data. It returns io.EOF
+// once the server closes the stream.
+func (it *{{ $iter }}) Next() ({{ if .Type }}{{ .Type }}{{ else }}[]byte{{ end }}, error) {
+{{ if .Type }}	var decoded {{ .TypeName }}
+	ev, err := it.reader.Next()
+	if err != nil {
+		return {{ if .IsObject }}nil{{ else }}decoded{{ end }}, err
+	}
+	err = it.c.Decoder.Decode(&decoded, bytes.NewReader(ev.Data), "application/json")
+	return {{ if .IsObject }}&{{ end }}decoded, err
+{{ else }}	ev, err := it.reader.Next()
+	if err != nil {
+		return nil, err
+	}
+	return ev.Data, nil
+{{ end }}}
+
+// Close closes the event stream.
+func (it *{{ $iter }}) Close() error {
+	return it.resp.Body.Close()
+}
 `
 
 	fsTmpl = `// {{ .Name }} downloads {{ if .DirName }}{{ .DirName }}files with the given filename{{ else }}{{ .FileName }}{{ end }} and writes it to the file dest.
@@ -1066,7 +1300,7 @@ func (c * Client) {{ .Name }}(ctx context.Context, {{ if .DirName }}filename, {{
 
 	requestsTmpl = `{{ $funcName := goify (printf "New%s%sRequest" (title .Name) (title .ResourceName)) true }}{{/*
 */}}// {{ $funcName }} create the request corresponding to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
-func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if .HasPayload }}{{ if .HasMultiContent }}, contentType string{{ end }}{{ end }}) (*http.Request, error) {
+func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if .HasPayload }}{{ if .HasMultiContent }}, contentType string{{ end }}{{ end }}{{ if .Conditional }}, opts ...goaclient.RequestOption{{ end }}) (*http.Request, error) {
 {{ if .HasPayload }}	var body bytes.Buffer
 {{ if .PayloadMultipart }}	w := multipart.NewWriter(&body)
 {{ $payload := .Payload.Definition }}
@@ -1164,13 +1398,41 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 	header.Set("{{ .Name }}", {{ $tmp }}){{ else }}
 	header.Set("{{ .Name }}", {{ .ValueName }})
 {{ end }}{{ if .CheckNil }}	}{{ end }}
-{{ end }}{{ end }}{{ if .Signer }}	if c.{{ .Signer }}Signer != nil {
+{{ end }}{{ end }}{{ if .Idempotent }}	goaclient.SetIdempotencyKey(ctx, req)
+{{ end }}{{ if .Conditional }}	for _, opt := range opts {
+		opt(req)
+	}
+{{ end }}{{ if .Signer }}	if c.{{ .Signer }}Signer != nil {
 		if err := c.{{ .Signer }}Signer.Sign(req); err != nil {
 			return nil, err
 		}
 	}
 {{ end }}	return req, nil
 }
+`
+
+	decodeTmpl = `{{ $funcName := goify (printf "Decode%s%sResponse" (title .Client.Name) (title .Client.ResourceName)) true }}{{/*
+*/}}// {{ $funcName }} decodes the response of the {{ .Client.Name }} action of the {{ .Client.ResourceName }} resource and closes
+// its body.{{ if .Multi }} The type of the result depends on the response status:{{ range .Cases }}{{ if .Result }}
+//   - {{ .Status }} ({{ .Name }}): {{ .Result }}{{ end }}{{ end }}
+//{{ end }} It returns a *goaclient.ResponseError for error responses.{{ if .Client.Conditional }}
+// It returns {{ if .Result }}a nil result and {{ end }}no error for 304 Not Modified responses.{{ end }}
+func (c *Client) {{ $funcName }}(resp *http.Response) ({{ if .Result }}{{ .Result }}, {{ end }}error) {
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+{{ range .Cases }}	case {{ .Status }}:
+{{ if .Error }}		return {{ if $.Result }}nil, {{ end }}goaclient.NewResponseError(resp, c.Decoder, {{ if .Type }}new({{ .Type }}){{ else }}nil{{ end }})
+{{ else if .Decode }}{{ if $.Multi }}		res, err := c.{{ .Decode }}(resp)
+		return res, err
+{{ else }}		return c.{{ .Decode }}(resp)
+{{ end }}{{ else if .DecodeType }}		var decoded {{ .DecodeType }}
+		err := c.Decoder.Decode(&decoded, resp.Body, resp.Header.Get("Content-Type"))
+		return {{ if .IsObject }}&{{ end }}decoded, err
+{{ else }}		return {{ if $.Result }}nil, {{ end }}nil
+{{ end }}{{ end }}	default:
+		return {{ if .Result }}nil, {{ end }}goaclient.NewResponseError(resp, c.Decoder, nil)
+	}
+}
 `
 
 	clientTmpl = `// Client is the {{ .API.Name }} service client.
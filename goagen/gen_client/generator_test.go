@@ -377,6 +377,255 @@ var _ = Describe("Generate", func() {
 		})
 	})
 
+	Context("with an action streaming events", func() {
+		BeforeEach(func() {
+			mt := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"name": {Type: design.String}},
+					},
+					TypeName: "GoaEvent",
+				},
+				Identifier: "application/vnd.goa.event",
+			}
+			mt.Views = map[string]*design.ViewDefinition{"default": {
+				AttributeDefinition: mt.AttributeDefinition,
+				Name:                "default",
+				Parent:              mt,
+			}}
+			design.ProjectedMediaTypes = make(map[string]*design.MediaTypeDefinition)
+			design.Design = &design.APIDefinition{
+				Name:       "testapi",
+				Consumes:   design.DefaultEncoders,
+				MediaTypes: map[string]*design.MediaTypeDefinition{design.CanonicalIdentifier(mt.Identifier): mt},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"watch": {
+								Name: "watch",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {
+										Name:      "OK",
+										Status:    200,
+										MediaType: mt.Identifier,
+										Stream:    design.SSEStream,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			watchAct := fooRes.Actions["watch"]
+			watchAct.Parent = fooRes
+			watchAct.Routes[0].Parent = watchAct
+		})
+
+		It("generates a typed event iterator", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("type WatchFooEvents struct {"))
+			Ω(content).Should(ContainSubstring("func (c *Client) WatchFooEvents(ctx context.Context, path string) (*WatchFooEvents, error) {"))
+			Ω(content).Should(ContainSubstring("func (it *WatchFooEvents) Next() (*GoaEvent, error) {"))
+			Ω(content).Should(ContainSubstring("reader: goaclient.NewEventReader(resp.Body)"))
+		})
+
+		It("prints the events from the CLI command", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "tool", "cli", "commands.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("goaclient.HandleEventStream(c.Client, resp, cmd.PrettyPrint)"))
+		})
+	})
+
+	Context("with an action with typed responses", func() {
+		newMediaType := func(identifier, typeName string) *design.MediaTypeDefinition {
+			mt := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"name": {Type: design.String}},
+					},
+					TypeName: typeName,
+				},
+				Identifier: identifier,
+			}
+			mt.Views = map[string]*design.ViewDefinition{"default": {
+				AttributeDefinition: mt.AttributeDefinition,
+				Name:                "default",
+				Parent:              mt,
+			}}
+			return mt
+		}
+
+		BeforeEach(func() {
+			bottle := newMediaType("application/vnd.goa.bottle", "GoaBottle")
+			conflict := newMediaType("application/vnd.goa.conflict", "GoaConflict")
+			design.ProjectedMediaTypes = make(map[string]*design.MediaTypeDefinition)
+			design.Design = &design.APIDefinition{
+				Name:     "testapi",
+				Consumes: design.DefaultEncoders,
+				MediaTypes: map[string]*design.MediaTypeDefinition{
+					design.CanonicalIdentifier(bottle.Identifier):            bottle,
+					design.CanonicalIdentifier(conflict.Identifier):          conflict,
+					design.CanonicalIdentifier(design.ErrorMedia.Identifier): design.ErrorMedia,
+				},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"update": {
+								Name: "update",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "PUT",
+										Path: "",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK":        {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+									"NoContent": {Name: "NoContent", Status: 204},
+									"NotFound":  {Name: "NotFound", Status: 404, MediaType: design.ErrorMedia.Identifier},
+									"Conflict":  {Name: "Conflict", Status: 409, MediaType: conflict.Identifier},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			updateAct := fooRes.Actions["update"]
+			updateAct.Parent = fooRes
+			updateAct.Routes[0].Parent = updateAct
+		})
+
+		It("generates a request builder and a typed response decoder", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *Client) NewUpdateFooRequest(ctx context.Context, path string) (*http.Request, error) {"))
+			Ω(content).Should(ContainSubstring(typedResponseDecoder))
+		})
+
+		Context("using a type that is not a media type", func() {
+			BeforeEach(func() {
+				ok := design.Design.Resources["foo"].Actions["update"].Responses["OK"]
+				ok.Type = &design.Hash{
+					KeyType:  &design.AttributeDefinition{Type: design.String},
+					ElemType: &design.AttributeDefinition{Type: design.Integer},
+				}
+			})
+
+			It("decodes the response body into the response type", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring("func (c *Client) DecodeUpdateFooResponse(resp *http.Response) (map[string]int, error) {"))
+				Ω(content).Should(ContainSubstring(hashResponseDecoderCase))
+				Ω(content).ShouldNot(ContainSubstring("return c.DecodeGoaBottle(resp)"))
+			})
+		})
+	})
+
+	Context("with a conditional action", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name:     "testapi",
+				Consumes: design.DefaultEncoders,
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"update": {
+								Name:        "update",
+								Conditional: true,
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "PATCH",
+										Path: "",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {Name: "OK", Status: 200, Type: &design.Hash{
+										KeyType:  &design.AttributeDefinition{Type: design.String},
+										ElemType: &design.AttributeDefinition{Type: design.String},
+									}},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			updateAct := fooRes.Actions["update"]
+			updateAct.Parent = fooRes
+			updateAct.Routes[0].Parent = updateAct
+		})
+
+		It("generates methods accepting conditional request options", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *Client) UpdateFoo(ctx context.Context, path string, opts ...goaclient.RequestOption) (*http.Response, error) {"))
+			Ω(content).Should(ContainSubstring("req, err := c.NewUpdateFooRequest(ctx, path, opts...)"))
+			Ω(content).Should(ContainSubstring("func (c *Client) NewUpdateFooRequest(ctx context.Context, path string, opts ...goaclient.RequestOption) (*http.Request, error) {"))
+			Ω(content).Should(ContainSubstring("for _, opt := range opts {\n\t\topt(req)\n\t}"))
+		})
+
+		It("does not decode 304 Not Modified responses as errors", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("// It returns a nil result and no error for 304 Not Modified responses."))
+			Ω(content).Should(ContainSubstring("\tcase 304:\n\t\treturn nil, nil\n"))
+		})
+	})
+
+	Context("with an action using idempotency keys", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name:     "testapi",
+				Consumes: design.DefaultEncoders,
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"create": {
+								Name:     "create",
+								Metadata: dslengine.MetadataDefinition{"idempotency": {"48h"}},
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "POST",
+										Path: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			createAct := fooRes.Actions["create"]
+			createAct.Parent = fooRes
+			createAct.Routes[0].Parent = createAct
+		})
+
+		It("sets the idempotency key header", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("goaclient.SetIdempotencyKey(ctx, req)"))
+		})
+	})
+
 	Context("with an action with multiple routes", func() {
 		BeforeEach(func() {
 			design.Design = &design.APIDefinition{
@@ -682,3 +931,28 @@ const clientHeaderTmpl = `// Code generated by goagen {{ .version }}, DO NOT EDI
 // --design={{.design}}
 // --version={{.version}}
 `
+
+const typedResponseDecoder = `// DecodeUpdateFooResponse decodes the response of the update action of the foo resource and closes
+// its body. It returns a *goaclient.ResponseError for error responses.
+func (c *Client) DecodeUpdateFooResponse(resp *http.Response) (*GoaBottle, error) {
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case 200:
+		return c.DecodeGoaBottle(resp)
+	case 204:
+		return nil, nil
+	case 404:
+		return nil, goaclient.NewResponseError(resp, c.Decoder, nil)
+	case 409:
+		return nil, goaclient.NewResponseError(resp, c.Decoder, new(GoaConflict))
+	default:
+		return nil, goaclient.NewResponseError(resp, c.Decoder, nil)
+	}
+}
+`
+
+const hashResponseDecoderCase = `	case 200:
+		var decoded map[string]int
+		err := c.Decoder.Decode(&decoded, resp.Body, resp.Header.Get("Content-Type"))
+		return decoded, err
+`
@@ -333,7 +333,7 @@ func okResp(a *design.ActionDefinition, appPkg string) map[string]interface{} {
 			break
 		}
 	}
-	if ok == nil {
+	if ok == nil || ok.Stream != 0 {
 		return nil
 	}
 	var mt *design.MediaTypeDefinition
@@ -453,7 +453,8 @@ func (c *{{ $ctrlName }}) {{ goify .Name true }}WSHandler(ctx *{{ targetPkg }}.{
 const mainT = `
 func main() {
 	// Create service
-	service := goa.New({{ printf "%q" .Name }})
+	service := goa.New({{ printf "%q" .Name }}){{ if .API.ProblemDetails }}
+	service.ProblemDetails = true{{ end }}
 
 	// Mount middleware
 	service.Use(middleware.RequestID())
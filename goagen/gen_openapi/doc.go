@@ -0,0 +1,9 @@
+/*
+Package genopenapi provides a generator for OpenAPI 3.0 specifications.
+The generator produces the JSON and YAML representations of the API design in the "openapi"
+directory. It complements the genswagger generator which produces Swagger 2.0 specifications and
+honors the same "swagger:" metadata keys.
+See https://github.com/OAI/OpenAPI-Specification/blob/master/versions/3.0.0.md for more
+information.
+*/
+package genopenapi
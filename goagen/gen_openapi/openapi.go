@@ -0,0 +1,1099 @@
+package genopenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	genschema "github.com/goadesign/goa/goagen/gen_schema"
+)
+
+const (
+	// ndjsonContentType is the content type of newline delimited JSON streams.
+	ndjsonContentType = "application/x-ndjson"
+	// eventStreamContentType is the content type of Server-Sent Events streams.
+	eventStreamContentType = "text/event-stream"
+)
+
+type (
+	// OpenAPI represents an instance of an OpenAPI 3.0 document.
+	// See https://github.com/OAI/OpenAPI-Specification/blob/master/versions/3.0.0.md
+	OpenAPI struct {
+		OpenAPI      string               `json:"openapi"`
+		Info         *Info                `json:"info"`
+		Servers      []*Server            `json:"servers,omitempty"`
+		Paths        map[string]*PathItem `json:"paths"`
+		Components   *Components          `json:"components,omitempty"`
+		Tags         []*Tag               `json:"tags,omitempty"`
+		ExternalDocs *ExternalDocs        `json:"externalDocs,omitempty"`
+	}
+
+	// Info provides metadata about the API.
+	Info struct {
+		Title          string                    `json:"title"`
+		Description    string                    `json:"description,omitempty"`
+		TermsOfService string                    `json:"termsOfService,omitempty"`
+		Contact        *design.ContactDefinition `json:"contact,omitempty"`
+		License        *design.LicenseDefinition `json:"license,omitempty"`
+		Version        string                    `json:"version"`
+		Extensions     map[string]interface{}    `json:"-"`
+	}
+
+	// Server represents a server hosting the API.
+	Server struct {
+		// URL of the target host.
+		URL string `json:"url"`
+		// Description of the host.
+		Description string `json:"description,omitempty"`
+	}
+
+	// PathItem describes the operations available on a single path.
+	PathItem struct {
+		// Get defines a GET operation on this path.
+		Get *Operation `json:"get,omitempty"`
+		// Put defines a PUT operation on this path.
+		Put *Operation `json:"put,omitempty"`
+		// Post defines a POST operation on this path.
+		Post *Operation `json:"post,omitempty"`
+		// Delete defines a DELETE operation on this path.
+		Delete *Operation `json:"delete,omitempty"`
+		// Options defines a OPTIONS operation on this path.
+		Options *Operation `json:"options,omitempty"`
+		// Head defines a HEAD operation on this path.
+		Head *Operation `json:"head,omitempty"`
+		// Patch defines a PATCH operation on this path.
+		Patch *Operation `json:"patch,omitempty"`
+		// Trace defines a TRACE operation on this path.
+		Trace *Operation `json:"trace,omitempty"`
+		// Extensions defines the OpenAPI extensions.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// Operation describes a single API operation on a path.
+	Operation struct {
+		// Tags is a list of tags for API documentation control.
+		Tags []string `json:"tags,omitempty"`
+		// Summary is a short summary of what the operation does.
+		Summary string `json:"summary,omitempty"`
+		// Description is a verbose explanation of the operation behavior.
+		Description string `json:"description,omitempty"`
+		// ExternalDocs points to additional external documentation for this operation.
+		ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
+		// OperationID is a unique string used to identify the operation.
+		OperationID string `json:"operationId,omitempty"`
+		// Parameters is a list of parameters that are applicable for this operation.
+		Parameters []*Parameter `json:"parameters,omitempty"`
+		// RequestBody describes the request body if any.
+		RequestBody *RequestBody `json:"requestBody,omitempty"`
+		// Responses is the list of possible responses as they are returned from executing
+		// this operation indexed by HTTP status code.
+		Responses map[string]*Response `json:"responses"`
+		// Deprecated declares this operation to be deprecated.
+		Deprecated bool `json:"deprecated,omitempty"`
+		// Security is a declaration of which security schemes are applied for this operation.
+		Security []map[string][]string `json:"security,omitempty"`
+		// Extensions defines the OpenAPI extensions.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// Parameter describes a single operation parameter.
+	Parameter struct {
+		// Name of the parameter. Parameter names are case sensitive.
+		Name string `json:"name"`
+		// In is the location of the parameter.
+		// Possible values are "query", "header" and "path".
+		In string `json:"in"`
+		// Description is a brief description of the parameter.
+		Description string `json:"description,omitempty"`
+		// Required determines whether this parameter is mandatory.
+		Required bool `json:"required,omitempty"`
+		// Schema defines the type used for the parameter.
+		Schema *Schema `json:"schema,omitempty"`
+		// Extensions defines the OpenAPI extensions.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// RequestBody describes a single request body.
+	RequestBody struct {
+		// Description is a brief description of the request body.
+		Description string `json:"description,omitempty"`
+		// Content lists the accepted representations indexed by content type.
+		Content map[string]*MediaType `json:"content"`
+		// Required determines if the request body is required in the request.
+		Required bool `json:"required,omitempty"`
+	}
+
+	// MediaType describes the schema of a given content type.
+	MediaType struct {
+		// Schema defining the type used by the body.
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	// Response describes a single response from an API operation.
+	Response struct {
+		// Description of the response.
+		Description string `json:"description"`
+		// Headers is a list of headers that are sent with the response.
+		Headers map[string]*Header `json:"headers,omitempty"`
+		// Content lists the possible representations indexed by content type.
+		Content map[string]*MediaType `json:"content,omitempty"`
+		// Extensions defines the OpenAPI extensions.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// Header represents a header parameter.
+	Header struct {
+		// Description is a brief description of the header.
+		Description string `json:"description,omitempty"`
+		// Required determines whether the header is mandatory.
+		Required bool `json:"required,omitempty"`
+		// Schema defines the type used for the header.
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	// Components holds the reusable objects referenced from the rest of the document.
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+		Responses       map[string]*Response       `json:"responses,omitempty"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	// SecurityScheme defines a security scheme that can be used by the operations.
+	SecurityScheme struct {
+		// Type of the security scheme. Valid values are "apiKey", "http" and "oauth2".
+		Type string `json:"type"`
+		// Description for security scheme.
+		Description string `json:"description,omitempty"`
+		// Name of the header or query parameter to be used when type is "apiKey".
+		Name string `json:"name,omitempty"`
+		// In is the location of the API key when type is "apiKey".
+		// Valid values are "query" and "header".
+		In string `json:"in,omitempty"`
+		// Scheme is the name of the HTTP authorization scheme when type is "http".
+		Scheme string `json:"scheme,omitempty"`
+		// BearerFormat is a hint to the client to identify how the bearer token is
+		// formatted.
+		BearerFormat string `json:"bearerFormat,omitempty"`
+		// Flows contains configuration information for the flow types supported when
+		// type is "oauth2".
+		Flows *OAuthFlows `json:"flows,omitempty"`
+		// Extensions defines the OpenAPI extensions.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// OAuthFlows allows configuration of the supported OAuth2 flows.
+	OAuthFlows struct {
+		Implicit          *OAuthFlow `json:"implicit,omitempty"`
+		Password          *OAuthFlow `json:"password,omitempty"`
+		ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+		AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+	}
+
+	// OAuthFlow contains the configuration details of a supported OAuth2 flow.
+	OAuthFlow struct {
+		AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+		TokenURL         string            `json:"tokenUrl,omitempty"`
+		RefreshURL       string            `json:"refreshUrl,omitempty"`
+		Scopes           map[string]string `json:"scopes"`
+	}
+
+	// Schema is the OpenAPI 3.0 flavor of JSON schema used to describe data types.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Title                string             `json:"title,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties bool               `json:"additionalProperties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Default              interface{}        `json:"default,omitempty"`
+		Example              interface{}        `json:"example,omitempty"`
+		ReadOnly             bool               `json:"readOnly,omitempty"`
+		Enum                 []interface{}      `json:"enum,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		MaxItems             *int               `json:"maxItems,omitempty"`
+		OneOf                []*Schema          `json:"oneOf,omitempty"`
+		AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	}
+
+	// Tag allows adding meta data to a single tag that is used by the Operation Object.
+	Tag struct {
+		// Name of the tag.
+		Name string `json:"name,omitempty"`
+		// Description is a short description of the tag.
+		Description string `json:"description,omitempty"`
+		// ExternalDocs is additional external documentation for this tag.
+		ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
+		// Extensions defines the OpenAPI extensions.
+		Extensions map[string]interface{} `json:"-"`
+	}
+
+	// ExternalDocs allows referencing an external document for extended documentation.
+	ExternalDocs struct {
+		// Description is a short description of the target documentation.
+		Description string `json:"description,omitempty"`
+		// URL for the target documentation.
+		URL string `json:"url"`
+	}
+
+	// These types are used in marshalJSON() to avoid recursive call of json.Marshal().
+	_Info           Info
+	_PathItem       PathItem
+	_Operation      Operation
+	_Parameter      Parameter
+	_Response       Response
+	_SecurityScheme SecurityScheme
+	_Tag            Tag
+)
+
+func marshalJSON(v interface{}, extensions map[string]interface{}) ([]byte, error) {
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(extensions) == 0 {
+		return marshaled, nil
+	}
+	var unmarshaled map[string]interface{}
+	if err := json.Unmarshal(marshaled, &unmarshaled); err != nil {
+		return nil, err
+	}
+	for k, v := range extensions {
+		unmarshaled[k] = v
+	}
+	return json.Marshal(unmarshaled)
+}
+
+// MarshalJSON returns the JSON encoding of i.
+func (i Info) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_Info(i), i.Extensions)
+}
+
+// MarshalJSON returns the JSON encoding of p.
+func (p PathItem) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_PathItem(p), p.Extensions)
+}
+
+// MarshalJSON returns the JSON encoding of o.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_Operation(o), o.Extensions)
+}
+
+// MarshalJSON returns the JSON encoding of p.
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_Parameter(p), p.Extensions)
+}
+
+// MarshalJSON returns the JSON encoding of r.
+func (r Response) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_Response(r), r.Extensions)
+}
+
+// MarshalJSON returns the JSON encoding of s.
+func (s SecurityScheme) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_SecurityScheme(s), s.Extensions)
+}
+
+// MarshalJSON returns the JSON encoding of t.
+func (t Tag) MarshalJSON() ([]byte, error) {
+	return marshalJSON(_Tag(t), t.Extensions)
+}
+
+// New creates an OpenAPI 3.0 document from an API definition.
+func New(api *design.APIDefinition) (*OpenAPI, error) {
+	if api == nil {
+		return nil, nil
+	}
+	basePath := api.BasePath
+	if hasAbsoluteRoutes(api) || len(design.ExtractWildcards(basePath)) > 0 {
+		// Paths that do not share the base path or that have base path parameters are
+		// listed in full.
+		basePath = ""
+	}
+	o := &OpenAPI{
+		OpenAPI: "3.0.0",
+		Info: &Info{
+			Title:          api.Title,
+			Description:    api.Description,
+			TermsOfService: api.TermsOfService,
+			Contact:        api.Contact,
+			License:        api.License,
+			Version:        api.Version,
+			Extensions:     extensionsFromDefinition(api.Metadata),
+		},
+		Servers:      serversFromDefinition(api, basePath),
+		Paths:        make(map[string]*PathItem),
+		Components:   &Components{SecuritySchemes: securitySchemesFromDefinition(api.SecuritySchemes)},
+		Tags:         tagsFromDefinition(api.Metadata),
+		ExternalDocs: docsFromDefinition(api.Docs),
+	}
+
+	err := api.IterateResponses(func(r *design.ResponseDefinition) error {
+		res, err := responseFromDefinition(api, r)
+		if err != nil {
+			return err
+		}
+		if o.Components.Responses == nil {
+			o.Components.Responses = make(map[string]*Response)
+		}
+		o.Components.Responses[r.Name] = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		err := res.IterateFileServers(func(fs *design.FileServerDefinition) error {
+			if !mustGenerate(fs.Metadata) {
+				return nil
+			}
+			return buildPathFromFileServer(o, api, fs)
+		})
+		if err != nil {
+			return err
+		}
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			if !mustGenerate(a.Metadata) {
+				return nil
+			}
+			for _, route := range a.Routes {
+				if err := buildPathFromDefinition(o, api, route, basePath); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(genschema.Definitions) > 0 {
+		o.Components.Schemas = make(map[string]*Schema, len(genschema.Definitions))
+		for n, d := range genschema.Definitions {
+			o.Components.Schemas[n] = schemaFromJSON(d)
+		}
+	}
+	return o, nil
+}
+
+// mustGenerate returns true if the metadata indicates that a specification should be generated,
+// false otherwise. It honors the "swagger:generate" metadata key.
+func mustGenerate(meta dslengine.MetadataDefinition) bool {
+	if m, ok := meta["swagger:generate"]; ok {
+		if len(m) > 0 && m[0] == "false" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAbsoluteRoutes returns true if any action exposed by the API uses an absolute route or if
+// the API has file servers.
+func hasAbsoluteRoutes(api *design.APIDefinition) bool {
+	for _, res := range api.Resources {
+		for _, fs := range res.FileServers {
+			if mustGenerate(fs.Metadata) {
+				return true
+			}
+		}
+		for _, a := range res.Actions {
+			if !mustGenerate(a.Metadata) {
+				continue
+			}
+			for _, ro := range a.Routes {
+				if ro.IsAbsolute() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// serversFromDefinition builds the list of servers from the API host, schemes and base path.
+func serversFromDefinition(api *design.APIDefinition, basePath string) []*Server {
+	if basePath == "/" {
+		basePath = ""
+	}
+	if api.Host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []*Server{{URL: basePath}}
+	}
+	schemes := api.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	servers := make([]*Server, len(schemes))
+	for i, scheme := range schemes {
+		servers[i] = &Server{URL: fmt.Sprintf("%s://%s%s", scheme, api.Host, basePath)}
+	}
+	return servers
+}
+
+func securitySchemesFromDefinition(schemes []*design.SecuritySchemeDefinition) map[string]*SecurityScheme {
+	if len(schemes) == 0 {
+		return nil
+	}
+	defs := make(map[string]*SecurityScheme)
+	for _, scheme := range schemes {
+		def := &SecurityScheme{
+			Description: scheme.Description,
+			Extensions:  extensionsFromDefinition(scheme.Metadata),
+		}
+		switch scheme.Kind {
+		case design.BasicAuthSecurityKind:
+			def.Type = "http"
+			def.Scheme = "basic"
+		case design.JWTSecurityKind:
+			def.Type = "http"
+			def.Scheme = "bearer"
+			def.BearerFormat = "JWT"
+			if scheme.TokenURL != "" {
+				def.Description += fmt.Sprintf("\n\n**Token URL**: %s", scheme.TokenURL)
+			}
+			if len(scheme.Scopes) != 0 {
+				def.Description += fmt.Sprintf("\n\n**Security Scopes**:\n%s", scopesMapList(scheme.Scopes))
+			}
+			def.Description = strings.TrimPrefix(def.Description, "\n\n")
+		case design.APIKeySecurityKind:
+			def.Type = "apiKey"
+			def.Name = scheme.Name
+			def.In = scheme.In
+		case design.OAuth2SecurityKind:
+			def.Type = "oauth2"
+			scopes := scheme.Scopes
+			if scopes == nil {
+				scopes = make(map[string]string)
+			}
+			flow := &OAuthFlow{
+				AuthorizationURL: scheme.AuthorizationURL,
+				TokenURL:         scheme.TokenURL,
+				Scopes:           scopes,
+			}
+			def.Flows = &OAuthFlows{}
+			switch scheme.Flow {
+			case "implicit":
+				flow.TokenURL = ""
+				def.Flows.Implicit = flow
+			case "password":
+				flow.AuthorizationURL = ""
+				def.Flows.Password = flow
+			case "application":
+				flow.AuthorizationURL = ""
+				def.Flows.ClientCredentials = flow
+			default:
+				def.Flows.AuthorizationCode = flow
+			}
+		default:
+			continue
+		}
+		defs[scheme.SchemeName] = def
+	}
+	if len(defs) == 0 {
+		return nil
+	}
+	return defs
+}
+
+func scopesMapList(scopes map[string]string) string {
+	names := []string{}
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  * `%s`: %s", name, scopes[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tagsFromDefinition(mdata dslengine.MetadataDefinition) (tags []*Tag) {
+	var keys []string
+	for k := range mdata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		chunks := strings.Split(key, ":")
+		if len(chunks) != 3 {
+			continue
+		}
+		if chunks[0] != "swagger" || chunks[1] != "tag" {
+			continue
+		}
+
+		tag := &Tag{Name: chunks[2]}
+		if desc := mdata[key+":desc"]; len(desc) != 0 {
+			tag.Description = desc[0]
+		}
+		docs := &ExternalDocs{}
+		if url := mdata[key+":url"]; len(url) != 0 {
+			docs.URL = url[0]
+			tag.ExternalDocs = docs
+		}
+		if desc := mdata[key+":url:desc"]; len(desc) != 0 {
+			docs.Description = desc[0]
+			tag.ExternalDocs = docs
+		}
+		tags = append(tags, tag)
+	}
+	return
+}
+
+func tagNamesFromDefinitions(mdatas ...dslengine.MetadataDefinition) (tagNames []string) {
+	for _, mdata := range mdatas {
+		for _, tag := range tagsFromDefinition(mdata) {
+			tagNames = append(tagNames, tag.Name)
+		}
+	}
+	return
+}
+
+func summaryFromDefinition(name string, metadata dslengine.MetadataDefinition) string {
+	if mdata, ok := metadata["swagger:summary"]; ok && len(mdata) > 0 {
+		return mdata[0]
+	}
+	return name
+}
+
+func extensionsFromDefinition(mdata dslengine.MetadataDefinition) map[string]interface{} {
+	extensions := make(map[string]interface{})
+	for key, value := range mdata {
+		chunks := strings.Split(key, ":")
+		if len(chunks) != 3 {
+			continue
+		}
+		if chunks[0] != "swagger" || chunks[1] != "extension" {
+			continue
+		}
+		if !strings.HasPrefix(chunks[2], "x-") || len(value) == 0 {
+			continue
+		}
+		var ival interface{}
+		if err := json.Unmarshal([]byte(value[0]), &ival); err != nil {
+			extensions[chunks[2]] = value[0]
+			continue
+		}
+		extensions[chunks[2]] = ival
+	}
+	if len(extensions) == 0 {
+		return nil
+	}
+	return extensions
+}
+
+func docsFromDefinition(docs *design.DocsDefinition) *ExternalDocs {
+	if docs == nil {
+		return nil
+	}
+	return &ExternalDocs{
+		Description: docs.Description,
+		URL:         docs.URL,
+	}
+}
+
+func paramsFromDefinition(api *design.APIDefinition, params *design.AttributeDefinition, path string) ([]*Parameter, error) {
+	if params == nil {
+		return nil, nil
+	}
+	obj := params.Type.ToObject()
+	if obj == nil {
+		return nil, fmt.Errorf("invalid parameters definition, not an object")
+	}
+	var res []*Parameter
+	wildcards := design.ExtractWildcards(path)
+	obj.IterateAttributes(func(n string, at *design.AttributeDefinition) error {
+		in := "query"
+		required := params.IsRequired(n)
+		for _, w := range wildcards {
+			if n == w {
+				in = "path"
+				required = true
+				break
+			}
+		}
+		res = append(res, paramFor(api, at, n, in, required))
+		return nil
+	})
+	return res, nil
+}
+
+func paramsFromHeaders(api *design.APIDefinition, action *design.ActionDefinition) []*Parameter {
+	var params []*Parameter
+	action.IterateHeaders(func(name string, required bool, header *design.AttributeDefinition) error {
+		params = append(params, paramFor(api, header, name, "header", required))
+		return nil
+	})
+	return params
+}
+
+func paramFor(api *design.APIDefinition, at *design.AttributeDefinition, name, in string, required bool) *Parameter {
+	return &Parameter{
+		Name:        name,
+		In:          in,
+		Description: at.Description,
+		Required:    required,
+		Schema:      attributeSchema(api, at),
+		Extensions:  extensionsFromDefinition(at.Metadata),
+	}
+}
+
+func headersFromDefinition(api *design.APIDefinition, headers *design.AttributeDefinition) (map[string]*Header, error) {
+	if headers == nil {
+		return nil, nil
+	}
+	obj := headers.Type.ToObject()
+	if obj == nil {
+		return nil, fmt.Errorf("invalid headers definition, not an object")
+	}
+	res := make(map[string]*Header)
+	obj.IterateAttributes(func(n string, at *design.AttributeDefinition) error {
+		res[n] = &Header{
+			Description: at.Description,
+			Required:    headers.IsRequired(n),
+			Schema:      attributeSchema(api, at),
+		}
+		return nil
+	})
+	return res, nil
+}
+
+// requestBodyFromDefinition builds the request body of the given action. The body lists the
+// payload schema for each MIME type the API consumes.
+func requestBodyFromDefinition(api *design.APIDefinition, action *design.ActionDefinition) (*RequestBody, error) {
+	if action.PayloadStream == design.RawStream {
+		return &RequestBody{
+			Content:  map[string]*MediaType{"application/octet-stream": {Schema: binarySchema()}},
+			Required: true,
+		}, nil
+	}
+	payload := action.Payload
+	if payload == nil {
+		return nil, nil
+	}
+	body := &RequestBody{
+		Description: payload.Description,
+		Content:     make(map[string]*MediaType),
+		Required:    !action.PayloadOptional,
+	}
+	if action.PayloadMultipart {
+		obj := payload.Type.ToObject()
+		if obj == nil {
+			return nil, fmt.Errorf("invalid payload definition, not an object")
+		}
+		schema := &Schema{Type: genschema.JSONObject, Properties: make(map[string]*Schema)}
+		obj.IterateAttributes(func(n string, at *design.AttributeDefinition) error {
+			schema.Properties[n] = attributeSchema(api, at)
+			if payload.IsRequired(n) {
+				schema.Required = append(schema.Required, n)
+			}
+			return nil
+		})
+		body.Content["multipart/form-data"] = &MediaType{Schema: schema}
+		return body, nil
+	}
+	schema := schemaFromJSON(genschema.TypeSchema(api, payload))
+	if action.PayloadStream == design.NDJSONStream {
+		body.Content[ndjsonContentType] = &MediaType{Schema: schema}
+		return body, nil
+	}
+	for _, c := range api.Consumes {
+		for _, m := range c.MIMETypes {
+			body.Content[m] = &MediaType{Schema: schema}
+		}
+	}
+	if len(body.Content) == 0 {
+		body.Content["application/json"] = &MediaType{Schema: schema}
+	}
+	return body, nil
+}
+
+// responseFromDefinition builds the response that describes the given response definition.
+// Responses whose media type may be rendered using different views list the schemas of all the
+// views using oneOf. The content lists the body schema for the response media type and for each
+// MIME type the API produces since the service encodes the body according to the request Accept
+// header.
+func responseFromDefinition(api *design.APIDefinition, r *design.ResponseDefinition) (*Response, error) {
+	headers, err := headersFromDefinition(api, r.Headers)
+	if err != nil {
+		return nil, err
+	}
+	desc := r.Description
+	if desc == "" {
+		desc = http.StatusText(r.Status)
+	}
+	res := &Response{
+		Description: desc,
+		Headers:     headers,
+		Extensions:  extensionsFromDefinition(r.Metadata),
+	}
+	if ct, schema := responseBody(api, r); ct != "" {
+		switch r.Stream {
+		case design.NDJSONStream:
+			ct = ndjsonContentType
+		case design.SSEStream:
+			ct = eventStreamContentType
+		case design.RawStream:
+			schema = binarySchema()
+		}
+		res.Content = map[string]*MediaType{ct: {Schema: schema}}
+		if r.Stream == 0 && schema != nil {
+			for _, p := range api.Produces {
+				for _, m := range p.MIMETypes {
+					if _, ok := res.Content[m]; !ok {
+						res.Content[m] = &MediaType{Schema: schema}
+					}
+				}
+			}
+		}
+	} else if r.Stream == design.RawStream {
+		res.Content = map[string]*MediaType{"application/octet-stream": {Schema: binarySchema()}}
+	}
+	return res, nil
+}
+
+// binarySchema returns the schema used to describe raw streams and files.
+func binarySchema() *Schema {
+	return &Schema{Type: genschema.JSONString, Format: "binary"}
+}
+
+// responseBody returns the content type and schema of the given response body if any.
+func responseBody(api *design.APIDefinition, r *design.ResponseDefinition) (string, *Schema) {
+	var mt *design.MediaTypeDefinition
+	if r.Type != nil {
+		var ok bool
+		if mt, ok = r.Type.(*design.MediaTypeDefinition); !ok {
+			ct := r.MediaType
+			if ct == "" {
+				ct = "application/json"
+			}
+			return contentType(ct), schemaFromJSON(genschema.TypeSchema(api, r.Type))
+		}
+	} else if r.MediaType != "" {
+		mt = api.MediaTypeWithIdentifier(r.MediaType)
+		if mt == nil {
+			return contentType(r.MediaType), nil
+		}
+	}
+	if mt == nil {
+		return "", nil
+	}
+	ct := mt.ContentType
+	if ct == "" {
+		ct = mt.Identifier
+	}
+	views := []string{r.ViewName}
+	if r.ViewName == "" {
+		views = make([]string, 0, len(mt.Views))
+		for name := range mt.Views {
+			views = append(views, name)
+		}
+		sort.Strings(views)
+	}
+	if len(views) == 0 {
+		views = []string{design.DefaultView}
+	}
+	schemas := make([]*Schema, len(views))
+	for i, view := range views {
+		schemas[i] = &Schema{Ref: schemaRef(genschema.MediaTypeRef(api, mt, view))}
+	}
+	if len(schemas) == 1 {
+		return contentType(ct), schemas[0]
+	}
+	return contentType(ct), &Schema{OneOf: schemas}
+}
+
+// contentType strips the parameters from the given media type identifier.
+func contentType(identifier string) string {
+	if mt, _, err := mime.ParseMediaType(identifier); err == nil {
+		return mt
+	}
+	return identifier
+}
+
+func buildPathFromFileServer(o *OpenAPI, api *design.APIDefinition, fs *design.FileServerDefinition) error {
+	wcs := design.ExtractWildcards(fs.RequestPath)
+	var params []*Parameter
+	if len(wcs) > 0 {
+		params = []*Parameter{{
+			In:          "path",
+			Name:        wcs[0],
+			Description: "Relative file path",
+			Required:    true,
+			Schema:      &Schema{Type: genschema.JSONString},
+		}}
+	}
+
+	responses := map[string]*Response{
+		"200": {
+			Description: "File downloaded",
+			Content: map[string]*MediaType{
+				"*/*": {Schema: binarySchema()},
+			},
+		},
+	}
+	if len(wcs) > 0 {
+		schema := schemaFromJSON(genschema.TypeSchema(api, api.ErrorMedia()))
+		responses["404"] = &Response{
+			Description: "File not found",
+			Content:     map[string]*MediaType{contentType(api.ErrorMedia().Identifier): {Schema: schema}},
+		}
+	}
+
+	operation := &Operation{
+		Description:  fs.Description,
+		Summary:      summaryFromDefinition(fmt.Sprintf("Download %s", fs.FilePath), fs.Metadata),
+		ExternalDocs: docsFromDefinition(fs.Docs),
+		OperationID:  fmt.Sprintf("%s#%s", fs.Parent.Name, fs.RequestPath),
+		Parameters:   params,
+		Responses:    responses,
+	}
+	applySecurity(operation, fs.Security)
+
+	p := pathItem(o, pathKey(fs.RequestPath, ""))
+	p.Get = operation
+	p.Extensions = extensionsFromDefinition(fs.Metadata)
+
+	return nil
+}
+
+func buildPathFromDefinition(o *OpenAPI, api *design.APIDefinition, route *design.RouteDefinition, basePath string) error {
+	action := route.Parent
+
+	tagNames := tagNamesFromDefinitions(action.Parent.Metadata, action.Metadata)
+	if len(tagNames) == 0 {
+		// By default tag with resource name
+		tagNames = []string{action.Parent.Name}
+	}
+	params, err := paramsFromDefinition(api, action.AllParams(), route.FullPath())
+	if err != nil {
+		return err
+	}
+	params = append(params, paramsFromHeaders(api, action)...)
+
+	body, err := requestBodyFromDefinition(api, action)
+	if err != nil {
+		return err
+	}
+
+	responses := make(map[string]*Response, len(action.Responses))
+	for _, r := range action.Responses {
+		resp, err := responseFromDefinition(api, r)
+		if err != nil {
+			return err
+		}
+		responses[strconv.Itoa(r.Status)] = resp
+	}
+
+	operationID := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
+	for i, rt := range action.Routes {
+		if rt == route && i > 0 {
+			operationID = fmt.Sprintf("%s#%d", operationID, i)
+			break
+		}
+	}
+
+	operation := &Operation{
+		Tags:         tagNames,
+		Description:  action.Description,
+		Summary:      summaryFromDefinition(action.Name+" "+action.Parent.Name, action.Metadata),
+		ExternalDocs: docsFromDefinition(action.Docs),
+		OperationID:  operationID,
+		Parameters:   params,
+		RequestBody:  body,
+		Responses:    responses,
+		Extensions:   extensionsFromDefinition(route.Metadata),
+	}
+	applySecurity(operation, action.Security)
+
+	p := pathItem(o, pathKey(route.FullPath(), basePath))
+	switch route.Verb {
+	case "GET":
+		p.Get = operation
+	case "PUT":
+		p.Put = operation
+	case "POST":
+		p.Post = operation
+	case "DELETE":
+		p.Delete = operation
+	case "OPTIONS":
+		p.Options = operation
+	case "HEAD":
+		p.Head = operation
+	case "PATCH":
+		p.Patch = operation
+	case "TRACE":
+		p.Trace = operation
+	}
+	p.Extensions = extensionsFromDefinition(action.Metadata)
+	return nil
+}
+
+// pathKey converts the wildcards of the given path to OpenAPI path templates and strips the
+// base path.
+func pathKey(path, basePath string) string {
+	key := design.WildcardRegex.ReplaceAllStringFunc(path, func(w string) string {
+		return fmt.Sprintf("/{%s}", w[2:])
+	})
+	if basePath != "/" {
+		key = strings.TrimPrefix(key, basePath)
+	}
+	if key == "" {
+		key = "/"
+	}
+	return key
+}
+
+// pathItem returns the path item with the given key, creating it if needed.
+func pathItem(o *OpenAPI, key string) *PathItem {
+	p, ok := o.Paths[key]
+	if !ok {
+		p = new(PathItem)
+		o.Paths[key] = p
+	}
+	return p
+}
+
+func applySecurity(operation *Operation, security *design.SecurityDefinition) {
+	if security == nil || security.Scheme.Kind == design.NoSecurityKind {
+		return
+	}
+	scopes := security.Scopes
+	if scopes == nil {
+		scopes = make([]string, 0)
+	}
+	if security.Scheme.Kind == design.JWTSecurityKind && len(scopes) > 0 {
+		if operation.Description != "" {
+			operation.Description += "\n\n"
+		}
+		operation.Description += fmt.Sprintf("Required security scopes:\n%s", scopesList(scopes))
+	}
+	if security.Scheme.Kind != design.OAuth2SecurityKind {
+		// Only OAuth2 requirements may list scopes.
+		scopes = make([]string, 0)
+	}
+	operation.Security = []map[string][]string{{security.Scheme.SchemeName: scopes}}
+}
+
+func scopesList(scopes []string) string {
+	sorted := make([]string, len(scopes))
+	copy(sorted, scopes)
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, scope := range sorted {
+		lines = append(lines, fmt.Sprintf("  * `%s`", scope))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// attributeSchema produces the schema of the given attribute.
+func attributeSchema(api *design.APIDefinition, at *design.AttributeDefinition) *Schema {
+	s := schemaFromJSON(genschema.TypeSchema(api, at.Type))
+	if s.Ref != "" {
+		return s
+	}
+	s.Default = toStringMap(at.DefaultValue)
+	val := at.Validation
+	if val == nil {
+		return s
+	}
+	s.Enum = val.Values
+	if val.Format != "" {
+		s.Format = val.Format
+	}
+	s.Pattern = val.Pattern
+	s.Minimum, s.Maximum = at.NumericRange()
+	if at.Type.IsArray() {
+		s.MinItems = val.MinLength
+		s.MaxItems = val.MaxLength
+	} else {
+		s.MinLength = val.MinLength
+		s.MaxLength = val.MaxLength
+	}
+	return s
+}
+
+// schemaFromJSON converts the given JSON schema into an OpenAPI schema. OpenAPI does not support
+// the hyper-schema fields and the Swagger "file" type, files are described as binary strings.
+func schemaFromJSON(js *genschema.JSONSchema) *Schema {
+	if js == nil {
+		return nil
+	}
+	s := &Schema{
+		Ref:                  schemaRef(js.Ref),
+		Title:                js.Title,
+		Type:                 string(js.Type),
+		Format:               js.Format,
+		Description:          js.Description,
+		Items:                schemaFromJSON(js.Items),
+		AdditionalProperties: js.AdditionalProperties,
+		Required:             js.Required,
+		Default:              js.DefaultValue,
+		Example:              js.Example,
+		ReadOnly:             js.ReadOnly,
+		Enum:                 js.Enum,
+		Pattern:              js.Pattern,
+		Minimum:              js.Minimum,
+		Maximum:              js.Maximum,
+		MinLength:            js.MinLength,
+		MaxLength:            js.MaxLength,
+		MinItems:             js.MinItems,
+		MaxItems:             js.MaxItems,
+	}
+	if s.Type == genschema.JSONFile {
+		s.Type, s.Format = genschema.JSONString, "binary"
+	}
+	if len(js.Properties) > 0 {
+		s.Properties = make(map[string]*Schema, len(js.Properties))
+		for n, p := range js.Properties {
+			s.Properties[n] = schemaFromJSON(p)
+		}
+	}
+	for _, a := range js.AnyOf {
+		s.AnyOf = append(s.AnyOf, schemaFromJSON(a))
+	}
+	return s
+}
+
+// schemaRef rewrites JSON schema definition references into OpenAPI component references.
+func schemaRef(ref string) string {
+	return strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+}
+
+// toStringMap converts map[interface{}]interface{} to a map[string]interface{} when possible.
+func toStringMap(val interface{}) interface{} {
+	switch actual := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[fmt.Sprintf("%v", k)] = toStringMap(v)
+		}
+		return m
+	case []interface{}:
+		mapSlice := make([]interface{}, len(actual))
+		for i, e := range actual {
+			mapSlice[i] = toStringMap(e)
+		}
+		return mapSlice
+	default:
+		return actual
+	}
+}
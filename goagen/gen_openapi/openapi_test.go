@@ -0,0 +1,265 @@
+package genopenapi_test
+
+import (
+	"encoding/json"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	genopenapi "github.com/goadesign/goa/goagen/gen_openapi"
+	genschema "github.com/goadesign/goa/goagen/gen_schema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("New", func() {
+	var spec *genopenapi.OpenAPI
+	var newErr error
+
+	BeforeEach(func() {
+		spec = nil
+		newErr = nil
+		dslengine.Reset()
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+	})
+
+	JustBeforeEach(func() {
+		err := dslengine.Run()
+		Ω(err).ShouldNot(HaveOccurred())
+		spec, newErr = genopenapi.New(Design)
+	})
+
+	Context("with a basic API", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				Title("title")
+				Version("1.0")
+				Host("goa.design")
+				Scheme("http", "https")
+				BasePath("/api")
+			})
+		})
+
+		It("sets the version, info and servers", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(spec.OpenAPI).Should(Equal("3.0.0"))
+			Ω(spec.Info.Title).Should(Equal("title"))
+			Ω(spec.Info.Version).Should(Equal("1.0"))
+			Ω(spec.Servers).Should(HaveLen(2))
+			Ω(spec.Servers[0].URL).Should(Equal("http://goa.design/api"))
+			Ω(spec.Servers[1].URL).Should(Equal("https://goa.design/api"))
+		})
+
+		It("serializes into valid JSON", func() {
+			b, err := json.Marshal(spec)
+			Ω(err).ShouldNot(HaveOccurred())
+			var raw map[string]interface{}
+			Ω(json.Unmarshal(b, &raw)).ShouldNot(HaveOccurred())
+			Ω(raw).Should(HaveKeyWithValue("openapi", "3.0.0"))
+			Ω(raw).Should(HaveKey("paths"))
+		})
+	})
+
+	Context("with actions", func() {
+		BeforeEach(func() {
+			bottle := MediaType("application/vnd.goa.bottle", func() {
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("name", String)
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("name")
+				})
+				View("tiny", func() {
+					Attribute("id")
+				})
+			})
+			payload := Type("BottlePayload", func() {
+				Attribute("name", String)
+				Required("name")
+			})
+			API("test", func() {
+				Host("goa.design")
+				BasePath("/api")
+				Consumes("application/json")
+				Consumes("application/xml")
+				Produces("application/json")
+				JWTSecurity("jwt", func() {
+					Header("Authorization")
+					TokenURL("https://goa.design/token")
+				})
+			})
+			Resource("bottle", func() {
+				BasePath("/bottles")
+				Security("jwt")
+				Action("show", func() {
+					Routing(GET("/:id"))
+					Params(func() {
+						Param("id", Integer)
+						Param("fields", ArrayOf(String))
+					})
+					Response(OK, bottle)
+					Response(NotFound)
+				})
+				Action("create", func() {
+					Routing(POST(""))
+					Payload(payload)
+					Response(Created, func() {
+						Media(bottle, "tiny")
+					})
+				})
+				Action("upload", func() {
+					Routing(POST("/upload"))
+					MultipartForm()
+					Payload(func() {
+						Attribute("file", File)
+						Required("file")
+					})
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("produces the paths relative to the servers", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(spec.Servers).Should(HaveLen(1))
+			Ω(spec.Servers[0].URL).Should(Equal("http://goa.design/api"))
+			Ω(spec.Paths).Should(HaveKey("/bottles/{id}"))
+			Ω(spec.Paths).Should(HaveKey("/bottles"))
+			Ω(spec.Paths).Should(HaveKey("/bottles/upload"))
+		})
+
+		It("describes the parameters using schemas", func() {
+			op := spec.Paths["/bottles/{id}"].Get
+			Ω(op).ShouldNot(BeNil())
+			Ω(op.OperationID).Should(Equal("bottle#show"))
+			Ω(op.Parameters).Should(HaveLen(2))
+			params := make(map[string]*genopenapi.Parameter)
+			for _, p := range op.Parameters {
+				params[p.Name] = p
+			}
+			Ω(params["id"].In).Should(Equal("path"))
+			Ω(params["id"].Required).Should(BeTrue())
+			Ω(params["id"].Schema.Type).Should(Equal("integer"))
+			Ω(params["fields"].In).Should(Equal("query"))
+			Ω(params["fields"].Schema.Type).Should(Equal("array"))
+			Ω(params["fields"].Schema.Items.Type).Should(Equal("string"))
+		})
+
+		It("uses oneOf for the views of the response media type", func() {
+			resp := spec.Paths["/bottles/{id}"].Get.Responses["200"]
+			Ω(resp).ShouldNot(BeNil())
+			Ω(resp.Description).Should(Equal("OK"))
+			Ω(resp.Content).Should(HaveKey("application/vnd.goa.bottle"))
+			schema := resp.Content["application/vnd.goa.bottle"].Schema
+			Ω(schema.OneOf).Should(HaveLen(2))
+			Ω(schema.OneOf[0].Ref).Should(Equal("#/components/schemas/GoaBottle"))
+			Ω(schema.OneOf[1].Ref).Should(Equal("#/components/schemas/GoaBottleTiny"))
+			Ω(spec.Components.Schemas).Should(HaveKey("GoaBottle"))
+			Ω(spec.Components.Schemas).Should(HaveKey("GoaBottleTiny"))
+			Ω(spec.Paths["/bottles/{id}"].Get.Responses["404"].Description).Should(Equal("Not Found"))
+		})
+
+		It("uses the response view if any", func() {
+			resp := spec.Paths["/bottles"].Post.Responses["201"]
+			Ω(resp).ShouldNot(BeNil())
+			schema := resp.Content["application/vnd.goa.bottle"].Schema
+			Ω(schema.OneOf).Should(BeEmpty())
+			Ω(schema.Ref).Should(Equal("#/components/schemas/GoaBottleTiny"))
+		})
+
+		It("lists the response body for each produced content type", func() {
+			resp := spec.Paths["/bottles"].Post.Responses["201"]
+			Ω(resp.Content).Should(HaveLen(2))
+			Ω(resp.Content["application/json"].Schema.Ref).Should(Equal("#/components/schemas/GoaBottleTiny"))
+			Ω(resp.Content["application/vnd.goa.bottle"].Schema.Ref).Should(Equal("#/components/schemas/GoaBottleTiny"))
+			Ω(spec.Paths["/bottles/upload"].Post.Responses["204"].Content).Should(BeEmpty())
+		})
+
+		It("lists the request body for each consumed content type", func() {
+			body := spec.Paths["/bottles"].Post.RequestBody
+			Ω(body).ShouldNot(BeNil())
+			Ω(body.Required).Should(BeTrue())
+			Ω(body.Content).Should(HaveLen(2))
+			Ω(body.Content["application/json"].Schema.Ref).Should(Equal("#/components/schemas/BottlePayload"))
+			Ω(body.Content["application/xml"].Schema.Ref).Should(Equal("#/components/schemas/BottlePayload"))
+		})
+
+		It("describes multipart payloads", func() {
+			body := spec.Paths["/bottles/upload"].Post.RequestBody
+			Ω(body).ShouldNot(BeNil())
+			Ω(body.Content).Should(HaveKey("multipart/form-data"))
+			schema := body.Content["multipart/form-data"].Schema
+			Ω(schema.Properties["file"].Type).Should(Equal("string"))
+			Ω(schema.Properties["file"].Format).Should(Equal("binary"))
+			Ω(schema.Required).Should(ConsistOf("file"))
+		})
+
+		It("produces a bearer security scheme for JWT", func() {
+			Ω(spec.Components.SecuritySchemes).Should(HaveKey("jwt"))
+			scheme := spec.Components.SecuritySchemes["jwt"]
+			Ω(scheme.Type).Should(Equal("http"))
+			Ω(scheme.Scheme).Should(Equal("bearer"))
+			Ω(scheme.BearerFormat).Should(Equal("JWT"))
+			Ω(scheme.Description).Should(ContainSubstring("https://goa.design/token"))
+			Ω(spec.Paths["/bottles"].Post.Security).Should(Equal([]map[string][]string{{"jwt": {}}}))
+		})
+	})
+
+	Context("with problem details", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				Produces("application/json")
+				ProblemDetails()
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+					Response(NoContent)
+					Response(BadRequest, ErrorMedia)
+				})
+			})
+		})
+
+		It("describes error responses with the problem media type", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			resp := spec.Paths["/bottles/{id}"].Get.Responses["400"]
+			Ω(resp).ShouldNot(BeNil())
+			Ω(resp.Content).Should(HaveLen(2))
+			Ω(resp.Content).Should(HaveKey("application/problem+json"))
+			Ω(resp.Content).ShouldNot(HaveKey("application/vnd.goa.error"))
+			Ω(resp.Content["application/problem+json"].Schema.Ref).Should(Equal("#/components/schemas/problem"))
+			Ω(spec.Components.Schemas).Should(HaveKey("problem"))
+			Ω(spec.Components.Schemas).ShouldNot(HaveKey("error"))
+		})
+	})
+
+	Context("with an OAuth2 security scheme", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				OAuth2Security("oauth2", func() {
+					AccessCodeFlow("/authorization", "/token")
+					Scope("api:read", "Read access")
+				})
+				APIKeySecurity("key", func() {
+					Query("key")
+				})
+			})
+		})
+
+		It("produces the flows", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			schemes := spec.Components.SecuritySchemes
+			Ω(schemes["oauth2"].Type).Should(Equal("oauth2"))
+			flow := schemes["oauth2"].Flows.AuthorizationCode
+			Ω(flow).ShouldNot(BeNil())
+			Ω(flow.AuthorizationURL).Should(Equal("/authorization"))
+			Ω(flow.TokenURL).Should(Equal("/token"))
+			Ω(flow.Scopes).Should(HaveKeyWithValue("api:read", "Read access"))
+			Ω(schemes["key"].Type).Should(Equal("apiKey"))
+			Ω(schemes["key"].In).Should(Equal("query"))
+			Ω(schemes["key"].Name).Should(Equal("key"))
+		})
+	})
+})
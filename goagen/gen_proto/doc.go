@@ -0,0 +1,24 @@
+/*
+Package genproto provides a generator for protocol buffers definitions and gRPC services.
+The generator produces a .proto file that defines a message for each user type and media type view
+of the API design and a gRPC service for each resource. It also produces Go adapters that implement
+the gRPC servers generated by protoc from the .proto file by dispatching the gRPC requests to the
+controllers mounted on the goa service so that both APIs share the same implementation.
+
+Field numbers must remain stable as the design evolves so they are never computed by the generator.
+Instead they are read from the "proto:field" metadata of the attributes, parameters and headers:
+
+	Attribute("name", String, func() {
+		Metadata("proto:field", "2")
+	})
+
+The number of the request field that holds the payload is read from the metadata of the payload
+type. The generator fails if any of these definitions does not define the metadata, the error lists
+the numbers following the greatest number in use as suggestions. The fields of the messages
+generated for the built-in error media types (ErrorMedia and ProblemMedia) use predefined numbers.
+
+Scalar fields are declared optional so that the adapters preserve the presence of attributes when
+converting messages to and from the JSON representation handled by the controllers. Attributes of
+type Any are represented with bytes fields and are not supported by the adapters.
+*/
+package genproto
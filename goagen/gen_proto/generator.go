@@ -0,0 +1,353 @@
+package genproto
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/ir"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/goadesign/goa/version"
+)
+
+// NewGenerator returns an initialized instance of a protobuf Generator.
+func NewGenerator(options ...Option) *Generator {
+	g := &Generator{}
+
+	for _, option := range options {
+		option(g)
+	}
+
+	return g
+}
+
+// Generator is the protobuf definitions and gRPC adapters generator.
+type Generator struct {
+	API      *design.APIDefinition // The API definition
+	OutDir   string                // Path to output directory
+	Target   string                // Name of generated package
+	genfiles []string              // Generated files
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, target, ver string
+
+	set := flag.NewFlagSet("proto", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&target, "pkg", "proto", "")
+	set.StringVar(&ver, "version", "", "")
+	set.String("design", "", "")
+	set.Parse(os.Args[1:])
+	outDir = filepath.Join(outDir, target)
+
+	if err := codegen.CheckVersion(ver); err != nil {
+		return nil, err
+	}
+
+	target = codegen.Goify(target, false)
+	g := &Generator{OutDir: outDir, Target: target, API: design.Design}
+
+	return g.Generate()
+}
+
+// Generate produces the .proto file and the Go gRPC adapters.
+func (g *Generator) Generate() ([]string, error) {
+	return ir.Run(g, g.API)
+}
+
+// GenerateIR produces the .proto file and the Go gRPC adapters from the intermediate
+// representation of the API.
+func (g *Generator) GenerateIR(api *ir.API) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(g.OutDir)
+	if err = os.MkdirAll(g.OutDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = []string{g.OutDir}
+
+	var goPackage string
+	if pkgPath, err := codegen.PackagePath(g.OutDir); err == nil {
+		goPackage = pkgPath + ";" + g.Target
+	}
+	f, err := NewFile(api, goPackage)
+	if err != nil {
+		return nil, err
+	}
+
+	// .proto file
+	protoFile := filepath.Join(g.OutDir, f.Package+".proto")
+	header := fmt.Sprintf("// Code generated by goagen %s, DO NOT EDIT.\n//\n// API %q: protocol buffers definitions\n//\n// Command:\n%s\n\n",
+		version.String(), api.Name, codegen.Comment(codegen.CommandLine()))
+	if err = ioutil.WriteFile(protoFile, []byte(header+f.Proto()), 0644); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, protoFile)
+
+	// Go adapters
+	adapterFile := filepath.Join(g.OutDir, "adapters.go")
+	file, err := codegen.SourceFileFor(adapterFile)
+	if err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, adapterFile)
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("bytes"),
+		codegen.SimpleImport("context"),
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("net/url"),
+		codegen.SimpleImport("reflect"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("google.golang.org/grpc/codes"),
+		codegen.SimpleImport("google.golang.org/grpc/metadata"),
+		codegen.SimpleImport("google.golang.org/grpc/status"),
+	}
+	title := fmt.Sprintf("API %q: gRPC adapters", api.Name)
+	if err = file.WriteHeader(title, g.Target, imports); err != nil {
+		return nil, err
+	}
+	funcs := template.FuncMap{
+		"pathFormat": pathFormat,
+	}
+	if err = file.ExecuteTemplate("adapters", adaptersT, funcs, f); err != nil {
+		return nil, err
+	}
+	if err = file.ExecuteTemplate("adapterHelpers", adapterHelpersT, nil, nil); err != nil {
+		return nil, err
+	}
+	if err = file.FormatCode(); err != nil {
+		return nil, err
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
+
+// pathFormat returns the format string used to build the request path from the values of the
+// path parameters.
+func pathFormat(path string) string {
+	return design.WildcardRegex.ReplaceAllLiteralString(strings.Replace(path, "%", "%%", -1), "/%s")
+}
+
+const adaptersT = `{{ range .Services }}{{ $svc := . }}
+// {{ .Name }}Adapter implements {{ .Name }}Server by dispatching the gRPC requests to the
+// controllers mounted on a goa service.
+type {{ .Name }}Adapter struct {
+	Unimplemented{{ .Name }}Server
+	service *goa.Service
+}
+
+// New{{ .Name }}Adapter returns a {{ .Name }}Server that dispatches the gRPC requests to the
+// controllers mounted on the given service.
+func New{{ .Name }}Adapter(service *goa.Service) *{{ .Name }}Adapter {
+	return &{{ .Name }}Adapter{service: service}
+}
+{{ range .RPCs }}
+// {{ .Name }} dispatches the request to the {{ .Method }} {{ .Path }} route.
+func (a *{{ $svc.Name }}Adapter) {{ .Name }}(ctx context.Context, req *{{ .Request.Name }}) (*{{ .Response.Name }}, error) {
+	r := newAdapterRequest("{{ .Method }}", {{ if .PathParams }}fmt.Sprintf("{{ pathFormat .Path }}"{{ range .PathParams }}, adapterPathValue(req.{{ .GoName }}){{ end }}){{ else }}"{{ .Path }}"{{ end }})
+{{ range .QueryParams }}	r.query("{{ .Key }}", req.{{ .GoName }})
+{{ end }}{{ range .Headers }}	r.header("{{ .Key }}", req.{{ .GoName }})
+{{ end }}{{ with .Payload }}	if req.{{ .GoName }} != nil {
+		r.body = req.{{ .GoName }}
+	}
+{{ end }}	var res {{ .Response.Name }}
+	if err := r.do(ctx, a.service, {{ if .Result }}&res.{{ .Result.GoName }}{{ else }}nil{{ end }}); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+{{ end }}{{ end }}`
+
+const adapterHelpersT = `
+// adapterRequest is the HTTP request dispatched to the goa service for a gRPC request.
+type adapterRequest struct {
+	method, path string
+	values       url.Values
+	headers      http.Header
+	body         interface{}
+}
+
+// adapterResponse records the response written by the goa service.
+type adapterResponse struct {
+	headers http.Header
+	status  int
+	body    bytes.Buffer
+}
+
+func newAdapterRequest(method, path string) *adapterRequest {
+	return &adapterRequest{method: method, path: path, values: url.Values{}, headers: http.Header{}}
+}
+
+// query adds the values held by v to the request query string.
+func (r *adapterRequest) query(name string, v interface{}) {
+	for _, val := range adapterValues(v) {
+		r.values.Add(name, val)
+	}
+}
+
+// header adds the values held by v to the request headers.
+func (r *adapterRequest) header(name string, v interface{}) {
+	for _, val := range adapterValues(v) {
+		r.headers.Add(name, val)
+	}
+}
+
+// do serves the request with the service mux and decodes the response body into result.
+// gRPC metadata is forwarded as request headers.
+func (r *adapterRequest) do(ctx context.Context, service *goa.Service, result interface{}) error {
+	var body io.Reader = http.NoBody
+	if r.body != nil {
+		b, err := json.Marshal(r.body)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		body = bytes.NewReader(b)
+	}
+	target := r.path
+	if q := r.values.Encode(); q != "" {
+		target += "?" + q
+	}
+	req, err := http.NewRequest(r.method, target, body)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	req = req.WithContext(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, vals := range md {
+			if strings.HasPrefix(k, ":") || strings.HasPrefix(k, "grpc-") {
+				continue
+			}
+			for _, v := range vals {
+				req.Header.Add(k, v)
+			}
+		}
+	}
+	for k, vals := range r.headers {
+		req.Header[k] = vals
+	}
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp := &adapterResponse{headers: http.Header{}}
+	service.Mux.ServeHTTP(resp, req)
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+	if resp.status < 200 || resp.status > 299 {
+		return adapterError(resp)
+	}
+	if result == nil || resp.body.Len() == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.body.Bytes(), result); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+func (r *adapterResponse) Header() http.Header { return r.headers }
+
+func (r *adapterResponse) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *adapterResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// adapterError converts an error response into a gRPC status error.
+func adapterError(resp *adapterResponse) error {
+	msg := http.StatusText(resp.status)
+	var e goa.ErrorResponse
+	if err := json.Unmarshal(resp.body.Bytes(), &e); err == nil && e.Detail != "" {
+		msg = e.Detail
+	}
+	code := codes.Unknown
+	switch resp.status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		code = codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	default:
+		if resp.status >= 500 {
+			code = codes.Internal
+		}
+	}
+	return status.Error(code, msg)
+}
+
+// adapterPathValue returns the path segment holding the value of v.
+func adapterPathValue(v interface{}) string {
+	vals := adapterValues(v)
+	if len(vals) == 0 {
+		return ""
+	}
+	return url.PathEscape(vals[0])
+}
+
+// adapterValues returns the string representations of the value held by the field value v.
+func adapterValues(v interface{}) []string {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8 {
+		vals := make([]string, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			vals[i] = fmt.Sprint(val.Index(i).Interface())
+		}
+		return vals
+	}
+	return []string{fmt.Sprint(val.Interface())}
+}
+`
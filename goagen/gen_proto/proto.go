@@ -0,0 +1,634 @@
+package genproto
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/ir"
+)
+
+// FieldMetadataKey is the name of the attribute metadata that holds the protobuf field number
+// generated for the attribute.
+const FieldMetadataKey = "proto:field"
+
+const (
+	// maxFieldNumber is the greatest protobuf field number.
+	maxFieldNumber = 1<<29 - 1
+	// firstReservedNumber and lastReservedNumber delimit the field numbers reserved for the
+	// protobuf implementation.
+	firstReservedNumber, lastReservedNumber = 19000, 19999
+)
+
+type (
+	// File is the protobuf definition of an API.
+	File struct {
+		// Package is the name of the protobuf package.
+		Package string
+		// GoPackage is the value of the go_package option if any.
+		GoPackage string
+		// Services lists the gRPC services generated for the API resources.
+		Services []*Service
+		// Messages lists the top level messages sorted by name.
+		Messages []*Message
+	}
+
+	// Service is the gRPC service generated for a resource.
+	Service struct {
+		// Name is the name of the service.
+		Name string
+		// Description is the resource description.
+		Description string
+		// RPCs lists the methods generated for the resource actions.
+		RPCs []*RPC
+	}
+
+	// RPC is the gRPC method generated for an action.
+	RPC struct {
+		// Name is the name of the method.
+		Name string
+		// Description is the action description.
+		Description string
+		// Request is the method request message.
+		Request *Message
+		// Response is the method response message.
+		Response *Message
+		// Method is the HTTP method of the action route used to dispatch requests.
+		Method string
+		// Path is the path of the action route used to dispatch requests.
+		Path string
+		// PathParams lists the request fields that hold path parameters in the order of
+		// the route wildcards.
+		PathParams []*Field
+		// QueryParams lists the request fields that hold query string parameters.
+		QueryParams []*Field
+		// Headers lists the request fields that hold request headers.
+		Headers []*Field
+		// Payload is the request field that holds the payload if any.
+		Payload *Field
+		// Result is the response field that holds the response body if any.
+		Result *Field
+	}
+
+	// Message is a protobuf message.
+	Message struct {
+		// Name is the name of the message.
+		Name string
+		// Description is the message description.
+		Description string
+		// Fields lists the message fields sorted by number.
+		Fields []*Field
+		// Nested lists the messages generated for the anonymous object attributes.
+		Nested []*Message
+	}
+
+	// Field is a protobuf message field.
+	Field struct {
+		// Name is the name of the field.
+		Name string
+		// GoName is the name of the struct field generated by protoc-gen-go.
+		GoName string
+		// Key is the attribute, parameter or header name the field corresponds to.
+		Key string
+		// Type is the protobuf type of the field.
+		Type string
+		// Number is the field number.
+		Number int
+		// Repeated is true if the field holds a list of values.
+		Repeated bool
+		// Optional is true if the field is a scalar that tracks presence.
+		Optional bool
+		// Description is the attribute description.
+		Description string
+	}
+
+	// builder computes the protobuf definitions of an API.
+	builder struct {
+		file     *File
+		messages map[string]*Message
+		// origins maps media type projections to the media types they are projected from.
+		origins map[*design.MediaTypeDefinition]*design.MediaTypeDefinition
+	}
+)
+
+// builtinFieldNumbers lists the field numbers of the attributes of the built-in error media types
+// (ErrorMedia and ProblemMedia) which cannot define the FieldMetadataKey metadata in the design.
+var builtinFieldNumbers = map[string]int{
+	"id":       1,
+	"status":   2,
+	"code":     3,
+	"detail":   4,
+	"meta":     5,
+	"type":     6,
+	"title":    7,
+	"instance": 8,
+}
+
+// identifierRegex matches valid protobuf identifiers.
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewFile computes the protobuf definitions of the given API. goPackage is the value of the
+// go_package option, it may be empty.
+func NewFile(api *ir.API, goPackage string) (*File, error) {
+	b := &builder{
+		file: &File{
+			Package:   strings.ToLower(codegen.Goify(api.Name, false)),
+			GoPackage: goPackage,
+		},
+		messages: make(map[string]*Message),
+		origins:  make(map[*design.MediaTypeDefinition]*design.MediaTypeDefinition),
+	}
+	var projections []*design.MediaTypeDefinition
+	for _, mt := range api.MediaTypes {
+		for _, v := range mt.Views {
+			b.origins[v.Projected] = mt.Definition
+			projections = append(projections, v.Projected)
+		}
+	}
+	for _, p := range projections {
+		if _, err := b.message(p); err != nil {
+			return nil, err
+		}
+	}
+	for _, ut := range api.UserTypes {
+		if _, err := b.message(ut.Definition); err != nil {
+			return nil, err
+		}
+	}
+	for _, res := range api.Resources {
+		svc := &Service{
+			Name:        res.GoName + "Service",
+			Description: res.Description,
+		}
+		for _, a := range res.Actions {
+			rpc, err := b.rpc(a)
+			if err != nil {
+				return nil, err
+			}
+			svc.RPCs = append(svc.RPCs, rpc)
+		}
+		if len(svc.RPCs) > 0 {
+			b.file.Services = append(b.file.Services, svc)
+		}
+	}
+	sort.Slice(b.file.Messages, func(i, j int) bool {
+		return b.file.Messages[i].Name < b.file.Messages[j].Name
+	})
+	return b.file, nil
+}
+
+// rpc computes the gRPC method generated for the given action.
+func (b *builder) rpc(a *ir.Action) (*RPC, error) {
+	if len(a.Routes) == 0 {
+		return nil, fmt.Errorf("%s: action has no route", a.Definition.Context())
+	}
+	name := a.GoName + a.Resource.GoName
+	route := a.Routes[0]
+	rpc := &RPC{
+		Name:        a.GoName,
+		Description: a.Description,
+		Method:      route.Verb,
+		Path:        route.Path,
+	}
+
+	// Request fields: parameters, headers and payload
+	obj := make(design.Object)
+	kinds := make(map[string]string)
+	for _, p := range a.Params() {
+		obj[p.Name] = p.Attribute
+		kinds[p.Name] = "query"
+	}
+	for _, p := range route.Params {
+		kinds[p] = "path"
+	}
+	for _, h := range a.Headers {
+		n := strings.ToLower(strings.Replace(h.Name, "-", "_", -1))
+		if _, ok := obj[n]; ok {
+			return nil, fmt.Errorf("%s: header %#v conflicts with parameter %#v", a.Definition.Context(), h.Name, n)
+		}
+		obj[n] = h.Attribute
+		kinds[n] = h.Name
+	}
+	if a.Payload != nil {
+		if _, ok := obj["payload"]; ok {
+			return nil, fmt.Errorf("%s: parameter or header %#v conflicts with the payload field", a.Definition.Context(), "payload")
+		}
+		obj["payload"] = &design.AttributeDefinition{
+			Type:        a.Payload.Type,
+			Description: a.Payload.Type.Description,
+			Metadata:    a.Payload.Type.Metadata,
+		}
+		kinds["payload"] = "payload"
+	}
+	rpc.Request = &Message{
+		Name:        name + "Request",
+		Description: fmt.Sprintf("%sRequest is the request of the %s action of the %s resource.", name, a.Name, a.Resource.Name),
+	}
+	if err := b.fields(rpc.Request, obj, obj); err != nil {
+		return nil, fmt.Errorf("%s: %s", a.Definition.Context(), err)
+	}
+	fields := make(map[string]*Field, len(rpc.Request.Fields))
+	for _, f := range rpc.Request.Fields {
+		fields[f.Key] = f
+		switch kind := kinds[f.Key]; kind {
+		case "query":
+			rpc.QueryParams = append(rpc.QueryParams, f)
+		case "path":
+		case "payload":
+			rpc.Payload = f
+		default:
+			f.Key = kind
+			rpc.Headers = append(rpc.Headers, f)
+		}
+	}
+	for _, p := range route.Params {
+		f, ok := fields[p]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown path parameter %#v", a.Definition.Context(), p)
+		}
+		rpc.PathParams = append(rpc.PathParams, f)
+	}
+	if err := b.add(rpc.Request); err != nil {
+		return nil, err
+	}
+
+	// Response field: body of the first success response that defines a media type
+	rpc.Response = &Message{
+		Name:        name + "Response",
+		Description: fmt.Sprintf("%sResponse is the response of the %s action of the %s resource.", name, a.Name, a.Resource.Name),
+	}
+	for _, r := range a.Responses {
+		if !r.IsSuccess() || r.View == nil {
+			continue
+		}
+		f, err := b.field(rpc.Response, "result", &design.AttributeDefinition{Type: r.View.Projected}, 1)
+		if err != nil {
+			return nil, err
+		}
+		rpc.Result = f
+		rpc.Response.Fields = []*Field{f}
+		break
+	}
+	if err := b.add(rpc.Response); err != nil {
+		return nil, err
+	}
+	return rpc, nil
+}
+
+// message returns the name of the message generated for the given user type or media type
+// projection, computing the message if needed. Types that are not objects are wrapped in a
+// message with a single field.
+func (b *builder) message(ut design.DataType) (string, error) {
+	var (
+		typeName string
+		att      *design.AttributeDefinition
+		src      design.Object
+	)
+	switch actual := ut.(type) {
+	case *design.MediaTypeDefinition:
+		typeName, att = actual.TypeName, actual.AttributeDefinition
+		if origin, ok := b.origins[actual]; ok && origin.Type.IsObject() {
+			src = origin.Type.ToObject()
+		}
+	case *design.UserTypeDefinition:
+		typeName, att = actual.TypeName, actual.AttributeDefinition
+	default:
+		return "", fmt.Errorf("%s is not a user type", ut.Name())
+	}
+	name := codegen.Goify(typeName, true)
+	if _, ok := b.messages[name]; ok {
+		return name, nil
+	}
+	msg := &Message{Name: name, Description: att.Description}
+	if err := b.add(msg); err != nil {
+		return "", err
+	}
+	if att.Type.IsObject() {
+		obj := att.Type.ToObject()
+		if src == nil {
+			src = obj
+		}
+		if mt, ok := ut.(*design.MediaTypeDefinition); ok && mt.IsError() {
+			src = builtinFields(src)
+		}
+		if err := b.fields(msg, obj, src); err != nil {
+			return "", fmt.Errorf("type %s: %s", typeName, err)
+		}
+		return name, nil
+	}
+	wrapped := "value"
+	if att.Type.IsArray() {
+		wrapped = "items"
+	} else if att.Type.IsHash() {
+		wrapped = "entries"
+	}
+	f, err := b.field(msg, wrapped, &design.AttributeDefinition{Type: att.Type, Validation: att.Validation}, 1)
+	if err != nil {
+		return "", fmt.Errorf("type %s: %s", typeName, err)
+	}
+	msg.Fields = []*Field{f}
+	return name, nil
+}
+
+// add registers a top level message.
+func (b *builder) add(msg *Message) error {
+	if _, ok := b.messages[msg.Name]; ok {
+		return fmt.Errorf("protobuf message name %#v is used more than once", msg.Name)
+	}
+	b.messages[msg.Name] = msg
+	b.file.Messages = append(b.file.Messages, msg)
+	return nil
+}
+
+// fields initializes the fields of msg with the attributes of obj. The field numbers are read
+// from the attributes of src which must define all the attributes of obj, from the attributes of
+// obj otherwise.
+func (b *builder) fields(msg *Message, obj, src design.Object) error {
+	all := make(design.Object, len(src)+len(obj))
+	for n, att := range src {
+		all[n] = att
+	}
+	for n, att := range obj {
+		if _, ok := all[n]; !ok {
+			all[n] = att
+		}
+	}
+	numbers, err := fieldNumbers(all)
+	if err != nil {
+		return err
+	}
+	for n, att := range obj {
+		f, err := b.field(msg, n, att, numbers[n])
+		if err != nil {
+			return err
+		}
+		msg.Fields = append(msg.Fields, f)
+	}
+	sort.Slice(msg.Fields, func(i, j int) bool { return msg.Fields[i].Number < msg.Fields[j].Number })
+	return nil
+}
+
+// field computes the field generated for the attribute att named name of msg.
+func (b *builder) field(msg *Message, name string, att *design.AttributeDefinition, number int) (*Field, error) {
+	if !identifierRegex.MatchString(name) {
+		return nil, fmt.Errorf("attribute name %#v is not a valid protobuf field name", name)
+	}
+	typ, repeated, err := b.typeRef(msg, name, att)
+	if err != nil {
+		return nil, fmt.Errorf("attribute %#v: %s", name, err)
+	}
+	return &Field{
+		Name:        name,
+		GoName:      goCamelCase(name),
+		Key:         name,
+		Type:        typ,
+		Number:      number,
+		Repeated:    repeated,
+		Optional:    !repeated && att.Type.IsPrimitive(),
+		Description: att.Description,
+	}, nil
+}
+
+// typeRef returns the protobuf type of the field generated for the attribute att named name of
+// msg and whether the field is repeated.
+func (b *builder) typeRef(msg *Message, name string, att *design.AttributeDefinition) (string, bool, error) {
+	switch actual := att.Type.(type) {
+	case design.Primitive:
+		return scalarType(att), false, nil
+	case *design.Array:
+		elem := actual.ElemType
+		if elem.Type.IsArray() || elem.Type.IsHash() {
+			return "", false, fmt.Errorf("arrays of arrays or maps cannot be represented in protobuf")
+		}
+		typ, _, err := b.typeRef(msg, name, elem)
+		return typ, true, err
+	case *design.Hash:
+		key, elem := actual.KeyType, actual.ElemType
+		switch key.Type.Kind() {
+		case design.StringKind, design.IntegerKind, design.BooleanKind, design.UUIDKind, design.DateTimeKind:
+		default:
+			return "", false, fmt.Errorf("map keys must be strings, integers or booleans")
+		}
+		if elem.Type.IsArray() || elem.Type.IsHash() {
+			return "", false, fmt.Errorf("maps of arrays or maps cannot be represented in protobuf")
+		}
+		typ, _, err := b.typeRef(msg, name, elem)
+		if err != nil {
+			return "", false, err
+		}
+		return fmt.Sprintf("map<%s, %s>", scalarType(key), typ), false, nil
+	case design.Object:
+		nested := &Message{Name: codegen.Goify(name, true)}
+		for _, m := range msg.Nested {
+			if m.Name == nested.Name {
+				return "", false, fmt.Errorf("nested message name %#v is used more than once", nested.Name)
+			}
+		}
+		if err := b.fields(nested, actual, actual); err != nil {
+			return "", false, err
+		}
+		msg.Nested = append(msg.Nested, nested)
+		return nested.Name, false, nil
+	case *design.MediaTypeDefinition:
+		p := actual
+		if _, ok := b.origins[actual]; !ok {
+			var err error
+			if p, _, err = actual.Project(design.DefaultView); err != nil {
+				return "", false, err
+			}
+		}
+		if !p.Type.IsObject() {
+			return b.typeRef(msg, name, &design.AttributeDefinition{Type: p.Type})
+		}
+		typ, err := b.message(p)
+		return typ, false, err
+	case *design.UserTypeDefinition:
+		if !actual.Type.IsObject() {
+			return b.typeRef(msg, name, &design.AttributeDefinition{Type: actual.Type, Validation: actual.Validation})
+		}
+		typ, err := b.message(actual)
+		return typ, false, err
+	}
+	return "", false, fmt.Errorf("unsupported type %s", att.Type.Name())
+}
+
+// scalarType returns the protobuf scalar type used to represent values of the given primitive
+// attribute.
+func scalarType(att *design.AttributeDefinition) string {
+	switch att.Type.Kind() {
+	case design.BooleanKind:
+		return "bool"
+	case design.IntegerKind:
+		if f := att.NumericFormat(); f != "" {
+			return f
+		}
+		return "int64"
+	case design.NumberKind:
+		if att.NumericFormat() == design.FormatFloat {
+			return "float"
+		}
+		return "double"
+	case design.AnyKind, design.FileKind:
+		return "bytes"
+	default:
+		return "string"
+	}
+}
+
+// fieldNumbers returns the field numbers of the attributes of obj read from the FieldMetadataKey
+// metadata. It returns an error listing the attributes that do not define the metadata together
+// with suggested numbers: the numbers following the greatest number in use in alphabetical order of
+// the attribute names.
+func fieldNumbers(obj design.Object) (map[string]int, error) {
+	numbers := make(map[string]int, len(obj))
+	names := make(map[int]string, len(obj))
+	var unset []string
+	max := 0
+	for _, n := range sortedNames(obj) {
+		vals := obj[n].Metadata[FieldMetadataKey]
+		if len(vals) == 0 {
+			unset = append(unset, n)
+			continue
+		}
+		num, err := strconv.Atoi(vals[0])
+		if err != nil || num < 1 || num > maxFieldNumber || num >= firstReservedNumber && num <= lastReservedNumber {
+			return nil, fmt.Errorf("attribute %#v: invalid %s metadata %#v", n, FieldMetadataKey, vals[0])
+		}
+		if other, ok := names[num]; ok {
+			return nil, fmt.Errorf("attributes %#v and %#v use the same field number %d", other, n, num)
+		}
+		numbers[n] = num
+		names[num] = n
+		if num > max {
+			max = num
+		}
+	}
+	if len(unset) == 0 {
+		return numbers, nil
+	}
+	suggestions := make([]string, len(unset))
+	for i, n := range unset {
+		max++
+		if max == firstReservedNumber {
+			max = lastReservedNumber + 1
+		}
+		suggestions[i] = fmt.Sprintf("%#v (suggested number: %d)", n, max)
+	}
+	return nil, fmt.Errorf("missing %s metadata for attributes %s", FieldMetadataKey, strings.Join(suggestions, ", "))
+}
+
+// builtinFields returns a copy of the attributes of a built-in error media type whose
+// FieldMetadataKey metadata is set from builtinFieldNumbers. The built-in definitions are left
+// untouched.
+func builtinFields(obj design.Object) design.Object {
+	res := make(design.Object, len(obj))
+	for n, att := range obj {
+		num, ok := builtinFieldNumbers[n]
+		if !ok {
+			res[n] = att
+			continue
+		}
+		dup := *att
+		dup.Metadata = dslengine.MetadataDefinition{FieldMetadataKey: {strconv.Itoa(num)}}
+		res[n] = &dup
+	}
+	return res
+}
+
+// sortedNames returns the names of the attributes of obj in alphabetical order.
+func sortedNames(obj design.Object) []string {
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// goCamelCase returns the name of the Go struct field that protoc-gen-go generates for a
+// protobuf field.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+			// skip "_" in "_{{lowercase}}"
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isLower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// Proto renders the protobuf definitions.
+func (f *File) Proto() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "syntax = \"proto3\";\n\npackage %s;\n", f.Package)
+	if f.GoPackage != "" {
+		fmt.Fprintf(&buf, "\noption go_package = %q;\n", f.GoPackage)
+	}
+	for _, svc := range f.Services {
+		buf.WriteString("\n")
+		writeComment(&buf, svc.Description, "")
+		fmt.Fprintf(&buf, "service %s {\n", svc.Name)
+		for _, rpc := range svc.RPCs {
+			writeComment(&buf, rpc.Description, "\t")
+			fmt.Fprintf(&buf, "\trpc %s (%s) returns (%s);\n", rpc.Name, rpc.Request.Name, rpc.Response.Name)
+		}
+		buf.WriteString("}\n")
+	}
+	for _, msg := range f.Messages {
+		buf.WriteString("\n")
+		writeMessage(&buf, msg, "")
+	}
+	return buf.String()
+}
+
+// writeMessage renders the definition of msg indented with the given prefix.
+func writeMessage(buf *bytes.Buffer, msg *Message, indent string) {
+	writeComment(buf, msg.Description, indent)
+	fmt.Fprintf(buf, "%smessage %s {\n", indent, msg.Name)
+	for _, nested := range msg.Nested {
+		writeMessage(buf, nested, indent+"\t")
+	}
+	for _, f := range msg.Fields {
+		writeComment(buf, f.Description, indent+"\t")
+		var label string
+		if f.Repeated {
+			label = "repeated "
+		} else if f.Optional {
+			label = "optional "
+		}
+		fmt.Fprintf(buf, "%s\t%s%s %s = %d;\n", indent, label, f.Type, f.Name, f.Number)
+	}
+	fmt.Fprintf(buf, "%s}\n", indent)
+}
+
+// writeComment renders desc as line comments indented with the given prefix.
+func writeComment(buf *bytes.Buffer, desc, indent string) {
+	if desc = strings.TrimSpace(desc); desc != "" {
+		buf.WriteString(codegen.Indent(codegen.Comment(desc), indent))
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,310 @@
+package genproto_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	genproto "github.com/goadesign/goa/goagen/gen_proto"
+	"github.com/goadesign/goa/goagen/ir"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewFile", func() {
+	var file *genproto.File
+	var newErr error
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		ProjectedMediaTypes = make(MediaTypeRoot)
+		API("cellar", func() {})
+		bottle := MediaType("application/vnd.goa.bottle", func() {
+			TypeName("Bottle")
+			Attributes(func() {
+				Attribute("name", String, "Bottle name", func() {
+					Metadata(genproto.FieldMetadataKey, "6")
+				})
+				Attribute("id", Integer, func() {
+					Format(FormatInt32)
+					Metadata(genproto.FieldMetadataKey, "5")
+				})
+				Attribute("tags", ArrayOf(String), func() {
+					Metadata(genproto.FieldMetadataKey, "8")
+				})
+				Attribute("origin", func() {
+					Metadata(genproto.FieldMetadataKey, "7")
+					Attribute("country", String, func() {
+						Metadata(genproto.FieldMetadataKey, "1")
+					})
+				})
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("name")
+				Attribute("tags")
+				Attribute("origin")
+			})
+			View("tiny", func() {
+				Attribute("name")
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			Action("show", func() {
+				Routing(GET("/:bottleID"))
+				Params(func() {
+					Param("bottleID", Integer, func() {
+						Metadata(genproto.FieldMetadataKey, "1")
+					})
+					Param("fields", ArrayOf(String), func() {
+						Metadata(genproto.FieldMetadataKey, "2")
+					})
+				})
+				Headers(func() {
+					Header("X-Account", String, func() {
+						Metadata(genproto.FieldMetadataKey, "3")
+					})
+				})
+				Response(OK, bottle)
+			})
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(func() {
+					Metadata(genproto.FieldMetadataKey, "1")
+					Attribute("name", String, func() {
+						Metadata(genproto.FieldMetadataKey, "1")
+					})
+				})
+				Response(Created)
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		api, err := ir.Build(Design)
+		Ω(err).ShouldNot(HaveOccurred())
+		file, newErr = genproto.NewFile(api, "example.com/cellar/proto;proto")
+	})
+
+	message := func(name string) *genproto.Message {
+		for _, m := range file.Messages {
+			if m.Name == name {
+				return m
+			}
+		}
+		return nil
+	}
+
+	It("generates a message for each media type view", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		Ω(message("Bottle")).ShouldNot(BeNil())
+		Ω(message("BottleTiny")).ShouldNot(BeNil())
+	})
+
+	It("uses stable field numbers", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		fields := message("Bottle").Fields
+		Ω(fields).Should(HaveLen(4))
+		Ω(fields[0].Name).Should(Equal("id"))
+		Ω(fields[0].Number).Should(Equal(5))
+		Ω(fields[0].Type).Should(Equal("int32"))
+		Ω(fields[1].Name).Should(Equal("name"))
+		Ω(fields[1].Number).Should(Equal(6))
+		tiny := message("BottleTiny").Fields
+		Ω(tiny).Should(HaveLen(1))
+		Ω(tiny[0].Number).Should(Equal(6))
+	})
+
+	It("numbers the request fields", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		show := file.Services[0].RPCs[1]
+		Ω(show.PathParams[0].Number).Should(Equal(1))
+		Ω(show.QueryParams[0].Number).Should(Equal(2))
+		Ω(show.Headers[0].Number).Should(Equal(3))
+		Ω(file.Services[0].RPCs[0].Payload.Number).Should(Equal(1))
+	})
+
+	It("generates nested messages for anonymous objects", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		msg := message("Bottle")
+		Ω(msg.Nested).Should(HaveLen(1))
+		Ω(msg.Nested[0].Name).Should(Equal("Origin"))
+		Ω(msg.Fields[2].Type).Should(Equal("Origin"))
+		Ω(msg.Fields[3].Repeated).Should(BeTrue())
+	})
+
+	It("generates a gRPC service per resource", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		Ω(file.Services).Should(HaveLen(1))
+		svc := file.Services[0]
+		Ω(svc.Name).Should(Equal("BottleService"))
+		Ω(svc.RPCs).Should(HaveLen(2))
+		show := svc.RPCs[1]
+		Ω(show.Name).Should(Equal("Show"))
+		Ω(show.Path).Should(Equal("/bottles/:bottleID"))
+		Ω(show.PathParams).Should(HaveLen(1))
+		Ω(show.PathParams[0].GoName).Should(Equal("BottleID"))
+		Ω(show.QueryParams).Should(HaveLen(1))
+		Ω(show.Headers).Should(HaveLen(1))
+		Ω(show.Headers[0].Key).Should(Equal("X-Account"))
+		Ω(show.Headers[0].Name).Should(Equal("x_account"))
+		Ω(show.Result.Type).Should(Equal("Bottle"))
+		create := svc.RPCs[0]
+		Ω(create.Payload).ShouldNot(BeNil())
+		Ω(create.Payload.Type).Should(Equal("CreateBottlePayload"))
+		Ω(create.Result).Should(BeNil())
+	})
+
+	It("renders the proto definitions", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		proto := file.Proto()
+		Ω(proto).Should(ContainSubstring(`option go_package = "example.com/cellar/proto;proto";`))
+		Ω(proto).Should(ContainSubstring("rpc Show (ShowBottleRequest) returns (ShowBottleResponse);"))
+		Ω(proto).Should(ContainSubstring("\t// Bottle name\n\toptional string name = 6;\n"))
+		Ω(proto).Should(ContainSubstring("\trepeated string tags = 8;\n"))
+	})
+
+	Context("with attributes that do not define field numbers", func() {
+		BeforeEach(func() {
+			Type("Unset", func() {
+				Attribute("c", String)
+				Attribute("a", String, func() {
+					Metadata(genproto.FieldMetadataKey, "2")
+				})
+				Attribute("b", String)
+			})
+		})
+
+		It("returns an error with suggested numbers", func() {
+			Ω(newErr).Should(HaveOccurred())
+			Ω(newErr.Error()).Should(ContainSubstring(`missing proto:field metadata for attributes "b" (suggested number: 3), "c" (suggested number: 4)`))
+		})
+	})
+
+	Context("with error responses", func() {
+		BeforeEach(func() {
+			Resource("operands", func() {
+				Action("add", func() {
+					Routing(GET("/add"))
+					Response(NoContent)
+					Response(BadRequest, ErrorMedia)
+				})
+			})
+		})
+
+		It("numbers the fields of the built-in error media type", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			fields := message("Error").Fields
+			Ω(fields).Should(HaveLen(5))
+			Ω(fields[0].Name).Should(Equal("id"))
+			Ω(fields[0].Number).Should(Equal(1))
+			Ω(fields[4].Name).Should(Equal("meta"))
+			Ω(fields[4].Number).Should(Equal(5))
+		})
+
+		Context("rendered as problem details", func() {
+			BeforeEach(func() {
+				Design.ProblemDetails = true
+			})
+
+			It("numbers the fields of the built-in problem media type", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				Ω(message("Error")).Should(BeNil())
+				fields := message("Problem").Fields
+				Ω(fields).Should(HaveLen(6))
+				Ω(fields[0].Name).Should(Equal("id"))
+				Ω(fields[1].Name).Should(Equal("status"))
+				Ω(fields[1].Type).Should(Equal("int64"))
+			})
+		})
+	})
+
+	Context("with duplicate field numbers", func() {
+		BeforeEach(func() {
+			Type("Dup", func() {
+				Attribute("a", String, func() {
+					Metadata(genproto.FieldMetadataKey, "1")
+				})
+				Attribute("b", String, func() {
+					Metadata(genproto.FieldMetadataKey, "1")
+				})
+			})
+		})
+
+		It("returns an error", func() {
+			Ω(newErr).Should(HaveOccurred())
+			Ω(newErr.Error()).Should(ContainSubstring("same field number 1"))
+		})
+	})
+})
+
+var _ = Describe("Generate", func() {
+	var workspace *codegen.Workspace
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		outDir, err = ioutil.TempDir(filepath.Join(workspace.Path, "src"), "")
+		Ω(err).ShouldNot(HaveOccurred())
+		dslengine.Reset()
+		ProjectedMediaTypes = make(MediaTypeRoot)
+		API("cellar", func() {})
+		bottle := MediaType("application/vnd.goa.bottle", func() {
+			Attributes(func() {
+				Attribute("name", String, func() {
+					Metadata(genproto.FieldMetadataKey, "1")
+				})
+			})
+			View("default", func() {
+				Attribute("name")
+			})
+		})
+		Resource("bottle", func() {
+			Action("list", func() {
+				Routing(GET("/bottles"))
+				Params(func() {
+					Param("limit", Integer, func() {
+						Metadata(genproto.FieldMetadataKey, "1")
+					})
+				})
+				Response(OK, CollectionOf(bottle))
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		g := genproto.NewGenerator(
+			genproto.API(Design),
+			genproto.OutDir(filepath.Join(outDir, "proto")),
+			genproto.Target("proto"),
+		)
+		files, genErr = g.Generate()
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	It("generates the proto file and the adapters", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(HaveLen(3))
+		proto, err := ioutil.ReadFile(filepath.Join(outDir, "proto", "cellar.proto"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(proto)).Should(ContainSubstring("repeated GoaBottle result = 1;"))
+		adapters, err := ioutil.ReadFile(filepath.Join(outDir, "proto", "adapters.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(adapters)).Should(ContainSubstring("package proto"))
+		Ω(string(adapters)).Should(ContainSubstring("func (a *BottleServiceAdapter) List(ctx context.Context, req *ListBottleRequest) (*ListBottleResponse, error) {"))
+		Ω(string(adapters)).Should(ContainSubstring(`r.query("limit", req.Limit)`))
+	})
+})
@@ -2,13 +2,13 @@ package genschema
 
 import (
 	"flag"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/ir"
 	"github.com/goadesign/goa/goagen/utils"
 )
 
@@ -48,12 +48,13 @@ func Generate() (files []string, err error) {
 	return g.Generate()
 }
 
-// Generate produces the skeleton main.
-func (g *Generator) Generate() (_ []string, err error) {
-	if g.API == nil {
-		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
-	}
+// Generate produces the API JSON hyper schema.
+func (g *Generator) Generate() ([]string, error) {
+	return ir.Run(g, g.API)
+}
 
+// GenerateIR produces the API JSON hyper schema from the intermediate representation of the API.
+func (g *Generator) GenerateIR(api *ir.API) (_ []string, err error) {
 	go utils.Catch(nil, func() { g.Cleanup() })
 
 	defer func() {
@@ -62,7 +63,7 @@ func (g *Generator) Generate() (_ []string, err error) {
 		}
 	}()
 
-	s := APISchema(g.API)
+	s := apiSchema(api)
 	js, err := s.JSON()
 	if err != nil {
 		return
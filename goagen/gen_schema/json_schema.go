@@ -9,6 +9,7 @@ import (
 	"strconv"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/ir"
 )
 
 type (
@@ -125,12 +126,39 @@ func (s *JSONSchema) JSON() ([]byte, error) {
 	return json.Marshal(s)
 }
 
-// APISchema produces the API JSON hyper schema.
+// APISchema produces the API JSON hyper schema. It panics if the design has not been validated,
+// see ir.Build.
 func APISchema(api *design.APIDefinition) *JSONSchema {
-	api.IterateResources(func(r *design.ResourceDefinition) error {
-		GenerateResourceDefinition(api, r)
-		return nil
-	})
+	return apiSchema(buildIR(api))
+}
+
+// GenerateResourceDefinition produces the JSON schema corresponding to the given API resource.
+// It stores the results in cachedSchema. It panics if the design has not been validated, see
+// ir.Build.
+func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDefinition) {
+	a := buildIR(api)
+	for _, res := range a.Resources {
+		if res.Definition == r {
+			generateResourceDefinition(a, res)
+			return
+		}
+	}
+}
+
+// buildIR computes the intermediate representation of the given API design.
+func buildIR(api *design.APIDefinition) *ir.API {
+	a, err := ir.Build(api)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// apiSchema produces the API JSON hyper schema from the intermediate representation of the API.
+func apiSchema(api *ir.API) *JSONSchema {
+	for _, r := range api.Resources {
+		generateResourceDefinition(api, r)
+	}
 	scheme := "http"
 	if len(api.Schemes) > 0 {
 		scheme = api.Schemes[0]
@@ -164,66 +192,57 @@ func APISchema(api *design.APIDefinition) *JSONSchema {
 	return &s
 }
 
-// GenerateResourceDefinition produces the JSON schema corresponding to the given API resource.
-// It stores the results in cachedSchema.
-func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDefinition) {
+// generateResourceDefinition produces the JSON schema corresponding to the given resource of the
+// intermediate representation of the API.
+func generateResourceDefinition(api *ir.API, r *ir.Resource) {
 	s := NewJSONSchema()
 	s.Description = r.Description
 	s.Type = JSONObject
 	s.Title = r.Name
 	Definitions[r.Name] = s
-	if mt, ok := api.MediaTypes[r.MediaType]; ok {
+	if mt := api.MediaType(r.Definition.MediaType); mt != nil {
 		for _, v := range mt.Views {
-			buildMediaTypeSchema(api, mt, v.Name, s)
+			buildMediaTypeSchema(api.Definition, mt.Definition, v.Name, s)
 		}
 	}
-	r.IterateActions(func(a *design.ActionDefinition) error {
+	for _, a := range r.Actions {
 		var requestSchema *JSONSchema
 		if a.Payload != nil {
-			requestSchema = TypeSchema(api, a.Payload)
+			requestSchema = TypeSchema(api.Definition, a.Payload.Type)
 			requestSchema.Description = a.Name + " payload"
 		}
-		if a.Params != nil {
-			params := design.DupAtt(a.Params)
-			// We don't want to keep the path params, these are defined inline in the href
-			for _, r := range a.Routes {
-				for _, p := range r.Params() {
-					delete(params.Type.ToObject(), p)
-				}
-			}
-		}
 		var targetSchema *JSONSchema
 		var identifier string
 		for _, resp := range a.Responses {
-			if mt, ok := api.MediaTypes[resp.MediaType]; ok {
+			if mt := resp.MediaType; mt != nil {
 				if identifier == "" {
 					identifier = mt.Identifier
 				} else {
 					identifier = ""
 				}
 				if targetSchema == nil {
-					targetSchema = TypeSchema(api, mt)
+					targetSchema = TypeSchema(api.Definition, mt.Definition)
 				} else if targetSchema.AnyOf == nil {
 					firstSchema := targetSchema
 					targetSchema = NewJSONSchema()
-					targetSchema.AnyOf = []*JSONSchema{firstSchema, TypeSchema(api, mt)}
+					targetSchema.AnyOf = []*JSONSchema{firstSchema, TypeSchema(api.Definition, mt.Definition)}
 				} else {
-					targetSchema.AnyOf = append(targetSchema.AnyOf, TypeSchema(api, mt))
+					targetSchema.AnyOf = append(targetSchema.AnyOf, TypeSchema(api.Definition, mt.Definition))
 				}
 			}
 		}
-		for i, r := range a.Routes {
+		for i, route := range a.Routes {
 			link := JSONLink{
 				Title:        a.Name,
 				Rel:          a.Name,
-				Href:         toSchemaHref(api, r),
-				Method:       r.Verb,
+				Href:         toSchemaHref(route.Path, route.Params),
+				Method:       route.Verb,
 				Schema:       requestSchema,
 				TargetSchema: targetSchema,
 				MediaType:    identifier,
 			}
 			if i == 0 {
-				if ca := a.Parent.CanonicalAction(); ca != nil {
+				if ca := r.Definition.CanonicalAction(); ca != nil {
 					if ca.Name == a.Name {
 						link.Rel = "self"
 					}
@@ -231,8 +250,7 @@ func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDef
 			}
 			s.Links = append(s.Links, &link)
 		}
-		return nil
-	})
+	}
 }
 
 // MediaTypeRef produces the JSON reference to the media type definition with the given view.
@@ -466,11 +484,8 @@ func buildAttributeSchema(api *design.APIDefinition, s *JSONSchema, at *design.A
 	s.Enum = val.Values
 	s.Format = val.Format
 	s.Pattern = val.Pattern
-	if val.Minimum != nil {
-		s.Minimum = val.Minimum
-	}
-	if val.Maximum != nil {
-		s.Maximum = val.Maximum
+	if min, max := at.NumericRange(); min != nil || max != nil {
+		s.Minimum, s.Maximum = min, max
 	}
 	if val.MinLength != nil {
 		switch {
@@ -528,15 +543,14 @@ func toString(val interface{}) string {
 	}
 }
 
-// toSchemaHref produces a href that replaces the path wildcards with JSON schema references when
-// appropriate.
-func toSchemaHref(api *design.APIDefinition, r *design.RouteDefinition) string {
-	params := r.Params()
+// toSchemaHref produces a href that replaces the wildcards of the given route full path with JSON
+// schema references when appropriate.
+func toSchemaHref(path string, params []string) string {
 	args := make([]interface{}, len(params))
 	for i, p := range params {
 		args[i] = fmt.Sprintf("/{%s}", p)
 	}
-	tmpl := design.WildcardRegex.ReplaceAllLiteralString(r.FullPath(), "%s")
+	tmpl := design.WildcardRegex.ReplaceAllLiteralString(path, "%s")
 	return fmt.Sprintf(tmpl, args...)
 }
 
@@ -579,7 +593,8 @@ func buildMediaTypeSchema(api *design.APIDefinition, mt *design.MediaTypeDefinit
 				href string
 			)
 			if r != nil {
-				href = toSchemaHref(api, r.CanonicalAction().Routes[0])
+				route := r.CanonicalAction().Routes[0]
+				href = toSchemaHref(route.FullPath(), route.Params())
 			}
 			sm := NewJSONSchema()
 			sm.Ref = MediaTypeRef(api, lmt, "default")
@@ -79,3 +79,59 @@ var _ = Describe("TypeSchema", func() {
 
 	})
 })
+
+var _ = Describe("APISchema", func() {
+	var s *genschema.JSONSchema
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		design.ProjectedMediaTypes = make(design.MediaTypeRoot)
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+		API("cellar", func() {
+			BasePath("/api")
+		})
+		bottle := MediaType("application/vnd.goa.bottle", func() {
+			Attributes(func() {
+				Attribute("id", design.Integer)
+			})
+			View("default", func() {
+				Attribute("id")
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			DefaultMedia(bottle)
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Params(func() {
+					Param("id", design.Integer)
+				})
+				Response(design.OK)
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		s = genschema.APISchema(design.Design)
+	})
+
+	It("generates the resource links with the route full paths", func() {
+		r := s.Definitions["bottle"]
+		Ω(r).ShouldNot(BeNil())
+		Ω(r.Media.Type).Should(Equal("application/vnd.goa.bottle"))
+		Ω(r.Links).Should(HaveLen(1))
+		Ω(r.Links[0].Href).Should(Equal("/api/bottles/{id}"))
+		Ω(r.Links[0].Method).Should(Equal("GET"))
+		Ω(r.Links[0].MediaType).Should(Equal("application/vnd.goa.bottle"))
+	})
+
+	It("generates the definition of a single resource", func() {
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+		genschema.GenerateResourceDefinition(design.Design, design.Design.Resources["bottle"])
+		r := genschema.Definitions["bottle"]
+		Ω(r).ShouldNot(BeNil())
+		Ω(r.Links).Should(HaveLen(1))
+		Ω(r.Links[0].Href).Should(Equal("/api/bottles/{id}"))
+	})
+})
@@ -864,7 +864,7 @@ func buildPathFromFileServer(s *Swagger, api *design.APIDefinition, fs *design.F
 		},
 	}
 	if len(wcs) > 0 {
-		schema := genschema.TypeSchema(api, design.ErrorMedia)
+		schema := genschema.TypeSchema(api, api.ErrorMedia())
 		responses["404"] = &Response{Description: "File not found", Schema: schema}
 	}
 
@@ -1206,11 +1206,12 @@ func initValidations(attr *design.AttributeDefinition, def interface{}) {
 	initEnumValidation(def, val.Values)
 	initFormatValidation(def, val.Format)
 	initPatternValidation(def, val.Pattern)
-	if val.Minimum != nil {
-		initMinimumValidation(def, val.Minimum)
+	min, max := attr.NumericRange()
+	if min != nil {
+		initMinimumValidation(def, min)
 	}
-	if val.Maximum != nil {
-		initMaximumValidation(def, val.Maximum)
+	if max != nil {
+		initMaximumValidation(def, max)
 	}
 	if val.MinLength != nil {
 		initMinLengthValidation(def, attr.Type.IsArray(), val.MinLength)
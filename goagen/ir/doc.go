@@ -0,0 +1,26 @@
+/*
+Package ir provides an intermediate representation of a goa API design intended for generators.
+
+The design package describes the API the way it is written in the DSL: routes are relative to
+their resource and API base paths, parameters are defined once for all the action routes,
+responses refer to media types by identifier etc. Each generator used to resolve these details on
+its own. The intermediate representation built by Build resolves them once:
+
+  - routes have their full paths and path parameters computed,
+  - action parameters are split by location (path, query string and headers),
+  - responses refer to the media type view they render,
+  - the Go type names used by the generated code are computed.
+
+The JSON schema (gen_schema) and protocol buffers (gen_proto) generators consume the intermediate
+representation, the other generators still walk the design directly. Generators that consume the
+intermediate representation implement the Generator interface and are run with Run:
+
+	func Generate() ([]string, error) {
+		// ... parse flags
+		return ir.Run(&MyGenerator{}, design.Design)
+	}
+
+Each element of the representation keeps a reference to the design definition it is built from so
+that generators may access the details not covered by the intermediate representation.
+*/
+package ir
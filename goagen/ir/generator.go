@@ -0,0 +1,21 @@
+package ir
+
+import "github.com/goadesign/goa/design"
+
+// Generator is the interface implemented by the generators that consume the intermediate
+// representation of the API design. Implementing the interface is optional: generators may keep
+// walking the design directly.
+type Generator interface {
+	// GenerateIR generates the files for the given API and returns their paths.
+	GenerateIR(api *API) ([]string, error)
+}
+
+// Run builds the intermediate representation of the given API design and runs the generator
+// with it.
+func Run(g Generator, api *design.APIDefinition) ([]string, error) {
+	a, err := Build(api)
+	if err != nil {
+		return nil, err
+	}
+	return g.GenerateIR(a)
+}
@@ -0,0 +1,496 @@
+package ir
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// Parameter locations.
+const (
+	// LocationPath identifies parameters defined in the request path.
+	LocationPath Location = "path"
+	// LocationQuery identifies parameters defined in the request query string.
+	LocationQuery Location = "query"
+	// LocationHeader identifies parameters defined in the request or response headers.
+	LocationHeader Location = "header"
+)
+
+type (
+	// Location is the location of a parameter in the HTTP request or response.
+	Location string
+
+	// API is the intermediate representation of an API design.
+	API struct {
+		// Name is the API name.
+		Name string
+		// Title is the API title.
+		Title string
+		// Description is the API description.
+		Description string
+		// Host is the API hostname.
+		Host string
+		// Schemes lists the API supported schemes.
+		Schemes []string
+		// BasePath is the common path prefix to all the API actions.
+		BasePath string
+		// Resources lists the API resources sorted by name.
+		Resources []*Resource
+		// MediaTypes lists the API media types sorted by identifier.
+		MediaTypes []*MediaType
+		// UserTypes lists the API user types sorted by name.
+		UserTypes []*UserType
+		// Definition is the API design definition.
+		Definition *design.APIDefinition
+	}
+
+	// Resource is the intermediate representation of a resource.
+	Resource struct {
+		// Name is the resource name as defined in the design.
+		Name string
+		// GoName is the name of the resource used in Go identifiers, e.g. "Bottle".
+		GoName string
+		// Description is the resource description.
+		Description string
+		// BasePath is the resource full base path.
+		BasePath string
+		// Actions lists the resource actions sorted by name.
+		Actions []*Action
+		// Definition is the resource design definition.
+		Definition *design.ResourceDefinition
+	}
+
+	// Action is the intermediate representation of an action.
+	Action struct {
+		// Name is the action name as defined in the design.
+		Name string
+		// GoName is the name of the action used in Go identifiers, e.g. "Show".
+		GoName string
+		// Description is the action description.
+		Description string
+		// ContextName is the name of the action context data structure, e.g.
+		// "ShowBottleContext".
+		ContextName string
+		// Resource is the parent resource.
+		Resource *Resource
+		// Routes lists the action routes.
+		Routes []*Route
+		// PathParams lists the parameters that appear in the action route paths in the order
+		// they appear.
+		PathParams []*Param
+		// QueryParams lists the query string parameters sorted by name.
+		QueryParams []*Param
+		// Headers lists the request headers sorted by name, this includes the headers
+		// defined on the parent resource.
+		Headers []*Param
+		// Payload describes the request body if any.
+		Payload *Payload
+		// Responses lists the action responses sorted by name.
+		Responses []*Response
+		// Security is the action security requirement if any.
+		Security *design.SecurityDefinition
+		// Definition is the action design definition.
+		Definition *design.ActionDefinition
+	}
+
+	// Route is the intermediate representation of an action route.
+	Route struct {
+		// Verb is the HTTP method.
+		Verb string
+		// Path is the route full path including the API and resource base paths.
+		Path string
+		// Params lists the names of the path parameters in the order they appear.
+		Params []string
+		// Definition is the route design definition.
+		Definition *design.RouteDefinition
+	}
+
+	// Param is the intermediate representation of a request parameter or header or of a
+	// response header.
+	Param struct {
+		// Name is the parameter name as it appears in the request, e.g. the header name.
+		Name string
+		// GoName is the name of the Go field holding the value of the parameter.
+		GoName string
+		// GoType is the Go type of the field holding the value of the parameter.
+		GoType string
+		// Location is the location of the parameter in the request or response.
+		Location Location
+		// Required is true if the parameter must be present.
+		Required bool
+		// Pointer is true if the Go field is a pointer to GoType.
+		Pointer bool
+		// Description is the parameter description.
+		Description string
+		// Attribute is the parameter attribute definition.
+		Attribute *design.AttributeDefinition
+	}
+
+	// Payload is the intermediate representation of a request body.
+	Payload struct {
+		// GoType is the Go type of the payload, e.g. "*CreateBottlePayload".
+		GoType string
+		// Required is true if the request must have a body.
+		Required bool
+		// Multipart is true if the body is encoded as multipart form data.
+		Multipart bool
+		// Stream indicates how the body is streamed if it is, zero otherwise.
+		Stream design.StreamKind
+		// Type is the payload type definition.
+		Type *design.UserTypeDefinition
+	}
+
+	// Response is the intermediate representation of an action response.
+	Response struct {
+		// Name is the response name, e.g. "OK".
+		Name string
+		// Status is the HTTP status code.
+		Status int
+		// Description is the response description.
+		Description string
+		// MediaType is the response media type if it is defined in the API.
+		MediaType *MediaType
+		// View is the media type view rendered by the response, nil if the response
+		// does not define a media type.
+		View *View
+		// GoType is the Go type of the response body if any, e.g. "*GoaBottle".
+		GoType string
+		// Headers lists the response headers sorted by name.
+		Headers []*Param
+		// Stream indicates how the body is streamed if it is, zero otherwise.
+		Stream design.StreamKind
+		// Definition is the response design definition.
+		Definition *design.ResponseDefinition
+	}
+
+	// MediaType is the intermediate representation of a media type.
+	MediaType struct {
+		// Identifier is the media type identifier.
+		Identifier string
+		// TypeName is the media type name as defined in the design.
+		TypeName string
+		// Description is the media type description.
+		Description string
+		// Views lists the media type views sorted by name.
+		Views []*View
+		// Definition is the media type design definition.
+		Definition *design.MediaTypeDefinition
+	}
+
+	// View is the intermediate representation of a media type view.
+	View struct {
+		// Name is the view name.
+		Name string
+		// GoType is the name of the Go type generated for the view, e.g. "GoaBottleTiny".
+		GoType string
+		// MediaType is the media type the view belongs to.
+		MediaType *MediaType
+		// Projected is the media type projected onto the view.
+		Projected *design.MediaTypeDefinition
+		// Links is the links user type of the projected media type if any.
+		Links *design.UserTypeDefinition
+	}
+
+	// UserType is the intermediate representation of a user type.
+	UserType struct {
+		// TypeName is the user type name as defined in the design.
+		TypeName string
+		// GoType is the name of the Go type generated for the user type.
+		GoType string
+		// Description is the user type description.
+		Description string
+		// Definition is the user type design definition.
+		Definition *design.UserTypeDefinition
+	}
+)
+
+// Build computes the intermediate representation of the given API design. The design must have
+// been run and validated.
+func Build(api *design.APIDefinition) (*API, error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+	res := &API{
+		Name:        api.Name,
+		Title:       api.Title,
+		Description: api.Description,
+		Host:        api.Host,
+		Schemes:     api.Schemes,
+		BasePath:    api.BasePath,
+		Definition:  api,
+	}
+	mts := make(map[string]*MediaType)
+	err := api.IterateMediaTypes(func(m *design.MediaTypeDefinition) error {
+		mt, err := buildMediaType(m)
+		if err != nil {
+			return err
+		}
+		mts[mt.Identifier] = mt
+		res.MediaTypes = append(res.MediaTypes, mt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		res.UserTypes = append(res.UserTypes, &UserType{
+			TypeName:    ut.TypeName,
+			GoType:      codegen.GoTypeName(ut, nil, 0, false),
+			Description: ut.Description,
+			Definition:  ut,
+		})
+		return nil
+	})
+	err = api.IterateResources(func(r *design.ResourceDefinition) error {
+		resource := &Resource{
+			Name:        r.Name,
+			GoName:      codegen.Goify(r.Name, true),
+			Description: r.Description,
+			BasePath:    r.FullPath(),
+			Definition:  r,
+		}
+		err := r.IterateActions(func(a *design.ActionDefinition) error {
+			action, err := buildAction(api, resource, a, mts)
+			if err != nil {
+				return err
+			}
+			resource.Actions = append(resource.Actions, action)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		res.Resources = append(res.Resources, resource)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Resource returns the resource with the given design name, nil if there isn't one.
+func (a *API) Resource(name string) *Resource {
+	for _, r := range a.Resources {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// MediaType returns the media type with the given identifier, nil if there isn't one.
+func (a *API) MediaType(identifier string) *MediaType {
+	identifier = design.CanonicalIdentifier(identifier)
+	for _, mt := range a.MediaTypes {
+		if mt.Identifier == identifier {
+			return mt
+		}
+	}
+	return nil
+}
+
+// Action returns the action with the given design name, nil if there isn't one.
+func (r *Resource) Action(name string) *Action {
+	for _, a := range r.Actions {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Params returns the path and query string parameters of the action.
+func (a *Action) Params() []*Param {
+	params := make([]*Param, 0, len(a.PathParams)+len(a.QueryParams))
+	params = append(params, a.PathParams...)
+	return append(params, a.QueryParams...)
+}
+
+// Response returns the response with the given name, nil if there isn't one.
+func (a *Action) Response(name string) *Response {
+	for _, r := range a.Responses {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// IsSuccess returns true if the response status code is in the 2xx range.
+func (r *Response) IsSuccess() bool {
+	return r.Status >= 200 && r.Status < 300
+}
+
+// View returns the view with the given name, nil if there isn't one.
+func (m *MediaType) View(name string) *View {
+	for _, v := range m.Views {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// buildMediaType computes the representation of the given media type and its views.
+func buildMediaType(m *design.MediaTypeDefinition) (*MediaType, error) {
+	mt := &MediaType{
+		Identifier:  design.CanonicalIdentifier(m.Identifier),
+		TypeName:    m.TypeName,
+		Description: m.Description,
+		Definition:  m,
+	}
+	err := m.IterateViews(func(v *design.ViewDefinition) error {
+		p, links, err := m.Project(v.Name)
+		if err != nil {
+			return err
+		}
+		mt.Views = append(mt.Views, &View{
+			Name:      v.Name,
+			GoType:    codegen.GoTypeName(p, nil, 0, false),
+			MediaType: mt,
+			Projected: p,
+			Links:     links,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mt, nil
+}
+
+// buildAction computes the representation of the given action.
+func buildAction(api *design.APIDefinition, r *Resource, a *design.ActionDefinition, mts map[string]*MediaType) (*Action, error) {
+	action := &Action{
+		Name:        a.Name,
+		GoName:      codegen.Goify(a.Name, true),
+		Description: a.Description,
+		ContextName: codegen.Goify(a.Name, true) + r.GoName + "Context",
+		Resource:    r,
+		Security:    a.Security,
+		Definition:  a,
+	}
+
+	// Routes and parameters
+	isPath := make(map[string]bool)
+	var pathParams []string
+	for _, route := range a.Routes {
+		rt := &Route{
+			Verb:       route.Verb,
+			Path:       route.FullPath(),
+			Params:     route.Params(),
+			Definition: route,
+		}
+		action.Routes = append(action.Routes, rt)
+		for _, p := range rt.Params {
+			if !isPath[p] {
+				isPath[p] = true
+				pathParams = append(pathParams, p)
+			}
+		}
+	}
+	params := a.AllParams()
+	obj := params.Type.ToObject()
+	for _, n := range pathParams {
+		att, ok := obj[n]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown path parameter %#v", a.Context(), n)
+		}
+		p := newParam(n, att, LocationPath, true, params.IsPrimitivePointer(n))
+		action.PathParams = append(action.PathParams, p)
+	}
+	for _, n := range sortedNames(obj) {
+		if isPath[n] {
+			continue
+		}
+		p := newParam(n, obj[n], LocationQuery, params.IsRequired(n), params.IsPrimitivePointer(n))
+		action.QueryParams = append(action.QueryParams, p)
+	}
+	var headers *design.AttributeDefinition
+	if a.Parent.Headers != nil {
+		headers = design.DupAtt(a.Parent.Headers)
+	}
+	headers = headers.Merge(a.Headers)
+	a.IterateHeaders(func(name string, required bool, h *design.AttributeDefinition) error {
+		p := newParam(name, h, LocationHeader, required, headers.IsPrimitivePointer(name))
+		action.Headers = append(action.Headers, p)
+		return nil
+	})
+
+	// Payload
+	if a.Payload != nil {
+		action.Payload = &Payload{
+			GoType:    codegen.GoTypeRef(a.Payload, nil, 0, false),
+			Required:  !a.PayloadOptional,
+			Multipart: a.PayloadMultipart,
+			Stream:    a.PayloadStream,
+			Type:      a.Payload,
+		}
+	}
+
+	// Responses
+	err := a.IterateResponses(func(resp *design.ResponseDefinition) error {
+		res := &Response{
+			Name:        resp.Name,
+			Status:      resp.Status,
+			Description: resp.Description,
+			Stream:      resp.Stream,
+			Definition:  resp,
+		}
+		if mt, ok := mts[design.CanonicalIdentifier(resp.MediaType)]; ok && resp.MediaType != "" {
+			view := resp.ViewName
+			if view == "" {
+				view = design.DefaultView
+			}
+			v := mt.View(view)
+			if v == nil {
+				return fmt.Errorf("%s: unknown view %#v of media type %#v", a.Context(), view, mt.Identifier)
+			}
+			res.MediaType = mt
+			res.View = v
+			res.GoType = codegen.GoTypeRef(v.Projected, v.Projected.AllRequired(), 0, false)
+		} else if resp.Type != nil {
+			res.GoType = codegen.GoTypeRef(resp.Type, nil, 0, false)
+		}
+		if resp.Headers != nil {
+			for _, n := range sortedNames(resp.Headers.Type.ToObject()) {
+				att := resp.Headers.Type.ToObject()[n]
+				p := newParam(n, att, LocationHeader, resp.Headers.IsRequired(n), false)
+				res.Headers = append(res.Headers, p)
+			}
+		}
+		action.Responses = append(action.Responses, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// newParam initializes a parameter.
+func newParam(name string, att *design.AttributeDefinition, loc Location, required, pointer bool) *Param {
+	return &Param{
+		Name:        name,
+		GoName:      codegen.GoifyAtt(att, name, true),
+		GoType:      codegen.GoTypeRefAtt(att, 0, false),
+		Location:    loc,
+		Required:    required,
+		Pointer:     pointer,
+		Description: att.Description,
+		Attribute:   att,
+	}
+}
+
+// sortedNames returns the names of the attributes of obj in alphabetical order.
+func sortedNames(obj design.Object) []string {
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
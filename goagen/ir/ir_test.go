@@ -0,0 +1,166 @@
+package ir_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/ir"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Build", func() {
+	var api *ir.API
+	var buildErr error
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		ProjectedMediaTypes = make(MediaTypeRoot)
+		API("cellar", func() {
+			BasePath("/api")
+		})
+		bottle := MediaType("application/vnd.goa.bottle", func() {
+			TypeName("Bottle")
+			Attributes(func() {
+				Attribute("id", Integer)
+				Attribute("name", String)
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("name")
+			})
+			View("tiny", func() {
+				Attribute("id")
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/accounts/:accountID/bottles")
+			Headers(func() {
+				Header("X-Account", String)
+			})
+			Action("show", func() {
+				Routing(GET("/:bottleID"))
+				Params(func() {
+					Param("accountID", Integer)
+					Param("bottleID", Integer)
+					Param("fields", ArrayOf(String))
+				})
+				Headers(func() {
+					Header("X-Request-Id", String)
+					Required("X-Request-Id")
+				})
+				Response(OK, func() {
+					Media(bottle, "tiny")
+					Headers(func() {
+						Header("ETag")
+					})
+				})
+				Response(NotFound)
+			})
+			Action("create", func() {
+				Routing(POST(""))
+				Params(func() {
+					Param("accountID", Integer)
+				})
+				Payload(func() {
+					Attribute("name", String)
+				})
+				Response(Created)
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		api, buildErr = ir.Build(Design)
+	})
+
+	It("resolves the media type views", func() {
+		Ω(buildErr).ShouldNot(HaveOccurred())
+		mt := api.MediaType("application/vnd.goa.bottle")
+		Ω(mt).ShouldNot(BeNil())
+		Ω(mt.Views).Should(HaveLen(2))
+		Ω(mt.View("default").GoType).Should(Equal("Bottle"))
+		Ω(mt.View("tiny").GoType).Should(Equal("BottleTiny"))
+	})
+
+	It("resolves the routes", func() {
+		Ω(buildErr).ShouldNot(HaveOccurred())
+		res := api.Resource("bottle")
+		Ω(res).ShouldNot(BeNil())
+		Ω(res.GoName).Should(Equal("Bottle"))
+		Ω(res.BasePath).Should(Equal("/api/accounts/:accountID/bottles"))
+		show := res.Action("show")
+		Ω(show).ShouldNot(BeNil())
+		Ω(show.ContextName).Should(Equal("ShowBottleContext"))
+		Ω(show.Routes).Should(HaveLen(1))
+		Ω(show.Routes[0].Verb).Should(Equal("GET"))
+		Ω(show.Routes[0].Path).Should(Equal("/api/accounts/:accountID/bottles/:bottleID"))
+		Ω(show.Routes[0].Params).Should(Equal([]string{"accountID", "bottleID"}))
+	})
+
+	It("splits the parameters by location", func() {
+		Ω(buildErr).ShouldNot(HaveOccurred())
+		show := api.Resource("bottle").Action("show")
+		Ω(show.PathParams).Should(HaveLen(2))
+		Ω(show.PathParams[0].Name).Should(Equal("accountID"))
+		Ω(show.PathParams[0].GoName).Should(Equal("AccountID"))
+		Ω(show.PathParams[0].GoType).Should(Equal("int"))
+		Ω(show.PathParams[0].Location).Should(Equal(ir.LocationPath))
+		Ω(show.PathParams[0].Required).Should(BeTrue())
+		Ω(show.QueryParams).Should(HaveLen(1))
+		Ω(show.QueryParams[0].Name).Should(Equal("fields"))
+		Ω(show.QueryParams[0].GoType).Should(Equal("[]string"))
+		Ω(show.QueryParams[0].Location).Should(Equal(ir.LocationQuery))
+		Ω(show.Params()).Should(HaveLen(3))
+		Ω(show.Headers).Should(HaveLen(2))
+		Ω(show.Headers[0].Name).Should(Equal("X-Account"))
+		Ω(show.Headers[0].Required).Should(BeFalse())
+		Ω(show.Headers[0].Pointer).Should(BeTrue())
+		Ω(show.Headers[1].Name).Should(Equal("X-Request-Id"))
+		Ω(show.Headers[1].Required).Should(BeTrue())
+		Ω(show.Headers[1].Location).Should(Equal(ir.LocationHeader))
+	})
+
+	It("resolves the responses", func() {
+		Ω(buildErr).ShouldNot(HaveOccurred())
+		show := api.Resource("bottle").Action("show")
+		Ω(show.Responses).Should(HaveLen(2))
+		notFound := show.Response("NotFound")
+		Ω(notFound.IsSuccess()).Should(BeFalse())
+		Ω(notFound.View).Should(BeNil())
+		ok := show.Response("OK")
+		Ω(ok.IsSuccess()).Should(BeTrue())
+		Ω(ok.MediaType.Identifier).Should(Equal("application/vnd.goa.bottle"))
+		Ω(ok.View.Name).Should(Equal("tiny"))
+		Ω(ok.GoType).Should(Equal("*BottleTiny"))
+		Ω(ok.Headers).Should(HaveLen(1))
+		Ω(ok.Headers[0].Name).Should(Equal("ETag"))
+	})
+
+	It("resolves the payload", func() {
+		Ω(buildErr).ShouldNot(HaveOccurred())
+		create := api.Resource("bottle").Action("create")
+		Ω(create.Payload).ShouldNot(BeNil())
+		Ω(create.Payload.GoType).Should(Equal("*CreateBottlePayload"))
+		Ω(create.Payload.Required).Should(BeTrue())
+		Ω(create.PathParams).Should(HaveLen(1))
+		Ω(create.QueryParams).Should(BeEmpty())
+	})
+
+	Context("with a generator", func() {
+		It("runs the generator with the intermediate representation", func() {
+			g := &generator{}
+			files, err := ir.Run(g, Design)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(files).Should(Equal([]string{"cellar"}))
+		})
+	})
+})
+
+// generator is a test ir.Generator.
+type generator struct{}
+
+func (g *generator) GenerateIR(api *ir.API) ([]string, error) {
+	return []string{api.Name}, nil
+}
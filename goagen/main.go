@@ -63,6 +63,7 @@ package and tool and the Swagger specification for the API.
 	var (
 		pkg    string
 		notest bool
+		hooks  []string
 	)
 	appCmd := &cobra.Command{
 		Use:   "app",
@@ -71,6 +72,7 @@ package and tool and the Swagger specification for the API.
 	}
 	appCmd.Flags().StringVar(&pkg, "pkg", "app", "Name of generated Go package containing controllers supporting code (contexts, media types, user types etc.)")
 	appCmd.Flags().BoolVar(&notest, "notest", false, "Prevent generation of test helpers")
+	appCmd.Flags().StringSliceVar(&hooks, "hooks", nil, "Import `paths` of the packages registering generator hooks (see codegen.RegisterHook)")
 	rootCmd.AddCommand(appCmd)
 
 	// mainCmd implements the "main" command.
@@ -110,6 +112,24 @@ package and tool and the Swagger specification for the API.
 	}
 	rootCmd.AddCommand(swaggerCmd)
 
+	// openapiCmd implements the "openapi" command.
+	openapiCmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Generate OpenAPI 3.0",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("genopenapi", c) },
+	}
+	rootCmd.AddCommand(openapiCmd)
+
+	// protoCmd implements the "proto" command.
+	var protoPkg string
+	protoCmd := &cobra.Command{
+		Use:   "proto",
+		Short: "Generate protocol buffers definitions and gRPC adapters",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("genproto", c) },
+	}
+	protoCmd.Flags().StringVar(&protoPkg, "pkg", "proto", "Name of generated Go package containing the gRPC adapters, must match the go_package of the protoc generated code")
+	rootCmd.AddCommand(protoCmd)
+
 	// jsCmd implements the "js" command.
 	var (
 		timeout      = time.Duration(20) * time.Second
@@ -273,8 +293,18 @@ func runGen(c *cobra.Command, args []string) ([]string, error) {
 
 func generate(pkgName, pkgPath string, c *cobra.Command, args []string) ([]string, error) {
 	m := make(map[string]string)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport(pkgPath)}
 	c.Flags().Visit(func(f *pflag.Flag) {
-		if f.Name != "pkg-path" {
+		switch f.Name {
+		case "pkg-path":
+		case "hooks":
+			// Hook packages register their hooks when initialized, import them in the
+			// generator tool.
+			paths, _ := c.Flags().GetStringSlice(f.Name)
+			for _, p := range paths {
+				imports = append(imports, codegen.NewImport("_", p))
+			}
+		default:
 			m[f.Name] = f.Value.String()
 		}
 	})
@@ -290,7 +320,7 @@ func generate(pkgName, pkgPath string, c *cobra.Command, args []string) ([]strin
 
 	gen, err := meta.NewGenerator(
 		pkgName+".Generate",
-		[]*codegen.ImportSpec{codegen.SimpleImport(pkgPath)},
+		imports,
 		m,
 		args,
 	)
@@ -0,0 +1,257 @@
+package goa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// LivenessPath is the path of the liveness endpoint mounted by ServeHealth.
+	LivenessPath = "/livez"
+
+	// ReadinessPath is the path of the readiness endpoint mounted by ServeHealth.
+	ReadinessPath = "/readyz"
+
+	// HealthOK is the status of passing health checks and reports.
+	HealthOK = "ok"
+
+	// HealthFailing is the status of failing health checks and reports.
+	HealthFailing = "failing"
+)
+
+var (
+	// DefaultHealthCheckTimeout is the default maximum duration of a health check.
+	DefaultHealthCheckTimeout = 5 * time.Second
+
+	// DefaultHealthCheckCacheTTL is the default duration during which the result of a health
+	// check is reused instead of running the check again.
+	DefaultHealthCheckCacheTTL = time.Second
+)
+
+type (
+	// HealthCheckOption configures a health check.
+	HealthCheckOption func(*healthCheck)
+
+	// HealthReport is the JSON document sent by the health endpoints.
+	HealthReport struct {
+		// Status is HealthOK if all the checks pass, HealthFailing otherwise.
+		Status string `json:"status"`
+		// Reason explains why the service is not ready if it is shutting down.
+		Reason string `json:"reason,omitempty"`
+		// Checks lists the result of each health check.
+		Checks []*HealthCheckResult `json:"checks,omitempty"`
+	}
+
+	// HealthCheckResult is the result of a single health check.
+	HealthCheckResult struct {
+		// Name is the health check name.
+		Name string `json:"name"`
+		// Status is HealthOK if the check passed, HealthFailing otherwise.
+		Status string `json:"status"`
+		// Error is the message of the error returned by the check if any.
+		Error string `json:"error,omitempty"`
+		// Duration is the time it took to run the check.
+		Duration string `json:"duration"`
+		// CheckedAt is the time the check ran.
+		CheckedAt time.Time `json:"checked_at"`
+	}
+
+	// healthCheck is a health check registered with AddHealthCheck.
+	healthCheck struct {
+		name     string
+		check    func(context.Context) error
+		timeout  time.Duration
+		ttl      time.Duration
+		liveness bool
+
+		lock sync.Mutex         // Serializes runs and protects last
+		last *HealthCheckResult // Result of the last run
+	}
+)
+
+// HealthCheckTimeout sets the maximum duration of the check, the check context is cancelled and
+// the check fails once it elapses. Defaults to DefaultHealthCheckTimeout.
+func HealthCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.timeout = timeout
+	}
+}
+
+// HealthCheckCacheTTL sets the duration during which the result of the check is reused by the
+// health endpoints, 0 runs the check for every request. Defaults to DefaultHealthCheckCacheTTL.
+func HealthCheckCacheTTL(ttl time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.ttl = ttl
+	}
+}
+
+// HealthCheckLiveness makes the check part of the liveness endpoint in addition to the readiness
+// endpoint. Liveness checks should only fail if the process cannot recover without a restart, for
+// example when it is deadlocked, and should not depend on external services.
+func HealthCheckLiveness() HealthCheckOption {
+	return func(c *healthCheck) {
+		c.liveness = true
+	}
+}
+
+// AddHealthCheck registers a health check with the given name. check returns an error if the
+// service cannot handle requests, for example because its database cannot be reached. The first
+// call mounts the health endpoints, see ServeHealth. The check is registered even if mounting the
+// endpoints fails, for example because the service already mounts LivenessPath, in which case
+// AddHealthCheck returns the error and the report is still available through Health.
+func (service *Service) AddHealthCheck(name string, check func(context.Context) error, opts ...HealthCheckOption) error {
+	c := &healthCheck{
+		name:    name,
+		check:   check,
+		timeout: DefaultHealthCheckTimeout,
+		ttl:     DefaultHealthCheckCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	service.healthLock.Lock()
+	service.healthChecks = append(service.healthChecks, c)
+	service.healthLock.Unlock()
+	return service.ServeHealth()
+}
+
+// ServeHealth creates a "Health" controller that serves the liveness (LivenessPath) and readiness
+// (ReadinessPath) endpoints. The endpoints respond with a HealthReport and status code 200 if all
+// the checks pass, 503 otherwise. The readiness endpoint fails as soon as the service starts
+// shutting down or its context is cancelled (see Shutdown and CancelAll) so that load balancers
+// stop sending it new requests. It is not necessary to call ServeHealth if AddHealthCheck is
+// called, calling it more than once has no effect.
+func (service *Service) ServeHealth() error {
+	service.healthLock.Lock()
+	defer service.healthLock.Unlock()
+	if service.healthMounted {
+		return nil
+	}
+	ctrl := service.NewController("Health")
+	for _, ep := range []struct{ action, path string }{{"livez", LivenessPath}, {"readyz", ReadinessPath}} {
+		action, path, ready := ep.action, ep.path, ep.action == "readyz"
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.serveHealth(req.Context(), rw, ready)
+		}
+		LogInfo(ctrl.Context, "mount health", "route", fmt.Sprintf("GET %s", path))
+		route := &Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: action}
+		if err := service.mount(route, ctrl.MuxHandler(action, handler, nil)); err != nil {
+			return err
+		}
+	}
+	service.healthMounted = true
+	return nil
+}
+
+// Health runs the health checks and returns the corresponding report. It runs all the checks if
+// ready is true and only the liveness checks otherwise.
+func (service *Service) Health(ctx context.Context, ready bool) *HealthReport {
+	report := &HealthReport{Status: HealthOK}
+	if ready {
+		if atomic.LoadInt32(&service.shuttingDown) == 1 {
+			report.Status, report.Reason = HealthFailing, "shutting down"
+			return report
+		}
+		if service.Context.Err() != nil {
+			report.Status, report.Reason = HealthFailing, "cancelled"
+			return report
+		}
+	}
+	service.healthLock.Lock()
+	var checks []*healthCheck
+	for _, c := range service.healthChecks {
+		if ready || c.liveness {
+			checks = append(checks, c)
+		}
+	}
+	service.healthLock.Unlock()
+
+	report.Checks = make([]*HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *healthCheck) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, res := range report.Checks {
+		if res.Status != HealthOK {
+			report.Status = HealthFailing
+		}
+	}
+	return report
+}
+
+// serveHealth writes the health report. ctx is the request context rather than the goa handler
+// context so that the checks still run once the service context is cancelled.
+func (service *Service) serveHealth(ctx context.Context, rw http.ResponseWriter, ready bool) error {
+	report := service.Health(ctx, ready)
+	status := http.StatusOK
+	if report.Status != HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(status)
+	return json.NewEncoder(rw).Encode(report)
+}
+
+// run runs the check unless its last result is still fresh.
+func (c *healthCheck) run(ctx context.Context) *HealthCheckResult {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.ttl {
+		return c.last
+	}
+	start := time.Now()
+	err := c.call(ctx)
+	res := &HealthCheckResult{
+		Name:      c.name,
+		Status:    HealthOK,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status, res.Error = HealthFailing, err.Error()
+	}
+	c.last = res
+	return res
+}
+
+// call calls the check function and returns an error if it does not return before the check
+// timeout.
+func (c *healthCheck) call(ctx context.Context) error {
+	if c.check == nil {
+		return nil
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", c.timeout)
+		}
+		return ctx.Err()
+	}
+}
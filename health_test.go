@@ -0,0 +1,129 @@
+package goa_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health", func() {
+	var s *goa.Service
+	var dbErr error
+	var calls int
+
+	BeforeEach(func() {
+		s = goa.New("test")
+		s.WithLogger(nil)
+		dbErr = nil
+		calls = 0
+	})
+
+	get := func(path string) (int, *goa.HealthReport) {
+		rw := httptest.NewRecorder()
+		s.Mux.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+		var report goa.HealthReport
+		Ω(json.Unmarshal(rw.Body.Bytes(), &report)).Should(Succeed())
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("application/json"))
+		return rw.Code, &report
+	}
+
+	Context("with health checks", func() {
+		BeforeEach(func() {
+			Ω(s.AddHealthCheck("db", func(context.Context) error {
+				calls++
+				return dbErr
+			}, goa.HealthCheckCacheTTL(0))).Should(Succeed())
+			Ω(s.AddHealthCheck("loop", func(context.Context) error { return nil }, goa.HealthCheckLiveness())).Should(Succeed())
+		})
+
+		It("reports the checks status", func() {
+			code, report := get("/readyz")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(report.Status).Should(Equal(goa.HealthOK))
+			Ω(report.Checks).Should(HaveLen(2))
+			Ω(report.Checks[0].Name).Should(Equal("db"))
+			Ω(report.Checks[0].Status).Should(Equal(goa.HealthOK))
+		})
+
+		It("reports failing checks", func() {
+			dbErr = errors.New("connection refused")
+			code, report := get("/readyz")
+			Ω(code).Should(Equal(http.StatusServiceUnavailable))
+			Ω(report.Status).Should(Equal(goa.HealthFailing))
+			Ω(report.Checks[0].Error).Should(Equal("connection refused"))
+		})
+
+		It("only runs the liveness checks on the liveness endpoint", func() {
+			dbErr = errors.New("connection refused")
+			code, report := get("/livez")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(report.Checks).Should(HaveLen(1))
+			Ω(report.Checks[0].Name).Should(Equal("loop"))
+			Ω(calls).Should(Equal(0))
+		})
+
+		It("fails readiness once the service is cancelled", func() {
+			s.CancelAll()
+			code, report := get("/readyz")
+			Ω(code).Should(Equal(http.StatusServiceUnavailable))
+			Ω(report.Reason).Should(Equal("cancelled"))
+			Ω(calls).Should(Equal(0))
+			code, _ = get("/livez")
+			Ω(code).Should(Equal(http.StatusOK))
+		})
+
+		It("fails readiness while the service is shutting down", func() {
+			Ω(s.Shutdown(context.Background())).Should(Succeed())
+			report := s.Health(context.Background(), true)
+			Ω(report.Status).Should(Equal(goa.HealthFailing))
+			Ω(report.Reason).Should(Equal("shutting down"))
+		})
+	})
+
+	It("caches results", func() {
+		Ω(s.AddHealthCheck("db", func(context.Context) error {
+			calls++
+			return nil
+		}, goa.HealthCheckCacheTTL(time.Hour))).Should(Succeed())
+		s.Health(context.Background(), true)
+		s.Health(context.Background(), true)
+		Ω(calls).Should(Equal(1))
+	})
+
+	It("times out slow checks", func() {
+		Ω(s.AddHealthCheck("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}, goa.HealthCheckTimeout(time.Millisecond))).Should(Succeed())
+		report := s.Health(context.Background(), true)
+		Ω(report.Status).Should(Equal(goa.HealthFailing))
+		Ω(report.Checks[0].Error).Should(Equal("timed out after 1ms"))
+	})
+
+	It("mounts the endpoints without checks", func() {
+		Ω(s.ServeHealth()).Should(Succeed())
+		Ω(s.ServeHealth()).Should(Succeed())
+		code, report := get("/readyz")
+		Ω(code).Should(Equal(http.StatusOK))
+		Ω(report.Checks).Should(BeEmpty())
+	})
+
+	It("returns an error if the endpoints conflict with existing routes", func() {
+		route := &goa.Route{Method: "GET", Path: goa.LivenessPath, Controller: "Ops", Action: "live"}
+		Ω(s.Mux.(goa.RouteMux).HandleRoute(route, func(http.ResponseWriter, *http.Request, url.Values) {})).Should(Succeed())
+		err := s.AddHealthCheck("db", func(context.Context) error { return nil })
+		Ω(err).Should(HaveOccurred())
+		report := s.Health(context.Background(), true)
+		Ω(report.Checks).Should(HaveLen(1))
+		Ω(report.Checks[0].Name).Should(Equal("db"))
+	})
+})
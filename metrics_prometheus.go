@@ -0,0 +1,328 @@
+package goa
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prometheus metric types.
+const (
+	promCounter   = "counter"
+	promGauge     = "gauge"
+	promHistogram = "histogram"
+)
+
+// DefaultBuckets are the histogram buckets used by PrometheusCollector, they are tailored to
+// measure HTTP request durations in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	// PrometheusCollector is a Collector that keeps metrics in memory and exposes them in the
+	// Prometheus text exposition format. Contrary to the other collectors it supports labeled
+	// counters, gauges and histograms via the Add, Set and Observe methods.
+	// PrometheusCollector implements http.Handler, see Service ServeMetrics to mount it.
+	//
+	// The Collector methods join the keys with underscores to produce the metric names:
+	// IncrCounter produces counters, SetGauge and EmitKey produce gauges and AddSample and
+	// MeasureSince produce histograms. MeasureSince records durations in seconds.
+	//
+	// A metric name is bound to the type of the first value recorded with it, values recorded
+	// under the same name with a different type are discarded.
+	PrometheusCollector struct {
+		// Namespace is prepended to all metric names if not empty.
+		Namespace string
+		// Buckets are the upper bounds of the histogram buckets. Use SetBuckets to
+		// override the buckets of a specific histogram.
+		Buckets []float64
+
+		lock     sync.Mutex
+		families map[string]*promFamily
+		helps    map[string]string
+		buckets  map[string][]float64
+	}
+
+	// promFamily groups the series of a metric.
+	promFamily struct {
+		name    string
+		typ     string
+		buckets []float64
+		series  map[string]*promSeries
+	}
+
+	// promSeries holds the value(s) of a metric for a given set of labels.
+	promSeries struct {
+		labels string // Rendered labels, e.g. `action="show",controller="bottle"`
+		value  float64
+		counts []uint64 // Histogram bucket counts, not cumulative
+		count  uint64
+		sum    float64
+	}
+)
+
+// NewPrometheusCollector returns a collector that prefixes all metric names with the given
+// namespace.
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		Namespace: namespace,
+		Buckets:   DefaultBuckets,
+		families:  make(map[string]*promFamily),
+		helps:     make(map[string]string),
+		buckets:   make(map[string][]float64),
+	}
+}
+
+// Help sets the help text exposed with the given metric.
+func (c *PrometheusCollector) Help(name, help string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.helps == nil {
+		c.helps = make(map[string]string)
+	}
+	c.helps[c.metricName(name)] = help
+}
+
+// SetBuckets sets the upper bounds of the buckets used by the given histogram. It must be
+// called before any value is observed.
+func (c *PrometheusCollector) SetBuckets(name string, buckets []float64) {
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.buckets == nil {
+		c.buckets = make(map[string][]float64)
+	}
+	c.buckets[c.metricName(name)] = sorted
+}
+
+// Add adds val to the counter with the given name and labels.
+func (c *PrometheusCollector) Add(name string, val float64, labels map[string]string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if s := c.series(name, promCounter, labels); s != nil {
+		s.value += val
+	}
+}
+
+// Set sets the gauge with the given name and labels.
+func (c *PrometheusCollector) Set(name string, val float64, labels map[string]string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if s := c.series(name, promGauge, labels); s != nil {
+		s.value = val
+	}
+}
+
+// Observe records val in the histogram with the given name and labels.
+func (c *PrometheusCollector) Observe(name string, val float64, labels map[string]string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s := c.series(name, promHistogram, labels)
+	if s == nil {
+		return
+	}
+	f := c.families[c.metricName(name)]
+	i := sort.SearchFloat64s(f.buckets, val)
+	if i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += val
+}
+
+// AddSample records val in the histogram named after key.
+func (c *PrometheusCollector) AddSample(key []string, val float32) {
+	c.Observe(joinKey(key), float64(val), nil)
+}
+
+// EmitKey sets the gauge named after key.
+func (c *PrometheusCollector) EmitKey(key []string, val float32) {
+	c.Set(joinKey(key), float64(val), nil)
+}
+
+// IncrCounter adds val to the counter named after key.
+func (c *PrometheusCollector) IncrCounter(key []string, val float32) {
+	c.Add(joinKey(key), float64(val), nil)
+}
+
+// MeasureSince records the number of seconds elapsed since start in the histogram named
+// after key.
+func (c *PrometheusCollector) MeasureSince(key []string, start time.Time) {
+	c.Observe(joinKey(key), time.Since(start).Seconds(), nil)
+}
+
+// SetGauge sets the gauge named after key.
+func (c *PrometheusCollector) SetGauge(key []string, val float32) {
+	c.Set(joinKey(key), float64(val), nil)
+}
+
+// ServeHTTP writes the metrics using the Prometheus text exposition format version 0.0.4.
+func (c *PrometheusCollector) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w := bufio.NewWriter(rw)
+	c.write(w)
+	w.Flush()
+}
+
+// write renders all the metrics sorted by name then labels.
+func (c *PrometheusCollector) write(w *bufio.Writer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	names := make([]string, 0, len(c.families))
+	for n := range c.families {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		f := c.families[n]
+		if help, ok := c.helps[n]; ok {
+			fmt.Fprintf(w, "# HELP %s %s\n", n, helpEscaper.Replace(help))
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", n, f.typ)
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s := f.series[k]
+			if f.typ != promHistogram {
+				fmt.Fprintf(w, "%s%s %s\n", n, braces(s.labels), formatFloat(s.value))
+				continue
+			}
+			var cumul uint64
+			for i, b := range f.buckets {
+				cumul += s.counts[i]
+				le := `le="` + formatFloat(b) + `"`
+				fmt.Fprintf(w, "%s_bucket%s %d\n", n, braces(joinLabels(s.labels, le)), cumul)
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", n, braces(joinLabels(s.labels, `le="+Inf"`)), s.count)
+			fmt.Fprintf(w, "%s_sum%s %s\n", n, braces(s.labels), formatFloat(s.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", n, braces(s.labels), s.count)
+		}
+	}
+}
+
+// series returns the series with the given metric name and labels, creating it if needed.
+// It returns nil if the metric exists with a different type. The caller must hold the lock.
+func (c *PrometheusCollector) series(name, typ string, labels map[string]string) *promSeries {
+	if c.families == nil {
+		c.families = make(map[string]*promFamily)
+	}
+	name = c.metricName(name)
+	f, ok := c.families[name]
+	if !ok {
+		f = &promFamily{name: name, typ: typ, series: make(map[string]*promSeries)}
+		if typ == promHistogram {
+			f.buckets = c.buckets[name]
+			if f.buckets == nil {
+				f.buckets = c.Buckets
+			}
+			if f.buckets == nil {
+				f.buckets = DefaultBuckets
+			}
+		}
+		c.families[name] = f
+	}
+	if f.typ != typ {
+		return nil
+	}
+	key := renderLabels(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &promSeries{labels: key}
+		if typ == promHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// metricName returns the sanitized name prefixed with the collector namespace.
+func (c *PrometheusCollector) metricName(name string) string {
+	if c.Namespace != "" {
+		name = c.Namespace + "_" + name
+	}
+	return sanitizeMetricName(name)
+}
+
+// joinKey produces a metric name from a Collector key.
+func joinKey(key []string) string {
+	return strings.Join(key, "_")
+}
+
+// sanitizeMetricName replaces the characters that are not valid in Prometheus metric and label
+// names with underscores. "*/*" is replaced with "all" for consistency with normalizeKeys.
+func sanitizeMetricName(name string) string {
+	b := []byte(strings.Replace(name, "*/*", "all", -1))
+	for i, r := range b {
+		valid := r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(i > 0 && r >= '0' && r <= '9')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// renderLabels renders the labels sorted by name.
+func renderLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for n := range labels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, n := range names {
+		pairs[i] = sanitizeMetricName(n) + `="` + labelEscaper.Replace(labels[n]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+// joinLabels appends the rendered label pair l to labels.
+func joinLabels(labels, l string) string {
+	if labels == "" {
+		return l
+	}
+	return labels + "," + l
+}
+
+// braces wraps non-empty rendered labels in curly braces.
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// formatFloat formats a sample value as defined by the exposition format.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	// labelEscaper escapes label values.
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+	// helpEscaper escapes help texts.
+	helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
@@ -0,0 +1,105 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrometheusCollector", func() {
+	var c *goa.PrometheusCollector
+	var out string
+
+	BeforeEach(func() {
+		c = goa.NewPrometheusCollector("ns")
+	})
+
+	JustBeforeEach(func() {
+		rw := httptest.NewRecorder()
+		c.ServeHTTP(rw, nil)
+		Ω(rw.Header().Get("Content-Type")).Should(HavePrefix("text/plain; version=0.0.4"))
+		out = rw.Body.String()
+	})
+
+	Context("with labeled counters", func() {
+		BeforeEach(func() {
+			c.Help("hits", "Number of hits.\nReally.")
+			c.Add("hits", 1, map[string]string{"b": "2", "a": `"quoted"`})
+			c.Add("hits", 2, map[string]string{"b": "2", "a": `"quoted"`})
+			c.Add("hits", 1, nil)
+		})
+
+		It("renders the counters", func() {
+			Ω(out).Should(Equal(`# HELP ns_hits Number of hits.\nReally.
+# TYPE ns_hits counter
+ns_hits 1
+ns_hits{a="\"quoted\"",b="2"} 3
+`))
+		})
+	})
+
+	Context("with a histogram", func() {
+		BeforeEach(func() {
+			c.SetBuckets("latency", []float64{1, 0.1})
+			c.Observe("latency", 0.05, map[string]string{"op": "get"})
+			c.Observe("latency", 0.5, map[string]string{"op": "get"})
+			c.Observe("latency", 3, map[string]string{"op": "get"})
+		})
+
+		It("renders cumulative buckets", func() {
+			Ω(out).Should(Equal(`# TYPE ns_latency histogram
+ns_latency_bucket{op="get",le="0.1"} 1
+ns_latency_bucket{op="get",le="1"} 2
+ns_latency_bucket{op="get",le="+Inf"} 3
+ns_latency_sum{op="get"} 3.55
+ns_latency_count{op="get"} 3
+`))
+		})
+	})
+
+	Context("used as a Collector", func() {
+		BeforeEach(func() {
+			goa.SetMetrics(c)
+			goa.IncrCounter([]string{"goa", "*/*", "requests"}, 1)
+			goa.SetGauge([]string{"goa", "conns"}, 4)
+			goa.MeasureSince([]string{"goa", "duration"}, time.Now())
+			c.Set("goa_conns", 5, nil)
+			c.Add("goa_conns", 1, nil)
+		})
+
+		AfterEach(func() {
+			goa.SetMetrics(goa.NewNoOpCollector())
+		})
+
+		It("normalizes the keys", func() {
+			Ω(out).Should(ContainSubstring("ns_goa_all_requests 1\n"))
+			Ω(out).Should(ContainSubstring("ns_goa_duration_count 1\n"))
+		})
+
+		It("ignores values of a different type", func() {
+			Ω(out).Should(ContainSubstring("# TYPE ns_goa_conns gauge\nns_goa_conns 5\n"))
+		})
+	})
+
+	Context("mounted on a service", func() {
+		var rw *httptest.ResponseRecorder
+
+		BeforeEach(func() {
+			c.Add("hits", 1, nil)
+			s := goa.New("test")
+			Ω(s.ServeMetrics("/metrics", c)).ShouldNot(HaveOccurred())
+			rw = httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/metrics", nil)
+			s.Mux.ServeHTTP(rw, req)
+		})
+
+		It("serves the metrics", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(ContainSubstring("ns_hits 1\n"))
+		})
+	})
+})
@@ -19,7 +19,10 @@ type (
 //
 // - a goa handler: goa.Handler or func(context.Context, http.ResponseWriter, *http.Request) error
 //
-// - an http middleware: func(http.Handler) http.Handler
+// - an http middleware: func(http.Handler) http.Handler. The context of the request given to the
+// next handler by the http middleware is merged into the goa request context and the request
+// replaces the goa request data request so that values and rewrites made by the http middleware
+// are visible to the goa handlers.
 //
 // - or an http handler: http.Handler or func(http.ResponseWriter, *http.Request)
 //
@@ -35,14 +38,7 @@ func NewMiddleware(m interface{}) (mw Middleware, err error) {
 	case func(context.Context, http.ResponseWriter, *http.Request) error:
 		mw = handlerToMiddleware(m)
 	case func(http.Handler) http.Handler:
-		mw = func(h Handler) Handler {
-			return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
-				m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					err = h(ctx, w, r)
-				})).ServeHTTP(rw, req)
-				return
-			}
-		}
+		mw = httpMiddlewareToMiddleware(m)
 	case http.Handler:
 		mw = httpHandlerToMiddleware(m.ServeHTTP)
 	case func(http.ResponseWriter, *http.Request):
@@ -78,3 +74,106 @@ func httpHandlerToMiddleware(m http.HandlerFunc) Middleware {
 		}
 	}
 }
+
+// httpMiddlewareToMiddleware creates a middleware from a http middleware.
+// The next goa handler is called with the request given by the http middleware and a context that
+// merges the context of that request with the goa request context.
+func httpMiddlewareToMiddleware(m func(http.Handler) http.Handler) Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
+			m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hctx := ctx
+				if rctx := r.Context(); rctx != req.Context() {
+					var cancel context.CancelFunc
+					hctx, cancel = mergeContext(ctx, rctx)
+					defer cancel()
+				}
+				if r != req {
+					if reqData := ContextRequest(hctx); reqData != nil {
+						reqData.Request = r
+					}
+				}
+				err = h(hctx, w, r)
+			})).ServeHTTP(rw, req)
+			return
+		}
+	}
+}
+
+// HTTPMiddleware exposes a goa middleware as a http middleware so that it may be used with
+// net/http compatible routers. If the request context is not already a goa request context (i.e.
+// the http middleware is not itself used in a goa service) then a goa request context is created
+// from it with NewContext. The next http handler is called with the request and context given by
+// the goa middleware. The errors returned by the goa middleware are written in the response unless
+// the response was already written. service is used to encode the error responses, the errors are
+// written as plain text if it is nil.
+func HTTPMiddleware(service *Service, m Middleware) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		h := m(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			next.ServeHTTP(rw, req.WithContext(ctx))
+			return nil
+		})
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if ContextRequest(ctx) == nil {
+				ctx = NewContext(ctx, rw, req, req.URL.Query())
+				ContextResponse(ctx).Service = service
+				rw = ContextResponse(ctx)
+			}
+			err := h(ctx, rw, req)
+			if err == nil {
+				return
+			}
+			if resp := ContextResponse(ctx); resp != nil && resp.Written() {
+				return
+			}
+			status := http.StatusInternalServerError
+			var body interface{} = err.Error()
+			if e, ok := err.(ServiceError); ok {
+				status = e.ResponseStatus()
+				body = e
+			}
+			if service == nil {
+				http.Error(rw, err.Error(), status)
+				return
+			}
+			if _, ok := body.(ServiceError); ok {
+				rw.Header().Set("Content-Type", service.ErrorContentType())
+			}
+			service.Send(ctx, status, body)
+		})
+	}
+}
+
+// mergedContext is a context whose values are looked up in the embedded context first and in the
+// parent context next.
+type mergedContext struct {
+	context.Context
+	parent context.Context
+}
+
+// mergeContext returns a context that contains the values of both ctx and the given request
+// context, the values of the request context take precedence. The returned context deadline is
+// the request context deadline, it is canceled when either context is done. The returned cancel
+// function must be called to release the associated resources.
+func mergeContext(ctx, reqCtx context.Context) (context.Context, context.CancelFunc) {
+	merged, cancel := context.WithCancel(&mergedContext{Context: reqCtx, parent: ctx})
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-merged.Done():
+			}
+		}()
+	}
+	return merged, cancel
+}
+
+// Value returns the value associated with key in the embedded context or in the parent context.
+func (c *mergedContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.parent.Value(key)
+}
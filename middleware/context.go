@@ -12,4 +12,6 @@ const (
 	traceKey
 	spanKey
 	parentSpanKey
+	traceSampledKey
+	traceStateKey
 )
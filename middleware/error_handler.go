@@ -13,6 +13,8 @@ import (
 // below the logger middleware so the logger properly logs the HTTP response. ErrorHandler
 // understands instances of goa.ServiceError and returns the status and response body embodied in
 // them, it turns other Go error types into a 500 internal error response.
+// Errors are rendered as RFC 7807 problem details documents if the service ProblemDetails field
+// is true.
 // If verbose is false the details of internal errors is not included in HTTP responses.
 // If you use github.com/pkg/errors then wrapping the error will allow a trace to be printed to the logs
 func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
@@ -29,7 +31,7 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 				status = err.ResponseStatus()
 				respBody = err
 				goa.ContextResponse(ctx).ErrorCode = err.Token()
-				rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
+				rw.Header().Set("Content-Type", service.ErrorContentType())
 			} else {
 				respBody = e.Error()
 				rw.Header().Set("Content-Type", "text/plain")
@@ -42,7 +44,7 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 				}
 				goa.LogError(ctx, "uncaught error", "err", fmt.Sprintf("%+v", e), "id", reqID, "msg", respBody)
 				if !verbose {
-					rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
+					rw.Header().Set("Content-Type", service.ErrorContentType())
 					msg := fmt.Sprintf("%s [%s]", http.StatusText(http.StatusInternalServerError), reqID)
 					respBody = goa.ErrInternal(msg)
 					// Preserve the ID of the original error as that's what gets logged, the client
@@ -163,6 +163,42 @@ var _ = Describe("ErrorHandler", func() {
 		})
 	})
 
+	Context("with a service rendering problem details", func() {
+		var gerr error
+
+		BeforeEach(func() {
+			service = newService(nil)
+			service.ProblemDetails = true
+			gerr = goa.NewErrorClass("code", 418)("teapot", "foobar", 42)
+			h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return gerr
+			}
+		})
+
+		It("renders RFC 7807 documents", func() {
+			var decoded map[string]interface{}
+			Ω(rw.Status).Should(Equal(418))
+			Ω(rw.ParentHeader["Content-Type"]).Should(Equal([]string{goa.ProblemMediaIdentifier}))
+			err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(decoded).Should(Equal(map[string]interface{}{
+				"type":   "code",
+				"title":  "I'm a teapot",
+				"status": 418.0,
+				"detail": "teapot",
+				"id":     gerr.(goa.ServiceError).Token(),
+				"foobar": 42.0,
+			}))
+		})
+
+		It("can be decoded into a goa error", func() {
+			var decoded goa.ErrorResponse
+			err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), goa.ProblemMediaIdentifier)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(decoded.Error()).Should(Equal(gerr.Error()))
+		})
+	})
+
 	Context("with a handler returning a pkg errors wrapped error", func() {
 		var wrappedError error
 		var logger *testLogger
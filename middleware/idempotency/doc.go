@@ -0,0 +1,26 @@
+/*
+Package idempotency provides a middleware that makes non-idempotent actions safe to retry.
+
+Clients send a unique value in the Idempotency-Key request header, typically a UUID generated
+once per logical operation and reused on retries. The middleware records the status, headers and
+body of the response sent to the first request made with a key and replays it to the retries
+instead of running the action again. Retries sent while the first request is still being processed
+are rejected with goa.ErrConflict (HTTP status 409).
+
+The responses are kept in memory by default, services running multiple instances should provide a
+Store backed by a shared database.
+
+	service.Use(idempotency.New(idempotency.WithTTL(time.Hour)))
+
+Only the actions that define the "idempotency" design metadata key are handled by the middleware.
+The optional value overrides how long the responses are kept:
+
+	Action("create", func() {
+		Metadata("idempotency", "48h")
+		...
+	})
+
+The clients generated by goagen send a new key with each request made to these actions unless
+the context includes one, see goaclient.SetContextIdempotencyKey.
+*/
+package idempotency
@@ -0,0 +1,224 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+const (
+	// MetadataKey is the design metadata key used to opt actions in.
+	MetadataKey = "idempotency"
+
+	// HeaderKey is the name of the request header that holds the idempotency key.
+	HeaderKey = "Idempotency-Key"
+
+	// HeaderReplayed is the name of the header set on replayed responses.
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+type (
+	// Option configures the middleware.
+	Option func(*options)
+
+	// options holds the middleware configuration.
+	options struct {
+		store   Store
+		ttl     time.Duration
+		maxBody int
+	}
+
+	// recorder is the response writer that records the response of the first request sent
+	// with a given idempotency key.
+	recorder struct {
+		http.ResponseWriter
+		status   int
+		header   http.Header
+		body     []byte
+		max      int
+		overflow bool
+	}
+)
+
+// WithStore sets the store used to record the responses, defaults to a LRUStore holding 10,000
+// responses.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithTTL sets how long the responses are kept by default, defaults to 24 hours. Actions may
+// override the value with the metadata.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithMaxBodyLength sets the maximum length of the response bodies recorded by the middleware,
+// defaults to 1MB. Requests whose responses are larger are processed again when retried.
+func WithMaxBodyLength(n int) Option {
+	return func(o *options) {
+		o.maxBody = n
+	}
+}
+
+// New returns a middleware that makes the actions that define the "idempotency" design metadata
+// key safe to retry. The first request sent with a given Idempotency-Key header value is
+// processed normally and its response is recorded. Retries sent with the same key get the
+// recorded response with the Idempotent-Replayed header set. Requests sent while the first
+// request is being processed are rejected with goa.ErrConflict and requests that reuse a key with
+// a different method, URL or payload are rejected with goa.ErrInvalidRequest.
+//
+// Responses are only recorded if the action succeeds: actions that return an error or send a 5xx
+// response may be retried with the same key. Requests that do not include the header and
+// requests made to actions that do not define the metadata are let through. The store errors are
+// logged and the corresponding requests are let through.
+func New(opts ...Option) goa.Middleware {
+	o := options{ttl: 24 * time.Hour, maxBody: 1 << 20}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.store == nil {
+		o.store = NewLRUStore(10000)
+	}
+	var routeTTLs sync.Map // *goa.Route -> time.Duration, 0 if the route does not use keys
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			route := goa.ContextRoute(ctx)
+			resp := goa.ContextResponse(ctx)
+			if route == nil || resp == nil {
+				return h(ctx, rw, req)
+			}
+			ttl, ok := routeTTLs.Load(route)
+			if !ok {
+				ttl = routeTTL(ctx, route, o.ttl)
+				routeTTLs.Store(route, ttl)
+			}
+			key := req.Header.Get(HeaderKey)
+			if ttl.(time.Duration) == 0 || key == "" {
+				return h(ctx, rw, req)
+			}
+			key = route.Controller + "#" + route.Action + ":" + key
+			fp := fingerprint(ctx, req)
+			rec, err := o.store.Lock(ctx, key, fp, ttl.(time.Duration))
+			if err != nil {
+				goa.LogError(ctx, "idempotency", "err", err)
+				return h(ctx, rw, req)
+			}
+			if rec != nil {
+				if rec.Fingerprint != fp {
+					return goa.ErrInvalidRequest("idempotency key already used by a different request", "header", HeaderKey)
+				}
+				if rec.Response == nil {
+					return goa.ErrConflict("a request with the same idempotency key is being processed", "header", HeaderKey)
+				}
+				return replay(rw, rec.Response)
+			}
+
+			w := &recorder{ResponseWriter: resp.SwitchWriter(nil), max: o.maxBody}
+			resp.SwitchWriter(w)
+			saved := false
+			defer func() {
+				resp.SwitchWriter(w.ResponseWriter)
+				if !saved {
+					if err := o.store.Unlock(ctx, key); err != nil {
+						goa.LogError(ctx, "idempotency", "err", err)
+					}
+				}
+			}()
+			if err := h(ctx, rw, req); err != nil {
+				return err
+			}
+			if w.status == 0 || w.status >= 500 || w.overflow {
+				return nil
+			}
+			r := &Response{Status: w.status, Header: w.header, Body: w.body}
+			if err := o.store.Save(ctx, key, r); err != nil {
+				goa.LogError(ctx, "idempotency", "err", err)
+				return nil
+			}
+			saved = true
+			return nil
+		}
+	}
+}
+
+// WriteHeader records the status code and a copy of the headers.
+func (w *recorder) WriteHeader(status int) {
+	w.status = status
+	w.header = make(http.Header, len(w.Header()))
+	for k, v := range w.Header() {
+		w.header[k] = append([]string(nil), v...)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body unless it exceeds the maximum length.
+func (w *recorder) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if len(w.body)+len(b) > w.max {
+			w.overflow = true
+			w.body = nil
+		} else {
+			w.body = append(w.body, b...)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying writer if it supports it.
+func (w *recorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// replay writes the recorded response.
+func replay(rw http.ResponseWriter, resp *Response) error {
+	header := rw.Header()
+	for k, v := range resp.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set(HeaderReplayed, "true")
+	rw.WriteHeader(resp.Status)
+	_, err := rw.Write(resp.Body)
+	return err
+}
+
+// fingerprint computes a hash of the request method, URL and decoded payload.
+func fingerprint(ctx context.Context, req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	if r := goa.ContextRequest(ctx); r != nil && r.Payload != nil {
+		if b, err := json.Marshal(r.Payload); err == nil {
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// routeTTL returns the TTL defined in the route metadata, def if the metadata does not define one
+// and 0 if the route does not define the metadata.
+func routeTTL(ctx context.Context, route *goa.Route, def time.Duration) time.Duration {
+	vals, ok := route.Metadata[MetadataKey]
+	if !ok {
+		return 0
+	}
+	if len(vals) == 0 || vals[0] == "" {
+		return def
+	}
+	ttl, err := time.ParseDuration(vals[0])
+	if err != nil || ttl <= 0 {
+		goa.LogError(ctx, "invalid idempotency metadata", "route", route.String(), "value", vals[0])
+		return def
+	}
+	return ttl
+}
@@ -0,0 +1,182 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/idempotency"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("New", func() {
+	var route *goa.Route
+	var key string
+	var payload interface{}
+	var status int
+	var handlerErr error
+	var rw *httptest.ResponseRecorder
+	var called int
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		route = &goa.Route{Controller: "bottle", Action: "create", Metadata: map[string][]string{"idempotency": nil}}
+		key = "abc"
+		payload = map[string]string{"name": "merlot"}
+		status = http.StatusCreated
+		handlerErr = nil
+		called = 0
+	})
+
+	serve := func() error {
+		rw = httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/bottles", nil)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		req = req.WithContext(goa.WithRoute(req.Context(), route))
+		ctx := goa.NewContext(nil, rw, req, nil)
+		goa.ContextRequest(ctx).Payload = payload
+		return handler(ctx, goa.ContextResponse(ctx), req)
+	}
+
+	JustBeforeEach(func() {
+		handler = idempotency.New()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called++
+			if handlerErr != nil {
+				return handlerErr
+			}
+			rw.Header().Set("Location", fmt.Sprintf("/bottles/%d", called))
+			rw.WriteHeader(status)
+			_, err := rw.Write([]byte(fmt.Sprintf("bottle %d", called)))
+			return err
+		})
+	})
+
+	It("replays the recorded response to retries", func() {
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(rw.Code).Should(Equal(http.StatusCreated))
+		Ω(rw.Header().Get("Idempotent-Replayed")).Should(BeEmpty())
+
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(called).Should(Equal(1))
+		Ω(rw.Code).Should(Equal(http.StatusCreated))
+		Ω(rw.Header().Get("Location")).Should(Equal("/bottles/1"))
+		Ω(rw.Header().Get("Idempotent-Replayed")).Should(Equal("true"))
+		Ω(rw.Body.String()).Should(Equal("bottle 1"))
+	})
+
+	It("rejects keys reused with a different payload", func() {
+		Ω(serve()).ShouldNot(HaveOccurred())
+		payload = map[string]string{"name": "syrah"}
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusBadRequest))
+		Ω(called).Should(Equal(1))
+	})
+
+	It("rejects concurrent requests with the same key", func() {
+		var concurrentErr error
+		handler = idempotency.New()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called++
+			if called == 1 {
+				concurrentErr = serve()
+			}
+			return nil
+		})
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(concurrentErr).Should(HaveOccurred())
+		Ω(concurrentErr.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusConflict))
+	})
+
+	Context("with a failing action", func() {
+		BeforeEach(func() {
+			handlerErr = errors.New("boom")
+		})
+
+		It("lets retries through", func() {
+			Ω(serve()).Should(HaveOccurred())
+			handlerErr = nil
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(called).Should(Equal(2))
+			Ω(rw.Header().Get("Idempotent-Replayed")).Should(BeEmpty())
+		})
+	})
+
+	Context("with a server error response", func() {
+		BeforeEach(func() {
+			status = http.StatusServiceUnavailable
+		})
+
+		It("does not record the response", func() {
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(called).Should(Equal(2))
+		})
+	})
+
+	Context("with no idempotency key", func() {
+		BeforeEach(func() {
+			key = ""
+		})
+
+		It("lets requests through", func() {
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(called).Should(Equal(2))
+		})
+	})
+
+	Context("with a route that does not define the metadata", func() {
+		BeforeEach(func() {
+			route = &goa.Route{Controller: "bottle", Action: "create"}
+		})
+
+		It("lets requests through", func() {
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(serve()).ShouldNot(HaveOccurred())
+			Ω(called).Should(Equal(2))
+		})
+	})
+})
+
+var _ = Describe("LRUStore", func() {
+	var store *idempotency.LRUStore
+	var ctx = context.Background()
+
+	BeforeEach(func() {
+		store = idempotency.NewLRUStore(2)
+	})
+
+	It("evicts the least recently used records", func() {
+		Ω(store.Lock(ctx, "a", "fa", time.Hour)).Should(BeNil())
+		Ω(store.Lock(ctx, "b", "fb", time.Hour)).Should(BeNil())
+		Ω(store.Lock(ctx, "a", "fa", time.Hour)).ShouldNot(BeNil())
+		Ω(store.Lock(ctx, "c", "fc", time.Hour)).Should(BeNil())
+		Ω(store.Len()).Should(Equal(2))
+		Ω(store.Lock(ctx, "a", "fa", time.Hour)).ShouldNot(BeNil())
+		Ω(store.Lock(ctx, "b", "fb", time.Hour)).Should(BeNil())
+	})
+
+	It("expires records", func() {
+		Ω(store.Lock(ctx, "a", "fa", time.Millisecond)).Should(BeNil())
+		time.Sleep(2 * time.Millisecond)
+		Ω(store.Lock(ctx, "a", "fa", time.Hour)).Should(BeNil())
+	})
+
+	It("saves and unlocks records", func() {
+		Ω(store.Lock(ctx, "a", "fa", time.Hour)).Should(BeNil())
+		resp := &idempotency.Response{Status: 201}
+		Ω(store.Save(ctx, "a", resp)).Should(Succeed())
+		rec, err := store.Lock(ctx, "a", "fa", time.Hour)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rec.Response).Should(Equal(resp))
+		Ω(store.Unlock(ctx, "a")).Should(Succeed())
+		Ω(store.Len()).Should(Equal(0))
+	})
+})
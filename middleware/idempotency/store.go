@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Response is a response recorded by the middleware.
+	Response struct {
+		// Status is the response status code.
+		Status int
+		// Header contains the response headers.
+		Header http.Header
+		// Body is the response body.
+		Body []byte
+	}
+
+	// Record describes the state of the requests sent with a given idempotency key.
+	Record struct {
+		// Fingerprint identifies the request that first used the key.
+		Fingerprint string
+		// Response is the recorded response, nil while the first request is being
+		// processed.
+		Response *Response
+	}
+
+	// Store is the interface implemented by the response stores. Implementations must be safe
+	// for concurrent use.
+	Store interface {
+		// Lock creates a record for key with the given fingerprint and no response if
+		// there is none and returns nil. It returns the existing record otherwise. The
+		// record expires after ttl.
+		Lock(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+		// Save records the response of the request that locked key.
+		Save(ctx context.Context, key string, resp *Response) error
+		// Unlock deletes the record of key so that the request may be retried, it is
+		// called when the request fails.
+		Unlock(ctx context.Context, key string) error
+	}
+
+	// LRUStore is a Store that keeps the records in memory. The least recently used records
+	// are evicted once the store holds its maximum number of records.
+	LRUStore struct {
+		size    int
+		lock    sync.Mutex
+		entries map[string]*list.Element
+		order   *list.List
+	}
+
+	// entry is a LRUStore record.
+	entry struct {
+		key     string
+		record  Record
+		expires time.Time
+	}
+)
+
+// NewLRUStore returns an in-memory store that holds at most size records.
+func NewLRUStore(size int) *LRUStore {
+	return &LRUStore{size: size, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// Lock creates a record for key if there is none or if it expired.
+func (s *LRUStore) Lock(_ context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*entry)
+		if now.Before(e.expires) {
+			s.order.MoveToFront(el)
+			rec := e.record
+			return &rec, nil
+		}
+		s.remove(el)
+	}
+	e := &entry{key: key, record: Record{Fingerprint: fingerprint}, expires: now.Add(ttl)}
+	s.entries[key] = s.order.PushFront(e)
+	for s.size > 0 && s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+	return nil, nil
+}
+
+// Save records the response of the request that locked key. It does nothing if the record was
+// evicted in the meantime.
+func (s *LRUStore) Save(_ context.Context, key string, resp *Response) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*entry).record.Response = resp
+		s.order.MoveToFront(el)
+	}
+	return nil
+}
+
+// Unlock deletes the record of key.
+func (s *LRUStore) Unlock(_ context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// Len returns the number of records held by the store.
+func (s *LRUStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.order.Len()
+}
+
+// remove deletes the given element, the store lock must be held.
+func (s *LRUStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*entry).key)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"context"
+)
+
+const (
+	// RequestsMetric is the name of the counter that records the number of requests.
+	RequestsMetric = "http_requests_total"
+
+	// RequestErrorsMetric is the name of the counter that records the number of requests that
+	// resulted in a server error (5xx status).
+	RequestErrorsMetric = "http_request_errors_total"
+
+	// RequestDurationMetric is the name of the histogram that records request durations in
+	// seconds.
+	RequestDurationMetric = "http_request_duration_seconds"
+)
+
+// Metrics returns a middleware that records the request rate, errors and duration (RED) in the
+// given collector. The metrics are labeled with the names of the controller and action that
+// handled the request and with the response status. Mount the middleware before ErrorHandler
+// so that it records the status of error responses, for example:
+//
+//	collector := goa.NewPrometheusCollector("cellar")
+//	service.Use(middleware.Metrics(collector))
+//	service.Use(middleware.ErrorHandler(service, true))
+//	service.ServeMetrics("/metrics", collector)
+func Metrics(collector *goa.PrometheusCollector) goa.Middleware {
+	collector.Help(RequestsMetric, "Number of HTTP requests by controller, action and status.")
+	collector.Help(RequestErrorsMetric, "Number of HTTP requests that resulted in a server error.")
+	collector.Help(RequestDurationMetric, "Duration of HTTP requests in seconds.")
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			started := time.Now()
+			err := h(ctx, rw, req)
+			status := goa.ContextResponse(ctx).Status
+			if status == 0 {
+				status = http.StatusOK
+				if err != nil {
+					status = http.StatusInternalServerError
+					if gerr, ok := err.(goa.ServiceError); ok {
+						status = gerr.ResponseStatus()
+					}
+				}
+			}
+			labels := map[string]string{
+				"controller": goa.ContextController(ctx),
+				"action":     goa.ContextAction(ctx),
+				"status":     strconv.Itoa(status),
+			}
+			collector.Add(RequestsMetric, 1, labels)
+			if status >= 500 {
+				collector.Add(RequestErrorsMetric, 1, labels)
+			}
+			collector.Observe(RequestDurationMetric, time.Since(started).Seconds(), labels)
+			return err
+		}
+	}
+}
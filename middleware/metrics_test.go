@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		collector *goa.PrometheusCollector
+		service   *goa.Service
+		ctx       context.Context
+		rw        *testResponseWriter
+		req       *http.Request
+		handler   goa.Handler
+	)
+
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		collector.ServeHTTP(w, nil)
+		return w.Body.String()
+	}
+
+	BeforeEach(func() {
+		collector = goa.NewPrometheusCollector("test")
+		service = newService(nil)
+		req, _ = http.NewRequest("GET", "/bottles/1", nil)
+		rw = newTestResponseWriter()
+		ctx = goa.WithAction(newContext(service, rw, req, nil), "show")
+	})
+
+	JustBeforeEach(func() {
+		middleware.Metrics(collector)(handler)(ctx, rw, req)
+	})
+
+	Context("with a successful request", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return service.Send(ctx, 200, "ok")
+			}
+		})
+
+		It("records the request count and duration", func() {
+			out := scrape()
+			Ω(out).Should(ContainSubstring(`test_http_requests_total{action="show",controller="test",status="200"} 1`))
+			Ω(out).Should(ContainSubstring(`test_http_request_duration_seconds_count{action="show",controller="test",status="200"} 1`))
+			Ω(out).Should(ContainSubstring(`# TYPE test_http_request_duration_seconds histogram`))
+			Ω(out).ShouldNot(ContainSubstring(`test_http_request_errors_total`))
+		})
+	})
+
+	Context("with a failed request", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return errors.New("boom")
+			}
+		})
+
+		It("records an error", func() {
+			out := scrape()
+			Ω(out).Should(ContainSubstring(`test_http_requests_total{action="show",controller="test",status="500"} 1`))
+			Ω(out).Should(ContainSubstring(`test_http_request_errors_total{action="show",controller="test",status="500"} 1`))
+		})
+	})
+
+	Context("with a request failing with a goa error", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return goa.ErrNotFound("no bottle")
+			}
+		})
+
+		It("uses the error status", func() {
+			out := scrape()
+			Ω(out).Should(ContainSubstring(`test_http_requests_total{action="show",controller="test",status="404"} 1`))
+			Ω(out).ShouldNot(ContainSubstring(`test_http_request_errors_total`))
+		})
+	})
+})
@@ -0,0 +1,26 @@
+/*
+Package ratelimit provides a middleware that limits the rate of requests handled by a service.
+
+Requests are grouped by key, for example the client IP address, the API key or the JWT subject,
+and each key is granted a token bucket. Requests that find their bucket empty are rejected with
+goa.ErrTooManyRequests (HTTP status 429) and a Retry-After header. All responses include the
+X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers.
+
+The buckets are kept in memory by default, services running multiple instances may share them
+by providing a Store backed by a shared database.
+
+	service.Use(ratelimit.New(ratelimit.Limit{Requests: 100, Period: time.Minute}))
+
+Actions may override the default limit using the "ratelimit" design metadata key. The first
+value is the rate expressed as the number of requests per period, the optional second value is
+the burst size:
+
+	Action("search", func() {
+		Metadata("ratelimit", "10/s", "20")
+		...
+	})
+
+The period is one of "s", "m" or "h" or any value accepted by time.ParseDuration, e.g.
+"1000/10m".
+*/
+package ratelimit
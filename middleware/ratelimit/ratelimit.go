@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/security/jwt"
+)
+
+// MetadataKey is the design metadata key used to override the default limit of an action.
+const MetadataKey = "ratelimit"
+
+const (
+	headerLimit      = "X-RateLimit-Limit"
+	headerRemaining  = "X-RateLimit-Remaining"
+	headerReset      = "X-RateLimit-Reset"
+	headerRetryAfter = "Retry-After"
+)
+
+type (
+	// KeyFunc computes the key that identifies the bucket used to limit a request. Requests
+	// for which the function returns an empty key are not limited.
+	KeyFunc func(ctx context.Context, req *http.Request) string
+
+	// Option configures the middleware.
+	Option func(*options)
+
+	// options holds the middleware configuration.
+	options struct {
+		store Store
+		key   KeyFunc
+	}
+)
+
+// WithStore sets the store used to keep the token buckets, defaults to a MemoryStore that evicts
+// buckets unused for an hour.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithKey sets the function that computes the bucket key of a request, defaults to ClientIP.
+func WithKey(fn KeyFunc) Option {
+	return func(o *options) {
+		o.key = fn
+	}
+}
+
+// New returns a middleware that limits the rate of requests using the given default limit. The
+// limit of an action may be overridden with the "ratelimit" design metadata key, in which case
+// the action gets its own set of buckets. A zero default limit means that only the actions that
+// define the metadata are limited.
+//
+// Requests that exceed the limit are rejected with goa.ErrTooManyRequests. The store errors are
+// logged and the corresponding requests are let through.
+func New(limit Limit, opts ...Option) goa.Middleware {
+	o := options{key: ClientIP}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore(time.Hour)
+	}
+	var routeLimits sync.Map // *goa.Route -> *Limit, nil if the route uses the default limit
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			l, scope := &limit, ""
+			if route := goa.ContextRoute(ctx); route != nil {
+				rl, ok := routeLimits.Load(route)
+				if !ok {
+					rl = routeLimit(ctx, route)
+					routeLimits.Store(route, rl)
+				}
+				if rl.(*Limit) != nil {
+					l, scope = rl.(*Limit), route.Controller+"#"+route.Action+":"
+				}
+			}
+			if l.Requests <= 0 {
+				return h(ctx, rw, req)
+			}
+			key := o.key(ctx, req)
+			if key == "" {
+				return h(ctx, rw, req)
+			}
+			res, err := o.store.Take(ctx, scope+key, *l)
+			if err != nil {
+				goa.LogError(ctx, "rate limit", "err", err)
+				return h(ctx, rw, req)
+			}
+			header := rw.Header()
+			header.Set(headerLimit, strconv.Itoa(l.Capacity()))
+			header.Set(headerRemaining, strconv.Itoa(res.Remaining))
+			header.Set(headerReset, strconv.Itoa(seconds(res.Reset)))
+			if !res.Allowed {
+				retry := seconds(res.RetryAfter)
+				header.Set(headerRetryAfter, strconv.Itoa(retry))
+				return goa.ErrTooManyRequests("rate limit exceeded", "limit", l.String(), "retry_after", retry)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ClientIP returns the IP address of the client that sent the request. It does not take into
+// account headers set by proxies, see ForwardedClientIP.
+func ClientIP(_ context.Context, req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// ForwardedClientIP returns the first address listed in the X-Forwarded-For request header if
+// any, the IP address of the client that sent the request otherwise. It should only be used when
+// the service runs behind a proxy that sets the header as clients may set any value.
+func ForwardedClientIP(ctx context.Context, req *http.Request) string {
+	if fwd := req.Header.Get("X-Forwarded-For"); fwd != "" {
+		if ip := strings.TrimSpace(strings.Split(fwd, ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	return ClientIP(ctx, req)
+}
+
+// APIKey returns a key function that uses the API key read from the location described by the
+// given security scheme. The key is hashed so that it does not get stored in clear.
+func APIKey(scheme *goa.APIKeySecurity) KeyFunc {
+	return func(_ context.Context, req *http.Request) string {
+		var key string
+		if scheme.In == goa.LocQuery {
+			key = req.URL.Query().Get(scheme.Name)
+		} else {
+			key = req.Header.Get(scheme.Name)
+		}
+		if key == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "apikey:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// JWTSubject returns the subject ("sub" claim) of the JWT validated by the jwt middleware. The
+// rate limit middleware must run after the jwt middleware for the token to be available, for
+// example:
+//
+//	jwtMiddleware := jwt.New(resolver, nil, app.NewJWTSecurity())
+//	limiter := ratelimit.New(limit, ratelimit.WithKey(ratelimit.JWTSubject))
+//	app.UseJWTMiddleware(service, func(h goa.Handler) goa.Handler {
+//		return jwtMiddleware(limiter(h))
+//	})
+func JWTSubject(ctx context.Context, _ *http.Request) string {
+	token := jwt.ContextJWT(ctx)
+	if token == nil {
+		return ""
+	}
+	var sub string
+	switch claims := token.Claims.(type) {
+	case jwtgo.MapClaims:
+		sub, _ = claims["sub"].(string)
+	case *jwtgo.StandardClaims:
+		sub = claims.Subject
+	}
+	if sub == "" {
+		return ""
+	}
+	return "sub:" + sub
+}
+
+// routeLimit returns the limit defined in the route metadata if any.
+func routeLimit(ctx context.Context, route *goa.Route) *Limit {
+	vals, ok := route.Metadata[MetadataKey]
+	if !ok || len(vals) == 0 {
+		return nil
+	}
+	l, err := ParseLimit(vals[0], vals[1:]...)
+	if err != nil {
+		goa.LogError(ctx, "invalid rate limit metadata", "route", route.String(), "err", err)
+		return nil
+	}
+	return &l
+}
+
+// seconds returns d rounded up to the second.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
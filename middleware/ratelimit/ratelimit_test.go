@@ -0,0 +1,190 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/ratelimit"
+	"github.com/goadesign/goa/middleware/security/jwt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type errorStore struct{}
+
+func (errorStore) Take(context.Context, string, ratelimit.Limit) (*ratelimit.Result, error) {
+	return nil, errors.New("boom")
+}
+
+var _ = Describe("New", func() {
+	var limit ratelimit.Limit
+	var options []ratelimit.Option
+	var route *goa.Route
+	var req *http.Request
+	var rw *httptest.ResponseRecorder
+	var called int
+
+	BeforeEach(func() {
+		limit = ratelimit.Limit{Requests: 2, Period: time.Hour}
+		options = nil
+		route = nil
+		called = 0
+		req = httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+	})
+
+	serve := func(h goa.Handler) error {
+		rw = httptest.NewRecorder()
+		r := req
+		if route != nil {
+			r = req.WithContext(goa.WithRoute(req.Context(), route))
+		}
+		ctx := goa.NewContext(nil, rw, r, nil)
+		return h(ctx, rw, r)
+	}
+
+	var handler goa.Handler
+	JustBeforeEach(func() {
+		handler = ratelimit.New(limit, options...)(func(context.Context, http.ResponseWriter, *http.Request) error {
+			called++
+			return nil
+		})
+	})
+
+	It("lets requests through until the bucket is empty", func() {
+		Ω(serve(handler)).ShouldNot(HaveOccurred())
+		Ω(rw.Header().Get("X-RateLimit-Limit")).Should(Equal("2"))
+		Ω(rw.Header().Get("X-RateLimit-Remaining")).Should(Equal("1"))
+		Ω(rw.Header().Get("X-RateLimit-Reset")).Should(Equal("1800"))
+		Ω(serve(handler)).ShouldNot(HaveOccurred())
+		Ω(rw.Header().Get("X-RateLimit-Remaining")).Should(Equal("0"))
+
+		err := serve(handler)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusTooManyRequests))
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("1800"))
+		Ω(called).Should(Equal(2))
+	})
+
+	It("uses a bucket per client", func() {
+		Ω(serve(handler)).ShouldNot(HaveOccurred())
+		Ω(serve(handler)).ShouldNot(HaveOccurred())
+		req.RemoteAddr = "10.0.0.2:4242"
+		Ω(serve(handler)).ShouldNot(HaveOccurred())
+		Ω(called).Should(Equal(3))
+	})
+
+	Context("with a route defining a limit", func() {
+		BeforeEach(func() {
+			limit = ratelimit.Limit{}
+			route = &goa.Route{Controller: "bottle", Action: "show", Metadata: map[string][]string{"ratelimit": {"1/h"}}}
+		})
+
+		It("uses the route limit", func() {
+			Ω(serve(handler)).ShouldNot(HaveOccurred())
+			Ω(serve(handler)).Should(HaveOccurred())
+			route = &goa.Route{Controller: "bottle", Action: "list"}
+			Ω(serve(handler)).ShouldNot(HaveOccurred())
+			Ω(rw.Header().Get("X-RateLimit-Limit")).Should(BeEmpty())
+			Ω(called).Should(Equal(2))
+		})
+	})
+
+	Context("with a failing store", func() {
+		BeforeEach(func() {
+			options = []ratelimit.Option{ratelimit.WithStore(errorStore{})}
+		})
+
+		It("lets requests through", func() {
+			Ω(serve(handler)).ShouldNot(HaveOccurred())
+			Ω(called).Should(Equal(1))
+		})
+	})
+
+	Context("with a key function returning no key", func() {
+		BeforeEach(func() {
+			options = []ratelimit.Option{ratelimit.WithKey(ratelimit.JWTSubject)}
+		})
+
+		It("does not limit requests", func() {
+			for i := 0; i < 3; i++ {
+				Ω(serve(handler)).ShouldNot(HaveOccurred())
+			}
+			Ω(called).Should(Equal(3))
+		})
+	})
+})
+
+var _ = Describe("ParseLimit", func() {
+	It("parses rates and bursts", func() {
+		l, err := ratelimit.ParseLimit("100/m", "20")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(Equal(ratelimit.Limit{Requests: 100, Period: time.Minute, Burst: 20}))
+		l, err = ratelimit.ParseLimit("1000/10m")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(Equal(ratelimit.Limit{Requests: 1000, Period: 10 * time.Minute}))
+	})
+
+	It("rejects invalid limits", func() {
+		for _, rate := range []string{"100", "x/s", "0/s", "10/x", "10/-1s"} {
+			_, err := ratelimit.ParseLimit(rate)
+			Ω(err).Should(HaveOccurred(), rate)
+		}
+		_, err := ratelimit.ParseLimit("10/s", "x")
+		Ω(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("MemoryStore", func() {
+	It("refills the buckets over time", func() {
+		s := ratelimit.NewMemoryStore(time.Hour)
+		l := ratelimit.Limit{Requests: 1, Period: 10 * time.Millisecond}
+		res, err := s.Take(context.Background(), "key", l)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(res.Allowed).Should(BeTrue())
+		res, err = s.Take(context.Background(), "key", l)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(res.Allowed).Should(BeFalse())
+		Ω(res.RetryAfter).Should(BeNumerically(">", 0))
+		time.Sleep(res.RetryAfter)
+		res, err = s.Take(context.Background(), "key", l)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(res.Allowed).Should(BeTrue())
+	})
+})
+
+var _ = Describe("Key functions", func() {
+	var req *http.Request
+
+	BeforeEach(func() {
+		req = httptest.NewRequest("GET", "/?key=secret", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+	})
+
+	It("computes the client IP", func() {
+		Ω(ratelimit.ClientIP(context.Background(), req)).Should(Equal("10.0.0.1"))
+		req.Header.Set("X-Forwarded-For", "192.168.0.1, 10.0.0.1")
+		Ω(ratelimit.ClientIP(context.Background(), req)).Should(Equal("10.0.0.1"))
+		Ω(ratelimit.ForwardedClientIP(context.Background(), req)).Should(Equal("192.168.0.1"))
+	})
+
+	It("hashes the API key", func() {
+		fn := ratelimit.APIKey(&goa.APIKeySecurity{In: goa.LocQuery, Name: "key"})
+		key := fn(context.Background(), req)
+		Ω(key).Should(HavePrefix("apikey:"))
+		Ω(key).ShouldNot(ContainSubstring("secret"))
+		fn = ratelimit.APIKey(&goa.APIKeySecurity{In: goa.LocHeader, Name: "X-Key"})
+		Ω(fn(context.Background(), req)).Should(BeEmpty())
+	})
+
+	It("reads the JWT subject", func() {
+		token := &jwtgo.Token{Claims: jwtgo.MapClaims{"sub": "user"}}
+		ctx := jwt.WithJWT(context.Background(), token)
+		Ω(ratelimit.JWTSubject(ctx, req)).Should(Equal("sub:user"))
+	})
+})
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Limit describes a token bucket: Burst tokens at most with Requests tokens added every
+	// Period.
+	Limit struct {
+		// Requests is the number of requests allowed per period.
+		Requests int
+		// Period is the duration over which Requests requests are allowed.
+		Period time.Duration
+		// Burst is the maximum number of requests allowed at once, defaults to Requests.
+		Burst int
+	}
+
+	// Result is the outcome of taking a token from a bucket.
+	Result struct {
+		// Allowed is true if a token was available.
+		Allowed bool
+		// Remaining is the number of tokens left in the bucket.
+		Remaining int
+		// RetryAfter is the time until the next token is available if the request was
+		// rejected.
+		RetryAfter time.Duration
+		// Reset is the time until the bucket is full again.
+		Reset time.Duration
+	}
+
+	// Store is the interface implemented by the token bucket backends. Implementations must
+	// be safe for concurrent use.
+	Store interface {
+		// Take takes a token from the bucket identified by key, creating the bucket with
+		// the given limit if needed.
+		Take(ctx context.Context, key string, limit Limit) (*Result, error)
+	}
+
+	// MemoryStore is a Store that keeps the buckets in memory. Buckets that have not been
+	// used for the store TTL are evicted.
+	MemoryStore struct {
+		ttl       time.Duration
+		lock      sync.Mutex
+		buckets   map[string]*bucket
+		lastSweep time.Time
+	}
+
+	// bucket is a token bucket.
+	bucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// NewMemoryStore returns an in-memory store that evicts buckets unused for ttl. ttl should be
+// greater than the time it takes for buckets to refill so that evicting a bucket does not grant
+// additional requests.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ttl: ttl, buckets: make(map[string]*bucket)}
+}
+
+// ParseLimit parses a limit expressed as "<requests>/<period>" with an optional burst size, e.g.
+// "100/m" or "1000/10m". The period is one of "s", "m" or "h" or any value accepted by
+// time.ParseDuration.
+func ParseLimit(rate string, burst ...string) (Limit, error) {
+	var l Limit
+	elems := strings.SplitN(rate, "/", 2)
+	if len(elems) != 2 {
+		return l, fmt.Errorf("invalid rate limit %q, must be of the form <requests>/<period>", rate)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(elems[0]))
+	if err != nil || n <= 0 {
+		return l, fmt.Errorf("invalid rate limit %q, number of requests must be a positive integer", rate)
+	}
+	var period time.Duration
+	switch p := strings.TrimSpace(elems[1]); p {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		if period, err = time.ParseDuration(p); err != nil || period <= 0 {
+			return l, fmt.Errorf("invalid rate limit %q, invalid period %q", rate, p)
+		}
+	}
+	l = Limit{Requests: n, Period: period}
+	if len(burst) > 0 && burst[0] != "" {
+		b, err := strconv.Atoi(strings.TrimSpace(burst[0]))
+		if err != nil || b <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit burst %q, must be a positive integer", burst[0])
+		}
+		l.Burst = b
+	}
+	return l, nil
+}
+
+// Capacity returns the maximum number of tokens in the bucket.
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// String returns the limit using the format accepted by ParseLimit.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// Take takes a token from the bucket identified by key.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (*Result, error) {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %s", limit)
+	}
+	now := time.Now()
+	capacity := float64(limit.Capacity())
+	rate := float64(limit.Requests) / float64(limit.Period)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+	res := &Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration(math.Ceil((capacity - b.tokens) / rate))
+	return res, nil
+}
+
+// sweep evicts the buckets unused for the store TTL, it runs at most once per TTL.
+func (s *MemoryStore) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for k, b := range s.buckets {
+		if now.Sub(b.last) >= s.ttl {
+			delete(s.buckets, k)
+		}
+	}
+	s.lastSweep = now
+}
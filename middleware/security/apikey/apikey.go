@@ -0,0 +1,91 @@
+/*
+Package apikey provides a middleware that authenticates requests using the API key security
+schemes defined with the APIKeySecurity DSL.
+
+The middleware reads the key from the header or query string parameter defined by the scheme and
+looks up its hash in a KeyStore. The store holds the hashes of the keys together with the
+principal owning them and the scopes they grant.
+*/
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/goadesign/goa"
+)
+
+// ErrAPIKeyError is the error returned by this middleware when the API key is missing, unknown or
+// does not grant the required scopes.
+var ErrAPIKeyError = goa.NewErrorClass("api_key_security_error", 401)
+
+// New returns a middleware to be used with the APIKeySecurity DSL definitions of goa. The steps
+// taken by the middleware are:
+//
+//  1. Extract the key from the header or query parameter defined by the scheme
+//  2. Look up the hash of the key in the store and compare it in constant time
+//  3. If scopes are defined in the design for the action, validate that the key grants them
+//
+// The key is then stored in the request context, use ContextPrincipal and ContextScopes to
+// retrieve its owner and scopes. Mount the middleware with the generated UseXX function where XX
+// is the name of the scheme as defined in the design, e.g.:
+//
+//	store := apikey.NewMemoryStore(&apikey.Key{
+//	    Hash:      apikey.Hash(os.Getenv("ADMIN_API_KEY")),
+//	    Principal: "admin",
+//	    Scopes:    []string{"api:read", "api:write"},
+//	})
+//	app.UseAPIKeyMiddleware(service, apikey.New(store, app.NewAPIKeySecurity()))
+func New(store KeyStore, scheme *goa.APIKeySecurity) goa.Middleware {
+	return func(nextHandler goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			var incomingKey string
+			switch scheme.In {
+			case goa.LocHeader:
+				incomingKey = req.Header.Get(scheme.Name)
+				if incomingKey == "" {
+					return ErrAPIKeyError(fmt.Sprintf("missing header %q", scheme.Name))
+				}
+			case goa.LocQuery:
+				incomingKey = req.URL.Query().Get(scheme.Name)
+				if incomingKey == "" {
+					return ErrAPIKeyError(fmt.Sprintf("missing parameter %q", scheme.Name))
+				}
+			default:
+				return fmt.Errorf("whoops, security scheme with location (in) %q not supported", scheme.In)
+			}
+
+			hash := Hash(incomingKey)
+			key, err := store.Lookup(ctx, hash)
+			if err != nil {
+				goa.LogError(ctx, "API key lookup failed", "err", err)
+				return err
+			}
+			if key == nil || subtle.ConstantTimeCompare(key.Hash, hash) != 1 {
+				return ErrAPIKeyError("invalid API key")
+			}
+
+			requiredScopes := goa.ContextRequiredScopes(ctx)
+			for _, scope := range requiredScopes {
+				if !hasScope(key.Scopes, scope) {
+					msg := "authorization failed: required scopes not granted to API key"
+					return ErrAPIKeyError(msg, "required", requiredScopes, "scopes", key.Scopes)
+				}
+			}
+
+			return nextHandler(WithKey(ctx, key), rw, req)
+		}
+	}
+}
+
+// hasScope returns true if scopes contains scope.
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/security/apikey"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingStore struct{}
+
+func (failingStore) Lookup(context.Context, []byte) (*apikey.Key, error) {
+	return nil, errors.New("connection refused")
+}
+
+var _ = Describe("New", func() {
+	var store apikey.KeyStore
+	var scheme *goa.APIKeySecurity
+	var req *http.Request
+	var ctx context.Context
+	var principal string
+	var scopes []string
+
+	BeforeEach(func() {
+		store = apikey.NewMemoryStore(
+			&apikey.Key{Hash: apikey.Hash("secret"), Principal: "admin", Scopes: []string{"api:read", "api:write"}},
+			&apikey.Key{Hash: apikey.Hash("readonly"), Principal: "reader", Scopes: []string{"api:read"}},
+		)
+		scheme = &goa.APIKeySecurity{In: goa.LocHeader, Name: "X-API-Key"}
+		req = httptest.NewRequest("GET", "/", nil)
+		ctx = context.Background()
+		principal, scopes = "", nil
+	})
+
+	serve := func() error {
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			principal, scopes = apikey.ContextPrincipal(ctx), apikey.ContextScopes(ctx)
+			return nil
+		}
+		return apikey.New(store, scheme)(handler)(ctx, httptest.NewRecorder(), req)
+	}
+
+	It("authenticates requests with a valid key", func() {
+		req.Header.Set("X-API-Key", "secret")
+		Ω(serve()).Should(Succeed())
+		Ω(principal).Should(Equal("admin"))
+		Ω(scopes).Should(Equal([]string{"api:read", "api:write"}))
+	})
+
+	It("reads the key from the query string", func() {
+		scheme = &goa.APIKeySecurity{In: goa.LocQuery, Name: "api_key"}
+		req = httptest.NewRequest("GET", "/?api_key=readonly", nil)
+		Ω(serve()).Should(Succeed())
+		Ω(principal).Should(Equal("reader"))
+	})
+
+	It("rejects missing keys", func() {
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("rejects unknown keys", func() {
+		req.Header.Set("X-API-Key", "guess")
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusUnauthorized))
+		Ω(principal).Should(BeEmpty())
+	})
+
+	It("enforces the required scopes", func() {
+		ctx = goa.WithRequiredScopes(ctx, []string{"api:write"})
+		req.Header.Set("X-API-Key", "readonly")
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusUnauthorized))
+		req.Header.Set("X-API-Key", "secret")
+		Ω(serve()).Should(Succeed())
+	})
+
+	It("returns store errors", func() {
+		store = failingStore{}
+		req.Header.Set("X-API-Key", "secret")
+		Ω(serve()).Should(MatchError("connection refused"))
+	})
+})
+
+var _ = Describe("MemoryStore", func() {
+	It("adds and removes keys", func() {
+		store := apikey.NewMemoryStore()
+		store.Add(&apikey.Key{Hash: apikey.Hash("secret"), Principal: "admin"})
+		key, err := store.Lookup(context.Background(), apikey.Hash("secret"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(key.Principal).Should(Equal("admin"))
+		store.Remove(apikey.Hash("secret"))
+		Ω(store.Lookup(context.Background(), apikey.Hash("secret"))).Should(BeNil())
+	})
+})
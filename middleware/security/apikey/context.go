@@ -0,0 +1,39 @@
+package apikey
+
+import "context"
+
+type contextKey int
+
+const (
+	apiKeyKey contextKey = iota + 1
+)
+
+// WithKey creates a child context containing the given API key.
+func WithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+// ContextKey retrieves the API key from a `context` that went through our security middleware.
+func ContextKey(ctx context.Context) *Key {
+	key, ok := ctx.Value(apiKeyKey).(*Key)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+// ContextPrincipal returns the principal owning the API key used to authenticate the request.
+func ContextPrincipal(ctx context.Context) string {
+	if key := ContextKey(ctx); key != nil {
+		return key.Principal
+	}
+	return ""
+}
+
+// ContextScopes returns the scopes granted to the API key used to authenticate the request.
+func ContextScopes(ctx context.Context) []string {
+	if key := ContextKey(ctx); key != nil {
+		return key.Scopes
+	}
+	return nil
+}
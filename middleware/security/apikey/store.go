@@ -0,0 +1,81 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+)
+
+type (
+	// Key describes an API key. Only the hash of the key is stored so that a leaked store does
+	// not leak the keys.
+	Key struct {
+		// Hash is the hash of the key computed with Hash.
+		Hash []byte
+		// Principal identifies the owner of the key, e.g. a user or service account ID.
+		Principal string
+		// Scopes lists the scopes granted to the key.
+		Scopes []string
+	}
+
+	// KeyStore looks up API keys.
+	KeyStore interface {
+		// Lookup returns the key with the given hash or nil if there is none. The middleware
+		// compares the hash of the returned key with the hash of the incoming key in
+		// constant time.
+		Lookup(ctx context.Context, hash []byte) (*Key, error)
+	}
+
+	// MemoryStore is a KeyStore that holds the keys in memory. It compares the hash of the
+	// incoming key with all the keys in constant time.
+	MemoryStore struct {
+		lock sync.RWMutex
+		keys []*Key
+	}
+)
+
+// Hash returns the SHA-256 hash of the given API key.
+func Hash(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// NewMemoryStore returns a store that holds the given keys.
+func NewMemoryStore(keys ...*Key) *MemoryStore {
+	return &MemoryStore{keys: keys}
+}
+
+// Add adds a key to the store.
+func (s *MemoryStore) Add(key *Key) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keys = append(s.keys, key)
+}
+
+// Remove removes the key with the given hash from the store.
+func (s *MemoryStore) Remove(hash []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var keys []*Key
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(k.Hash, hash) != 1 {
+			keys = append(keys, k)
+		}
+	}
+	s.keys = keys
+}
+
+// Lookup returns the key with the given hash. It compares the hash with the hash of every key so
+// that the duration of the lookup does not depend on the key.
+func (s *MemoryStore) Lookup(_ context.Context, hash []byte) (*Key, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var found *Key
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(k.Hash, hash) == 1 {
+			found = k
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,184 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+type (
+	// Option configures the claim validations done by the middleware created with New.
+	Option func(*options)
+
+	// options lists the claim validation settings.
+	options struct {
+		issuers        []string
+		audiences      []string
+		leeway         time.Duration
+		algorithms     []string
+		requiredClaims []string
+	}
+)
+
+// WithIssuers sets the accepted values of the "iss" claim, overriding the issuers of the security
+// scheme. Tokens issued by others are rejected.
+func WithIssuers(issuers ...string) Option {
+	return func(o *options) {
+		o.issuers = issuers
+	}
+}
+
+// WithAudiences sets the accepted values of the "aud" claim, overriding the audiences of the
+// security scheme. Tokens that do not list any of the audiences are rejected.
+func WithAudiences(audiences ...string) Option {
+	return func(o *options) {
+		o.audiences = audiences
+	}
+}
+
+// WithLeeway sets the tolerated clock skew when validating the "exp", "nbf" and "iat" claims.
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		o.leeway = leeway
+	}
+}
+
+// WithAlgorithms sets the accepted signing algorithms, e.g. "RS256". Tokens signed with other
+// algorithms are rejected before their signature is validated.
+func WithAlgorithms(algorithms ...string) Option {
+	return func(o *options) {
+		o.algorithms = algorithms
+	}
+}
+
+// WithRequiredClaims sets the names of the claims that must be present in the tokens.
+func WithRequiredClaims(names ...string) Option {
+	return func(o *options) {
+		o.requiredClaims = names
+	}
+}
+
+// validateAlgorithm returns an error if the signing algorithm of the token is not accepted.
+func (o *options) validateAlgorithm(token *jwt.Token) error {
+	if len(o.algorithms) == 0 {
+		return nil
+	}
+	alg, _ := token.Header["alg"].(string)
+	if !contains(o.algorithms, alg) {
+		return ErrJWTError("unexpected signing algorithm", "alg", alg, "allowed", o.algorithms)
+	}
+	return nil
+}
+
+// validateClaims validates the registered and required claims of the token.
+func (o *options) validateClaims(token *jwt.Token) error {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ErrJWTError("unsupported claims shape")
+	}
+	now := time.Now()
+	exp, err := timeClaim(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !exp.IsZero() && now.After(exp.Add(o.leeway)) {
+		return ErrJWTError("token is expired", "exp", exp)
+	}
+	nbf, err := timeClaim(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if !nbf.IsZero() && now.Before(nbf.Add(-o.leeway)) {
+		return ErrJWTError("token is not valid yet", "nbf", nbf)
+	}
+	iat, err := timeClaim(claims, "iat")
+	if err != nil {
+		return err
+	}
+	if !iat.IsZero() && now.Before(iat.Add(-o.leeway)) {
+		return ErrJWTError("token used before issued", "iat", iat)
+	}
+	if len(o.issuers) > 0 {
+		iss, _ := claims["iss"].(string)
+		if !contains(o.issuers, iss) {
+			return ErrJWTError("invalid issuer", "iss", iss, "expected", o.issuers)
+		}
+	}
+	if len(o.audiences) > 0 {
+		auds, err := audienceClaim(claims)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, aud := range auds {
+			if contains(o.audiences, aud) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrJWTError("invalid audience", "aud", auds, "expected", o.audiences)
+		}
+	}
+	for _, name := range o.requiredClaims {
+		if claims[name] == nil {
+			return ErrJWTError("missing required claim", "claim", name)
+		}
+	}
+	return nil
+}
+
+// timeClaim returns the value of the NumericDate claim with the given name, the zero value if the
+// claim is absent.
+func timeClaim(claims jwt.MapClaims, name string) (time.Time, error) {
+	var secs float64
+	switch v := claims[name].(type) {
+	case nil:
+		return time.Time{}, nil
+	case float64:
+		secs = v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, ErrJWTError(fmt.Sprintf("invalid %q claim", name))
+		}
+		secs = f
+	default:
+		return time.Time{}, ErrJWTError(fmt.Sprintf("invalid %q claim", name))
+	}
+	return time.Unix(int64(secs), 0), nil
+}
+
+// audienceClaim returns the values of the "aud" claim which may be a string or a list of strings.
+func audienceClaim(claims jwt.MapClaims) ([]string, error) {
+	switch v := claims["aud"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		auds := make([]string, 0, len(v))
+		for _, aud := range v {
+			s, ok := aud.(string)
+			if !ok {
+				return nil, ErrJWTError(`invalid "aud" claim`)
+			}
+			auds = append(auds, s)
+		}
+		return auds, nil
+	default:
+		return nil, ErrJWTError(`invalid "aud" claim`)
+	}
+}
+
+// contains returns true if vals contains val.
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package jwt_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	jwtpkg "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/security/jwt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Claim validation", func() {
+	var scheme *goa.JWTSecurity
+	var opts []jwt.Option
+	var method jwtpkg.SigningMethod
+	var claims jwtpkg.MapClaims
+
+	BeforeEach(func() {
+		scheme = &goa.JWTSecurity{In: goa.LocHeader, Name: "Authorization"}
+		opts = nil
+		method = jwtpkg.SigningMethodHS256
+		claims = jwtpkg.MapClaims{
+			"iss": "https://example.com",
+			"aud": []string{"users", "admin"},
+			"sub": "me",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+	})
+
+	validate := func() error {
+		signed, err := jwtpkg.NewWithClaims(method, claims).SignedString([]byte("keys"))
+		Ω(err).ShouldNot(HaveOccurred())
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signed)
+		resolver := jwt.NewSimpleResolver([]jwt.Key{"keys"})
+		handler := func(context.Context, http.ResponseWriter, *http.Request) error { return nil }
+		return jwt.New(resolver, nil, scheme, opts...)(handler)(context.Background(), httptest.NewRecorder(), req)
+	}
+
+	detail := func(err error) string {
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusUnauthorized))
+		return err.(*goa.ErrorResponse).Detail
+	}
+
+	It("accepts valid tokens", func() {
+		opts = []jwt.Option{
+			jwt.WithIssuers("https://example.com"),
+			jwt.WithAudiences("admin"),
+			jwt.WithAlgorithms("HS256"),
+			jwt.WithRequiredClaims("sub"),
+		}
+		Ω(validate()).Should(Succeed())
+	})
+
+	It("uses the issuers and audiences of the security scheme", func() {
+		scheme.Issuers = []string{"https://example.com"}
+		scheme.Audiences = []string{"users"}
+		Ω(validate()).Should(Succeed())
+		claims["aud"] = "others"
+		Ω(detail(validate())).Should(Equal("invalid audience"))
+	})
+
+	It("rejects expired tokens", func() {
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		Ω(detail(validate())).Should(Equal("token is expired"))
+	})
+
+	It("rejects tokens that are not valid yet", func() {
+		claims["nbf"] = time.Now().Add(time.Minute).Unix()
+		Ω(detail(validate())).Should(Equal("token is not valid yet"))
+	})
+
+	It("tolerates clock skew", func() {
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		claims["nbf"] = time.Now().Add(time.Minute).Unix()
+		claims["iat"] = time.Now().Add(time.Minute).Unix()
+		opts = []jwt.Option{jwt.WithLeeway(2 * time.Minute)}
+		Ω(validate()).Should(Succeed())
+	})
+
+	It("rejects unexpected issuers", func() {
+		opts = []jwt.Option{jwt.WithIssuers("https://other.com")}
+		Ω(detail(validate())).Should(Equal("invalid issuer"))
+	})
+
+	It("rejects unexpected audiences", func() {
+		opts = []jwt.Option{jwt.WithAudiences("others")}
+		Ω(detail(validate())).Should(Equal("invalid audience"))
+	})
+
+	It("rejects unexpected algorithms", func() {
+		method = jwtpkg.SigningMethodHS512
+		opts = []jwt.Option{jwt.WithAlgorithms("HS256", "RS256")}
+		Ω(detail(validate())).Should(Equal("unexpected signing algorithm"))
+	})
+
+	It("rejects tokens missing required claims", func() {
+		opts = []jwt.Option{jwt.WithRequiredClaims("sub", "email")}
+		err := validate()
+		Ω(detail(err)).Should(Equal("missing required claim"))
+		Ω(err.(*goa.ErrorResponse).Meta).Should(HaveKeyWithValue("claim", "email"))
+	})
+})
@@ -0,0 +1,357 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+var (
+	// DefaultJWKSRefreshInterval is the default maximum duration between two refreshes of the
+	// keys of a JWKSResolver.
+	DefaultJWKSRefreshInterval = time.Hour
+
+	// DefaultJWKSMinRefreshInterval is the default minimum duration between two refreshes of the
+	// keys of a JWKSResolver.
+	DefaultJWKSMinRefreshInterval = time.Minute
+
+	// minJWKSBackoff is the delay before retrying a failed refresh, the delay doubles after each
+	// consecutive failure up to the refresh interval.
+	minJWKSBackoff = time.Second
+
+	// maxJWKSLength is the maximum length of a JWKS document.
+	maxJWKSLength int64 = 1 << 20
+)
+
+type (
+	// JWKSResolver is a key resolver that loads the keys from a JSON Web Key Set document (RFC
+	// 7517) such as the ones published by OpenID Connect identity providers. It supports RSA, EC
+	// and oct keys and selects the key used to validate a token using the token "kid" header.
+	// The keys are refreshed in the background, a token signed with an unknown key also causes a
+	// refresh unless the keys were refreshed less than the minimum refresh interval ago.
+	JWKSResolver struct {
+		url         string
+		client      *http.Client
+		interval    time.Duration
+		minInterval time.Duration
+		ctx         context.Context
+		cancel      context.CancelFunc
+
+		lock sync.RWMutex     // Protects keys and ids
+		keys []Key            // All the keys
+		ids  map[string][]Key // Keys indexed by ID
+
+		refreshLock sync.Mutex // Serializes refreshes and protects fields below
+		etag        string     // ETag of the last document
+		fetchedAt   time.Time  // Time of the last refresh
+	}
+
+	// JWKSOption configures a JWKSResolver.
+	JWKSOption func(*JWKSResolver)
+
+	// jwk is a JSON Web Key as defined by RFC 7517.
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+		K   string `json:"k"`
+	}
+)
+
+// WithJWKSClient sets the HTTP client used to retrieve the JWKS document, defaults to
+// http.DefaultClient.
+func WithJWKSClient(client *http.Client) JWKSOption {
+	return func(r *JWKSResolver) {
+		r.client = client
+	}
+}
+
+// WithJWKSRefreshInterval sets the maximum duration between two refreshes of the keys. The keys
+// are refreshed sooner if the max-age of the JWKS document Cache-Control header is shorter.
+// Defaults to DefaultJWKSRefreshInterval.
+func WithJWKSRefreshInterval(interval time.Duration) JWKSOption {
+	return func(r *JWKSResolver) {
+		r.interval = interval
+	}
+}
+
+// WithJWKSMinRefreshInterval sets the minimum duration between two refreshes of the keys, it
+// limits the rate of refreshes caused by tokens signed with unknown keys. Defaults to
+// DefaultJWKSMinRefreshInterval.
+func WithJWKSMinRefreshInterval(interval time.Duration) JWKSOption {
+	return func(r *JWKSResolver) {
+		r.minInterval = interval
+	}
+}
+
+// WithJWKSLogger sets the logger used to report refresh errors.
+func WithJWKSLogger(logger goa.LogAdapter) JWKSOption {
+	return func(r *JWKSResolver) {
+		r.ctx = goa.WithLogger(r.ctx, logger)
+	}
+}
+
+// NewJWKSResolver retrieves the JWKS document at the given URL and returns a resolver that uses
+// its keys. It returns an error if the document cannot be retrieved or does not contain any
+// usable key. Call Close to stop refreshing the keys.
+func NewJWKSResolver(url string, opts ...JWKSOption) (*JWKSResolver, error) {
+	r := &JWKSResolver{
+		url:         url,
+		client:      http.DefaultClient,
+		interval:    DefaultJWKSRefreshInterval,
+		minInterval: DefaultJWKSMinRefreshInterval,
+		ctx:         context.Background(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.ctx, r.cancel = context.WithCancel(r.ctx)
+	r.refreshLock.Lock()
+	maxAge, err := r.refresh(r.ctx)
+	r.refreshLock.Unlock()
+	if err != nil {
+		r.cancel()
+		return nil, err
+	}
+	go r.run(maxAge)
+	return r, nil
+}
+
+// SelectKeys returns all the keys, it is used to validate tokens that do not have a "kid" header.
+func (r *JWKSResolver) SelectKeys(req *http.Request) []Key {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.keys
+}
+
+// SelectKeysByID returns the keys with the given ID. It refreshes the keys first if there is none
+// with the ID and the keys were not refreshed recently.
+func (r *JWKSResolver) SelectKeysByID(req *http.Request, kid string) []Key {
+	if keys := r.lookup(kid); keys != nil {
+		return keys
+	}
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+	if keys := r.lookup(kid); keys != nil {
+		return keys // refreshed while waiting for the lock
+	}
+	if time.Since(r.fetchedAt) < r.minInterval {
+		return nil
+	}
+	if _, err := r.refresh(req.Context()); err != nil {
+		goa.LogError(r.ctx, "failed to refresh JWKS", "url", r.url, "err", err)
+	}
+	return r.lookup(kid)
+}
+
+// Close stops refreshing the keys.
+func (r *JWKSResolver) Close() {
+	r.cancel()
+}
+
+// lookup returns the keys with the given ID.
+func (r *JWKSResolver) lookup(kid string) []Key {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.ids[kid]
+}
+
+// run refreshes the keys until the resolver is closed. It retries failed refreshes with an
+// exponential backoff.
+func (r *JWKSResolver) run(maxAge time.Duration) {
+	wait := r.nextRefresh(maxAge)
+	var backoff time.Duration
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		r.refreshLock.Lock()
+		maxAge, err := r.refresh(r.ctx)
+		r.refreshLock.Unlock()
+		if err != nil {
+			if r.ctx.Err() != nil {
+				return
+			}
+			goa.LogError(r.ctx, "failed to refresh JWKS", "url", r.url, "err", err)
+			backoff *= 2
+			if backoff < minJWKSBackoff {
+				backoff = minJWKSBackoff
+			}
+			if backoff > r.interval {
+				backoff = r.interval
+			}
+			wait = backoff
+			continue
+		}
+		backoff = 0
+		wait = r.nextRefresh(maxAge)
+	}
+}
+
+// nextRefresh returns the delay until the next refresh given the max-age of the last document.
+func (r *JWKSResolver) nextRefresh(maxAge time.Duration) time.Duration {
+	wait := r.interval
+	if maxAge > 0 && maxAge < wait {
+		wait = maxAge
+	}
+	if wait < r.minInterval {
+		wait = r.minInterval
+	}
+	return wait
+}
+
+// refresh retrieves the JWKS document and replaces the keys. It returns the max-age of the
+// document if any. The caller must hold refreshLock.
+func (r *JWKSResolver) refresh(ctx context.Context) (time.Duration, error) {
+	r.fetchedAt = time.Now()
+	req, err := http.NewRequest("GET", r.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	maxAge := parseMaxAge(resp.Header.Get("Cache-Control"))
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return maxAge, nil
+	default:
+		return 0, fmt.Errorf("GET %s: %s", r.url, resp.Status)
+	}
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSLength)).Decode(&set); err != nil {
+		return 0, fmt.Errorf("invalid JWKS document: %s", err)
+	}
+	var keys []Key
+	ids := make(map[string][]Key)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.key()
+		if err != nil {
+			goa.LogError(r.ctx, "skipping JWKS key", "url", r.url, "kid", k.Kid, "err", err)
+			continue
+		}
+		keys = append(keys, key)
+		if k.Kid != "" {
+			ids[k.Kid] = append(ids[k.Kid], key)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, errors.New("JWKS document does not contain any usable key")
+	}
+	r.lock.Lock()
+	r.keys, r.ids = keys, ids
+	r.lock.Unlock()
+	r.etag = resp.Header.Get("ETag")
+	return maxAge, nil
+}
+
+// key returns the public key (or secret for oct keys) described by the JWK.
+func (k *jwk) key() (Key, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		if k.K == "" {
+			return nil, errors.New("missing key value")
+		}
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// parseMaxAge returns the max-age directive of the given Cache-Control header value.
+func parseMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(strings.ToLower(directive), "max-age=") {
+			continue
+		}
+		secs, err := strconv.Atoi(directive[len("max-age="):])
+		if err != nil || secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
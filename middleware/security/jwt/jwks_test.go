@@ -0,0 +1,209 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	jwtpkg "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/security/jwt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWKSResolver", func() {
+	var rsaKey, rotatedKey *rsa.PrivateKey
+	var ecKey *ecdsa.PrivateKey
+	var secret = []byte("keys")
+
+	var lock sync.Mutex
+	var keys []map[string]string
+	var status int
+	var fetches int
+	var server *httptest.Server
+
+	var resolver *jwt.JWKSResolver
+	var opts []jwt.JWKSOption
+	var newErr error
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	rsaJWK := func(kid string, key *rsa.PrivateKey) map[string]string {
+		return map[string]string{
+			"kty": "RSA", "kid": kid, "use": "sig",
+			"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+		}
+	}
+
+	setKeys := func(ks ...map[string]string) {
+		lock.Lock()
+		defer lock.Unlock()
+		keys = ks
+	}
+
+	setStatus := func(code int) {
+		lock.Lock()
+		defer lock.Unlock()
+		status = code
+	}
+
+	fetchCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return fetches
+	}
+
+	sign := func(method jwtpkg.SigningMethod, kid string, key interface{}) string {
+		token := jwtpkg.NewWithClaims(method, jwtpkg.MapClaims{"sub": "me"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		Ω(err).ShouldNot(HaveOccurred())
+		return signed
+	}
+
+	validate := func(token string) error {
+		scheme := &goa.JWTSecurity{In: goa.LocHeader, Name: "Authorization"}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler := func(context.Context, http.ResponseWriter, *http.Request) error { return nil }
+		return jwt.New(resolver, nil, scheme)(handler)(context.Background(), httptest.NewRecorder(), req)
+	}
+
+	BeforeEach(func() {
+		var err error
+		if rsaKey == nil {
+			rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+			Ω(err).ShouldNot(HaveOccurred())
+			rotatedKey, err = rsa.GenerateKey(rand.Reader, 2048)
+			Ω(err).ShouldNot(HaveOccurred())
+			ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		setKeys(
+			rsaJWK("rsa", rsaKey),
+			map[string]string{
+				"kty": "EC", "kid": "ec", "crv": "P-256",
+				"x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes()),
+			},
+			map[string]string{"kty": "oct", "kid": "oct", "k": b64(secret)},
+			map[string]string{"kty": "RSA", "kid": "enc", "use": "enc"},
+			map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AA"},
+		)
+		status = http.StatusOK
+		fetches = 0
+		opts = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			fetches++
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			body, _ := json.Marshal(map[string]interface{}{"keys": keys})
+			sum := sha256.Sum256(body)
+			etag := `"` + b64(sum[:]) + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Write(body)
+		}))
+	})
+
+	JustBeforeEach(func() {
+		resolver, newErr = jwt.NewJWKSResolver(server.URL, opts...)
+	})
+
+	AfterEach(func() {
+		if resolver != nil {
+			resolver.Close()
+		}
+		server.Close()
+	})
+
+	It("validates tokens signed with RSA, EC and oct keys", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		Ω(resolver.SelectKeys(nil)).Should(HaveLen(3))
+		Ω(validate(sign(jwtpkg.SigningMethodRS256, "rsa", rsaKey))).Should(Succeed())
+		Ω(validate(sign(jwtpkg.SigningMethodES256, "ec", ecKey))).Should(Succeed())
+		Ω(validate(sign(jwtpkg.SigningMethodHS256, "oct", secret))).Should(Succeed())
+		Ω(validate(sign(jwtpkg.SigningMethodRS256, "", rsaKey))).Should(Succeed())
+	})
+
+	It("only uses the key with the token ID", func() {
+		Ω(validate(sign(jwtpkg.SigningMethodRS256, "ec", rsaKey))).Should(HaveOccurred())
+	})
+
+	It("rate limits refreshes caused by unknown key IDs", func() {
+		setKeys(rsaJWK("rotated", rotatedKey))
+		Ω(validate(sign(jwtpkg.SigningMethodRS256, "rotated", rotatedKey))).Should(HaveOccurred())
+		Ω(fetchCount()).Should(Equal(1))
+	})
+
+	Context("with no minimum refresh interval", func() {
+		BeforeEach(func() {
+			opts = []jwt.JWKSOption{jwt.WithJWKSMinRefreshInterval(0)}
+		})
+
+		It("refreshes the keys when the token ID is unknown", func() {
+			setKeys(rsaJWK("rotated", rotatedKey))
+			Ω(validate(sign(jwtpkg.SigningMethodRS256, "rotated", rotatedKey))).Should(Succeed())
+			Ω(fetchCount()).Should(Equal(2))
+			Ω(validate(sign(jwtpkg.SigningMethodRS256, "rsa", rsaKey))).Should(HaveOccurred())
+		})
+
+		It("keeps the keys when the refresh fails", func() {
+			setStatus(http.StatusInternalServerError)
+			Ω(validate(sign(jwtpkg.SigningMethodRS256, "unknown", rotatedKey))).Should(HaveOccurred())
+			Ω(fetchCount()).Should(Equal(2))
+			Ω(validate(sign(jwtpkg.SigningMethodRS256, "rsa", rsaKey))).Should(Succeed())
+		})
+
+		It("keeps the keys when the document is not modified", func() {
+			Ω(validate(sign(jwtpkg.SigningMethodRS256, "unknown", rotatedKey))).Should(HaveOccurred())
+			Ω(fetchCount()).Should(Equal(2))
+			Ω(validate(sign(jwtpkg.SigningMethodRS256, "rsa", rsaKey))).Should(Succeed())
+		})
+	})
+
+	Context("with a short refresh interval", func() {
+		BeforeEach(func() {
+			opts = []jwt.JWKSOption{
+				jwt.WithJWKSRefreshInterval(10 * time.Millisecond),
+				jwt.WithJWKSMinRefreshInterval(0),
+			}
+		})
+
+		It("refreshes the keys in the background", func() {
+			setKeys(rsaJWK("rotated", rotatedKey))
+			Eventually(func() []jwt.Key { return resolver.SelectKeys(nil) }).Should(Equal([]jwt.Key{&rotatedKey.PublicKey}))
+			Ω(fetchCount()).Should(BeNumerically(">", 1))
+		})
+	})
+
+	Context("with a failing endpoint", func() {
+		BeforeEach(func() {
+			status = http.StatusNotFound
+		})
+
+		It("returns an error", func() {
+			Ω(newErr).Should(HaveOccurred())
+			Ω(resolver).Should(BeNil())
+		})
+	})
+})
@@ -25,7 +25,10 @@ import (
 //        against the scopes presented by the JWT in the claim "scope", or if
 //        that's not defined, "scopes".
 //
-// The `exp` (expiration) and `nbf` (not before) date checks are validated by the JWT library.
+// The `exp` (expiration), `nbf` (not before) and `iat` (issued at) date checks are always
+// validated. The options returned by WithIssuers, WithAudiences, WithLeeway, WithAlgorithms and
+// WithRequiredClaims configure additional claim validations. The issuers and audiences default to
+// the ones listed in the security scheme, see the Issuer and Audience DSLs.
 //
 // validationKeys can be one of these:
 //
@@ -59,7 +62,23 @@ import (
 //    jwtResolver, _ := jwt.NewSimpleResolver("secret")
 //    app.UseJWT(jwt.New(jwtResolver, validationHandler, app.NewJWTSecurity()))
 //
-func New(resolver KeyResolver, validationFunc goa.Middleware, scheme *goa.JWTSecurity) goa.Middleware {
+// or with claim validation options:
+//
+//    app.UseJWT(jwt.New(jwtResolver, nil, app.NewJWTSecurity(),
+//        jwt.WithLeeway(30*time.Second),
+//        jwt.WithRequiredClaims("sub")))
+//
+// Use NewJWKSResolver to validate tokens issued by an identity provider that publishes its keys
+// as a JWKS document:
+//
+//    jwksResolver, _ := jwt.NewJWKSResolver("https://idp.example.com/.well-known/jwks.json")
+//    app.UseJWT(jwt.New(jwksResolver, nil, app.NewJWTSecurity()))
+//
+func New(resolver KeyResolver, validationFunc goa.Middleware, scheme *goa.JWTSecurity, opts ...Option) goa.Middleware {
+	o := &options{issuers: scheme.Issuers, audiences: scheme.Audiences}
+	for _, opt := range opts {
+		opt(o)
+	}
 	return func(nextHandler goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 			var (
@@ -81,7 +100,15 @@ func New(resolver KeyResolver, validationFunc goa.Middleware, scheme *goa.JWTSec
 				return fmt.Errorf("whoops, security scheme with location (in) %q not supported", scheme.In)
 			}
 
-			rsaKeys, ecdsaKeys, hmacKeys := partitionKeys(resolver.SelectKeys(req))
+			unverified, _, err := new(jwt.Parser).ParseUnverified(incomingToken, jwt.MapClaims{})
+			if err != nil {
+				return ErrJWTError("JWT validation failed")
+			}
+			if err := o.validateAlgorithm(unverified); err != nil {
+				return err
+			}
+
+			rsaKeys, ecdsaKeys, hmacKeys := partitionKeys(selectKeys(resolver, req, unverified))
 
 			var (
 				token     *jwt.Token
@@ -113,6 +140,10 @@ func New(resolver KeyResolver, validationFunc goa.Middleware, scheme *goa.JWTSec
 				return ErrJWTError("JWT validation failed")
 			}
 
+			if err := o.validateClaims(token); err != nil {
+				return err
+			}
+
 			scopesInClaim, scopesInClaimList, err := parseClaimScopes(token)
 			if err != nil {
 				goa.LogError(ctx, err.Error())
@@ -161,6 +192,17 @@ func extractTokenFromQueryParam(schemeName string, req *http.Request) (string, e
 	return incomingToken, nil
 }
 
+// selectKeys returns the keys used to validate the incoming token. It uses the token "kid" header
+// to select the keys if the resolver is a KeyIDResolver.
+func selectKeys(resolver KeyResolver, req *http.Request, token *jwt.Token) []Key {
+	if r, ok := resolver.(KeyIDResolver); ok {
+		if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+			return r.SelectKeysByID(req, kid)
+		}
+	}
+	return resolver.SelectKeys(req)
+}
+
 // partitionKeys sorts keys by their type.
 func partitionKeys(keys []Key) ([]*rsa.PublicKey, []*ecdsa.PublicKey, [][]byte) {
 	var (
@@ -185,6 +227,10 @@ func partitionKeys(keys []Key) ([]*rsa.PublicKey, []*ecdsa.PublicKey, [][]byte)
 	return rsaKeys, ecdsaKeys, hmacKeys
 }
 
+// parser parses and validates the signature of the incoming tokens, the claims are validated
+// by options.validateClaims.
+var parser = &jwt.Parser{SkipClaimsValidation: true}
+
 // validScopeClaimKeys are the claims under which scopes may be found in a token
 var validScopeClaimKeys = []string{"scope", "scopes"}
 
@@ -230,7 +276,7 @@ func parseClaimScopes(token *jwt.Token) (map[string]bool, []string, error) {
 
 func validateRSAKeys(rsaKeys []*rsa.PublicKey, algo, incomingToken string) (token *jwt.Token, err error) {
 	for _, pubkey := range rsaKeys {
-		token, err = jwt.Parse(incomingToken, func(token *jwt.Token) (interface{}, error) {
+		token, err = parser.Parse(incomingToken, func(token *jwt.Token) (interface{}, error) {
 			if !strings.HasPrefix(token.Method.Alg(), algo) {
 				return nil, ErrJWTError(fmt.Sprintf("Unexpected signing method: %v", token.Header["alg"]))
 			}
@@ -245,7 +291,7 @@ func validateRSAKeys(rsaKeys []*rsa.PublicKey, algo, incomingToken string) (toke
 
 func validateECDSAKeys(ecdsaKeys []*ecdsa.PublicKey, algo, incomingToken string) (token *jwt.Token, err error) {
 	for _, pubkey := range ecdsaKeys {
-		token, err = jwt.Parse(incomingToken, func(token *jwt.Token) (interface{}, error) {
+		token, err = parser.Parse(incomingToken, func(token *jwt.Token) (interface{}, error) {
 			if !strings.HasPrefix(token.Method.Alg(), algo) {
 				return nil, ErrJWTError(fmt.Sprintf("Unexpected signing method: %v", token.Header["alg"]))
 			}
@@ -260,7 +306,7 @@ func validateECDSAKeys(ecdsaKeys []*ecdsa.PublicKey, algo, incomingToken string)
 
 func validateHMACKeys(hmacKeys [][]byte, algo, incomingToken string) (token *jwt.Token, err error) {
 	for _, key := range hmacKeys {
-		token, err = jwt.Parse(incomingToken, func(token *jwt.Token) (interface{}, error) {
+		token, err = parser.Parse(incomingToken, func(token *jwt.Token) (interface{}, error) {
 			if !strings.HasPrefix(token.Method.Alg(), algo) {
 				return nil, ErrJWTError(fmt.Sprintf("Unexpected signing method: %v", token.Header["alg"]))
 			}
@@ -23,6 +23,15 @@ type (
 		SelectKeys(req *http.Request) []Key
 	}
 
+	// KeyIDResolver is implemented by key resolvers that can select keys using the ID found in
+	// the "kid" header of the incoming token. The middleware calls SelectKeysByID instead of
+	// SelectKeys for tokens that have a "kid" header.
+	KeyIDResolver interface {
+		KeyResolver
+		// SelectKeysByID returns the keys with the given ID.
+		SelectKeysByID(req *http.Request, kid string) []Key
+	}
+
 	// GroupResolver is a key resolver that switches on the value of a specified request header
 	// for selecting the key group used to authorize the incoming request.
 	GroupResolver struct {
@@ -0,0 +1,222 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultCacheTTL is the default maximum duration during which the introspection result of
+	// an active token is reused.
+	DefaultCacheTTL = 5 * time.Minute
+
+	// DefaultCacheSize is the default maximum number of introspection results kept in the cache.
+	DefaultCacheSize = 10000
+
+	// maxIntrospectionLength is the maximum length of an introspection response.
+	maxIntrospectionLength int64 = 1 << 20
+)
+
+type (
+	// Introspection is the result of a token introspection as defined by RFC 7662 section 2.2.
+	Introspection struct {
+		// Active indicates whether the token is currently active.
+		Active bool `json:"active"`
+		// Scope is the space-separated list of scopes associated with the token.
+		Scope string `json:"scope,omitempty"`
+		// ClientID is the identifier of the client that requested the token.
+		ClientID string `json:"client_id,omitempty"`
+		// Username identifies the resource owner who authorized the token.
+		Username string `json:"username,omitempty"`
+		// TokenType is the type of the token, e.g. "Bearer".
+		TokenType string `json:"token_type,omitempty"`
+		// Exp is the time at which the token expires in seconds since the epoch.
+		Exp int64 `json:"exp,omitempty"`
+		// Iat is the time at which the token was issued in seconds since the epoch.
+		Iat int64 `json:"iat,omitempty"`
+		// Nbf is the time before which the token must not be used in seconds since the epoch.
+		Nbf int64 `json:"nbf,omitempty"`
+		// Sub is the subject of the token, usually the resource owner ID.
+		Sub string `json:"sub,omitempty"`
+		// Aud lists the intended audiences of the token.
+		Aud Audience `json:"aud,omitempty"`
+		// Iss is the issuer of the token.
+		Iss string `json:"iss,omitempty"`
+		// Jti is the identifier of the token.
+		Jti string `json:"jti,omitempty"`
+	}
+
+	// Audience is the value of the "aud" member of an introspection response which may be a
+	// string or a list of strings.
+	Audience []string
+
+	// Introspector retrieves and caches the state of access tokens from an OAuth2 authorization
+	// server introspection endpoint.
+	Introspector struct {
+		endpoint     string
+		clientID     string
+		clientSecret string
+		client       *http.Client
+		ttl          time.Duration
+		size         int
+
+		lock  sync.Mutex
+		cache map[[sha256.Size]byte]*cacheEntry
+	}
+
+	// Option configures an Introspector.
+	Option func(*Introspector)
+
+	// cacheEntry is a cached introspection result.
+	cacheEntry struct {
+		introspection *Introspection
+		expiresAt     time.Time
+	}
+)
+
+// WithClient sets the HTTP client used to call the introspection endpoint, defaults to
+// http.DefaultClient.
+func WithClient(client *http.Client) Option {
+	return func(i *Introspector) {
+		i.client = client
+	}
+}
+
+// WithCacheTTL sets the maximum duration during which the introspection result of an active token
+// is reused. Results are never reused past the token expiration. 0 disables caching. Defaults to
+// DefaultCacheTTL.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(i *Introspector) {
+		i.ttl = ttl
+	}
+}
+
+// WithCacheSize sets the maximum number of introspection results kept in the cache. Defaults to
+// DefaultCacheSize.
+func WithCacheSize(size int) Option {
+	return func(i *Introspector) {
+		i.size = size
+	}
+}
+
+// NewIntrospector returns an introspector that calls the introspection endpoint at the given URL
+// authenticating with the given client credentials using HTTP basic authentication.
+func NewIntrospector(endpoint, clientID, clientSecret string, opts ...Option) *Introspector {
+	i := &Introspector{
+		endpoint:     endpoint,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       http.DefaultClient,
+		ttl:          DefaultCacheTTL,
+		size:         DefaultCacheSize,
+		cache:        make(map[[sha256.Size]byte]*cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Introspect returns the state of the given access token. It returns the cached result if the
+// token was found active recently and has not expired since.
+func (i *Introspector) Introspect(ctx context.Context, token string) (*Introspection, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+	i.lock.Lock()
+	if e, ok := i.cache[key]; ok {
+		if now.Before(e.expiresAt) {
+			i.lock.Unlock()
+			return e.introspection, nil
+		}
+		delete(i.cache, key)
+	}
+	i.lock.Unlock()
+
+	res, err := i.introspect(ctx, token)
+	if err != nil || !res.Active || i.ttl <= 0 || i.size <= 0 {
+		return res, err
+	}
+	expiresAt := now.Add(i.ttl)
+	if res.Exp > 0 {
+		if exp := time.Unix(res.Exp, 0); exp.Before(expiresAt) {
+			expiresAt = exp
+		}
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if len(i.cache) >= i.size {
+		i.evict(now)
+	}
+	i.cache[key] = &cacheEntry{introspection: res, expiresAt: expiresAt}
+	return res, nil
+}
+
+// introspect calls the introspection endpoint.
+func (i *Introspector) introspect(ctx context.Context, token string) (*Introspection, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequest("POST", i.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(i.clientID), url.QueryEscape(i.clientSecret))
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("POST %s: %s", i.endpoint, resp.Status)
+	}
+	var res Introspection
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxIntrospectionLength)).Decode(&res); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %s", err)
+	}
+	return &res, nil
+}
+
+// evict removes the expired entries from the cache, it removes arbitrary entries if the cache is
+// still full afterwards. The caller must hold the lock.
+func (i *Introspector) evict(now time.Time) {
+	for key, e := range i.cache {
+		if !now.Before(e.expiresAt) {
+			delete(i.cache, key)
+		}
+	}
+	for key := range i.cache {
+		if len(i.cache) < i.size {
+			break
+		}
+		delete(i.cache, key)
+	}
+}
+
+// Scopes returns the list of scopes associated with the token.
+func (i *Introspection) Scopes() []string {
+	return strings.Fields(i.Scope)
+}
+
+// UnmarshalJSON accepts a string or a list of strings.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(data, &l); err != nil {
+		return err
+	}
+	*a = Audience(l)
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Package oauth2 provides a middleware that validates the opaque bearer tokens of requests made to
+actions secured with the OAuth2Security DSL.
+
+The middleware calls the token introspection endpoint (RFC 7662) of the authorization server to
+retrieve the state and scopes of the token and caches the results of active tokens.
+*/
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// ErrOAuth2Error is the error returned by this middleware when the access token is missing,
+// inactive or does not grant the required scopes.
+var ErrOAuth2Error = goa.NewErrorClass("oauth2_security_error", 401)
+
+// New returns a middleware to be used with the OAuth2Security DSL definitions of goa. The steps
+// taken by the middleware are:
+//
+//  1. Extract the "Bearer" token from the Authorization header
+//  2. Introspect the token and validate that it is active
+//  3. If scopes are defined in the design for the action, validate that the token grants them
+//
+// The introspection result is then stored in the request context, use ContextIntrospection to
+// retrieve it. Mount the middleware with the generated UseXX function where XX is the name of the
+// scheme as defined in the design, e.g.:
+//
+//	introspector := oauth2.NewIntrospector("https://auth.example.com/introspect", "id", "secret")
+//	app.UseOAuth2Middleware(service, oauth2.New(introspector))
+func New(introspector *Introspector) goa.Middleware {
+	return func(nextHandler goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			val := req.Header.Get("Authorization")
+			if val == "" {
+				return ErrOAuth2Error(`missing header "Authorization"`)
+			}
+			if !strings.HasPrefix(strings.ToLower(val), "bearer ") {
+				return ErrOAuth2Error("invalid or malformed \"Authorization\" header, expected 'Bearer token...'")
+			}
+			token := strings.TrimSpace(val[len("bearer "):])
+			if token == "" {
+				return ErrOAuth2Error("missing bearer token")
+			}
+
+			introspection, err := introspector.Introspect(ctx, token)
+			if err != nil {
+				goa.LogError(ctx, "token introspection failed", "err", err)
+				return err
+			}
+			if !introspection.Active {
+				return ErrOAuth2Error("inactive token")
+			}
+
+			scopes := introspection.Scopes()
+			granted := make(map[string]bool, len(scopes))
+			for _, scope := range scopes {
+				granted[scope] = true
+			}
+			requiredScopes := goa.ContextRequiredScopes(ctx)
+			for _, scope := range requiredScopes {
+				if !granted[scope] {
+					sort.Strings(scopes)
+					msg := "authorization failed: required scopes not granted to access token"
+					return ErrOAuth2Error(msg, "required", requiredScopes, "scopes", scopes)
+				}
+			}
+
+			return nextHandler(WithIntrospection(ctx, introspection), rw, req)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package oauth2_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/security/oauth2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("New", func() {
+	var lock sync.Mutex
+	var tokens map[string]map[string]interface{}
+	var calls int
+	var server *httptest.Server
+	var opts []oauth2.Option
+
+	var ctx context.Context
+	var req *http.Request
+	var introspection *oauth2.Introspection
+
+	callCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls
+	}
+
+	BeforeEach(func() {
+		tokens = map[string]map[string]interface{}{
+			"valid": {
+				"active":    true,
+				"scope":     "api:read api:write",
+				"client_id": "app",
+				"sub":       "me",
+				"aud":       "api",
+				"exp":       time.Now().Add(time.Hour).Unix(),
+			},
+			"readonly": {"active": true, "scope": "api:read", "aud": []string{"api", "web"}},
+			"expiring": {"active": true, "exp": time.Now().Add(-time.Second).Unix()},
+		}
+		calls = 0
+		opts = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			calls++
+			if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Method != "POST" || r.PostFormValue("token_type_hint") != "access_token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			res, ok := tokens[r.PostFormValue("token")]
+			if !ok {
+				res = map[string]interface{}{"active": false}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(res)
+		}))
+		ctx = context.Background()
+		req = httptest.NewRequest("GET", "/", nil)
+		introspection = nil
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	serve := func(token string) error {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			introspection = oauth2.ContextIntrospection(ctx)
+			return nil
+		}
+		introspector := oauth2.NewIntrospector(server.URL, "client", "secret", opts...)
+		return oauth2.New(introspector)(handler)(ctx, httptest.NewRecorder(), req)
+	}
+
+	unauthorized := func(err error) {
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(http.StatusUnauthorized))
+		Ω(introspection).Should(BeNil())
+	}
+
+	It("accepts active tokens", func() {
+		ctx = goa.WithRequiredScopes(ctx, []string{"api:write"})
+		Ω(serve("valid")).Should(Succeed())
+		Ω(introspection).ShouldNot(BeNil())
+		Ω(introspection.Sub).Should(Equal("me"))
+		Ω(introspection.ClientID).Should(Equal("app"))
+		Ω(introspection.Aud).Should(Equal(oauth2.Audience{"api"}))
+		Ω(introspection.Scopes()).Should(Equal([]string{"api:read", "api:write"}))
+	})
+
+	It("parses lists of audiences", func() {
+		Ω(serve("readonly")).Should(Succeed())
+		Ω(introspection.Aud).Should(Equal(oauth2.Audience{"api", "web"}))
+	})
+
+	It("rejects inactive tokens", func() {
+		unauthorized(serve("revoked"))
+	})
+
+	It("rejects missing tokens", func() {
+		unauthorized(serve(""))
+	})
+
+	It("rejects tokens that do not grant the required scopes", func() {
+		ctx = goa.WithRequiredScopes(ctx, []string{"api:write"})
+		unauthorized(serve("readonly"))
+	})
+
+	It("returns introspection errors", func() {
+		server.Close()
+		Ω(serve("valid")).Should(HaveOccurred())
+	})
+
+	Describe("Introspector", func() {
+		var introspector *oauth2.Introspector
+
+		JustBeforeEach(func() {
+			introspector = oauth2.NewIntrospector(server.URL, "client", "secret", opts...)
+		})
+
+		It("caches active tokens", func() {
+			for i := 0; i < 3; i++ {
+				res, err := introspector.Introspect(ctx, "valid")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(res.Active).Should(BeTrue())
+			}
+			Ω(callCount()).Should(Equal(1))
+		})
+
+		It("does not cache inactive tokens", func() {
+			introspector.Introspect(ctx, "revoked")
+			introspector.Introspect(ctx, "revoked")
+			Ω(callCount()).Should(Equal(2))
+		})
+
+		It("does not cache tokens past their expiration", func() {
+			introspector.Introspect(ctx, "expiring")
+			introspector.Introspect(ctx, "expiring")
+			Ω(callCount()).Should(Equal(2))
+		})
+
+		Context("with a small cache", func() {
+			BeforeEach(func() {
+				opts = []oauth2.Option{oauth2.WithCacheSize(1)}
+			})
+
+			It("evicts results", func() {
+				introspector.Introspect(ctx, "valid")
+				introspector.Introspect(ctx, "readonly")
+				introspector.Introspect(ctx, "valid")
+				Ω(callCount()).Should(Equal(3))
+			})
+		})
+
+		Context("with caching disabled", func() {
+			BeforeEach(func() {
+				opts = []oauth2.Option{oauth2.WithCacheTTL(0)}
+			})
+
+			It("calls the endpoint for every request", func() {
+				introspector.Introspect(ctx, "valid")
+				introspector.Introspect(ctx, "valid")
+				Ω(callCount()).Should(Equal(2))
+			})
+		})
+
+		Context("with invalid client credentials", func() {
+			It("returns an error", func() {
+				introspector = oauth2.NewIntrospector(server.URL, "client", "guess")
+				_, err := introspector.Introspect(ctx, "valid")
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+})
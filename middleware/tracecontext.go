@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// TraceParentHeader is the name of the W3C Trace Context HTTP request header containing
+	// the trace ID, the parent span ID and the trace flags.
+	TraceParentHeader = "traceparent"
+
+	// TraceStateHeader is the name of the W3C Trace Context HTTP request header containing
+	// the vendor specific trace state.
+	TraceStateHeader = "tracestate"
+)
+
+// TraceFlagSampled is the traceparent flag set when the caller may have recorded the trace.
+const TraceFlagSampled byte = 0x01
+
+// TraceParent is the content of a W3C traceparent header, see
+// https://www.w3.org/TR/trace-context/#traceparent-header.
+type TraceParent struct {
+	// Version is the version of the header format.
+	Version byte
+	// TraceID is the trace ID encoded as 32 lower case hexadecimal characters.
+	TraceID string
+	// ParentID is the ID of the caller span encoded as 16 lower case hexadecimal characters.
+	ParentID string
+	// Flags contains the trace flags, see TraceFlagSampled.
+	Flags byte
+}
+
+// ParseTraceParent parses the value of a traceparent header. Values that use a version greater
+// than 0 are parsed using the version 0 format as mandated by the specification.
+func ParseTraceParent(h string) (*TraceParent, error) {
+	h = strings.TrimSpace(h)
+	if len(h) < 55 || h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return nil, fmt.Errorf("invalid traceparent %q", h)
+	}
+	version, err := parseHexByte(h[:2])
+	if err != nil || version == 0xff {
+		return nil, fmt.Errorf("invalid traceparent version %q", h[:2])
+	}
+	if (version == 0 && len(h) != 55) || (len(h) > 55 && h[55] != '-') {
+		return nil, fmt.Errorf("invalid traceparent %q", h)
+	}
+	traceID, parentID := h[3:35], h[36:52]
+	if !isTraceContextID(traceID, 32) {
+		return nil, fmt.Errorf("invalid traceparent trace ID %q", traceID)
+	}
+	if !isTraceContextID(parentID, 16) {
+		return nil, fmt.Errorf("invalid traceparent parent ID %q", parentID)
+	}
+	flags, err := parseHexByte(h[53:55])
+	if err != nil {
+		return nil, fmt.Errorf("invalid traceparent flags %q", h[53:55])
+	}
+	return &TraceParent{Version: version, TraceID: traceID, ParentID: parentID, Flags: flags}, nil
+}
+
+// Sampled returns true if the sampled flag is set.
+func (tp *TraceParent) Sampled() bool {
+	return tp.Flags&TraceFlagSampled != 0
+}
+
+// String returns the traceparent header value using the version 0 format.
+func (tp *TraceParent) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tp.TraceID, tp.ParentID, tp.Flags)
+}
+
+// NewW3CTraceID is a trace ID creation algorithm which produces values that are compatible with
+// the W3C Trace Context specification.
+func NewW3CTraceID() string {
+	return randomHex(16)
+}
+
+// NewW3CSpanID is a span ID creation algorithm which produces values that are compatible with
+// the W3C Trace Context specification.
+func NewW3CSpanID() string {
+	return randomHex(8)
+}
+
+// WithTraceContext returns a context that records whether the current trace is sampled and the
+// W3C trace state propagated to downstream services by TraceDoer. Traces initialized with
+// WithTrace alone are sampled.
+func WithTraceContext(ctx context.Context, sampled bool, state string) context.Context {
+	ctx = context.WithValue(ctx, traceSampledKey, sampled)
+	if state != "" {
+		ctx = context.WithValue(ctx, traceStateKey, state)
+	}
+	return ctx
+}
+
+// ContextTraceSampled returns true if the context contains a trace that is sampled, that is a
+// trace that should be recorded.
+func ContextTraceSampled(ctx context.Context) bool {
+	if ContextTraceID(ctx) == "" {
+		return false
+	}
+	if s := ctx.Value(traceSampledKey); s != nil {
+		return s.(bool)
+	}
+	return true
+}
+
+// ContextTraceState returns the W3C trace state extracted from the given context if any, the
+// empty string otherwise.
+func ContextTraceState(ctx context.Context) string {
+	if s := ctx.Value(traceStateKey); s != nil {
+		return s.(string)
+	}
+	return ""
+}
+
+// contextTraceParent returns the traceparent of the requests made in the context of the current
+// span, nil if the trace or span IDs cannot be represented in a traceparent header. AWS X-Ray
+// trace IDs are converted to the W3C format.
+func contextTraceParent(ctx context.Context) *TraceParent {
+	traceID, spanID := ContextTraceID(ctx), ContextSpanID(ctx)
+	if !isTraceContextID(traceID, 32) {
+		// X-Ray trace IDs are of the form 1-<8 hex digits>-<24 hex digits>.
+		elems := strings.Split(traceID, "-")
+		if len(elems) != 3 || elems[0] != "1" || len(elems[1]) != 8 || len(elems[2]) != 24 {
+			return nil
+		}
+		traceID = elems[1] + elems[2]
+		if !isTraceContextID(traceID, 32) {
+			return nil
+		}
+	}
+	if !isTraceContextID(spanID, 16) {
+		return nil
+	}
+	tp := &TraceParent{TraceID: traceID, ParentID: spanID}
+	if ContextTraceSampled(ctx) {
+		tp.Flags = TraceFlagSampled
+	}
+	return tp
+}
+
+// isTraceContextID returns true if id is a valid W3C trace or span ID: a non-zero lower case
+// hexadecimal value of n characters.
+func isTraceContextID(id string, n int) bool {
+	return len(id) == n && isLowerHex(id) && strings.Trim(id, "0") != ""
+}
+
+// parseHexByte parses a byte encoded as two lower case hexadecimal characters.
+func parseHexByte(s string) (byte, error) {
+	if !isLowerHex(s) {
+		return 0, fmt.Errorf("invalid hexadecimal value %q", s)
+	}
+	b, err := strconv.ParseUint(s, 16, 8)
+	return byte(b), err
+}
+
+// isLowerHex returns true if s only contains lower case hexadecimal characters.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHex returns n random bytes encoded in lower case hexadecimal.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		rand.Read(b)
+		for _, c := range b {
+			if c != 0 {
+				return fmt.Sprintf("%x", b)
+			}
+		}
+	}
+}
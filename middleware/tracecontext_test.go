@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+	cases := map[string]struct {
+		Header  string
+		Valid   bool
+		Sampled bool
+	}{
+		"sampled":          {"00-" + traceID + "-" + parentID + "-01", true, true},
+		"not-sampled":      {"00-" + traceID + "-" + parentID + "-00", true, false},
+		"future-version":   {"cc-" + traceID + "-" + parentID + "-01-extra", true, true},
+		"empty":            {"", false, false},
+		"invalid-version":  {"ff-" + traceID + "-" + parentID + "-01", false, false},
+		"v0-extra":         {"00-" + traceID + "-" + parentID + "-01-extra", false, false},
+		"upper-case":       {"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + parentID + "-01", false, false},
+		"zero-trace-id":    {"00-00000000000000000000000000000000-" + parentID + "-01", false, false},
+		"zero-parent-id":   {"00-" + traceID + "-0000000000000000-01", false, false},
+		"invalid-flags":    {"00-" + traceID + "-" + parentID + "-0x", false, false},
+		"invalid-separate": {"00_" + traceID + "-" + parentID + "-01", false, false},
+	}
+	for k, c := range cases {
+		tp, err := ParseTraceParent(c.Header)
+		if c.Valid != (err == nil) {
+			t.Errorf("%s: expected valid to be %v, got error %v", k, c.Valid, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tp.TraceID != traceID || tp.ParentID != parentID {
+			t.Errorf("%s: invalid IDs %s - %s", k, tp.TraceID, tp.ParentID)
+		}
+		if tp.Sampled() != c.Sampled {
+			t.Errorf("%s: expected sampled to be %v", k, c.Sampled)
+		}
+	}
+}
+
+func TestNewW3CIDs(t *testing.T) {
+	if id := NewW3CTraceID(); !regexp.MustCompile("^[0-9a-f]{32}$").MatchString(id) {
+		t.Errorf("invalid trace ID %s", id)
+	}
+	if id := NewW3CSpanID(); !regexp.MustCompile("^[0-9a-f]{16}$").MatchString(id) {
+		t.Errorf("invalid span ID %s", id)
+	}
+}
+
+func TestTraceContextMiddleware(t *testing.T) {
+	const (
+		traceID   = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID  = "00f067aa0ba902b7"
+		spanID    = "b7ad6b7169203331"
+		newTrace  = "0af7651916cd43dd8448eb211c80319c"
+		state     = "congo=t61rcWkgMzE"
+		sampled   = "00-" + traceID + "-" + parentID + "-01"
+		unsampled = "00-" + traceID + "-" + parentID + "-00"
+	)
+
+	cases := map[string]struct {
+		Rate                      int
+		TraceParent, State        string
+		LegacyTraceID             string
+		CtxTraceID, CtxParentID   string
+		CtxSampled                bool
+		CtxState, OutgoingTracing string
+	}{
+		"sampled":           {0, sampled, state, "", traceID, parentID, true, state, "00-" + traceID + "-" + spanID + "-01"},
+		"unsampled":         {100, unsampled, "", "", traceID, parentID, false, "", "00-" + traceID + "-" + spanID + "-00"},
+		"invalid":           {100, "invalid", state, "", newTrace, "", true, "", "00-" + newTrace + "-" + spanID + "-01"},
+		"legacy":            {0, "", "", "legacy", "legacy", "", true, "", ""},
+		"new-trace":         {100, "", "", "", newTrace, "", true, "", "00-" + newTrace + "-" + spanID + "-01"},
+		"new-trace-no-rate": {0, "", "", "", newTrace, "", false, "", "00-" + newTrace + "-" + spanID + "-00"},
+	}
+
+	for k, c := range cases {
+		var (
+			ctxTraceID, ctxParentID, ctxState string
+			ctxSampled                        bool
+			outgoing                          http.Header
+
+			m = NewTracer(TraceContext(), SamplingPercent(c.Rate),
+				TraceIDFunc(func() string { return newTrace }),
+				SpanIDFunc(func() string { return spanID }))
+			doer = TraceDoer(doFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				outgoing = req.Header
+				return nil, nil
+			}))
+			h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				ctxTraceID = ContextTraceID(ctx)
+				ctxParentID = ContextParentSpanID(ctx)
+				ctxSampled = ContextTraceSampled(ctx)
+				ctxState = ContextTraceState(ctx)
+				out, _ := http.NewRequest("GET", "/", nil)
+				doer.Do(ctx, out)
+				return nil
+			}
+		)
+		req, _ := http.NewRequest("GET", "/", nil)
+		if c.TraceParent != "" {
+			req.Header.Set(TraceParentHeader, c.TraceParent)
+		}
+		if c.State != "" {
+			req.Header.Set(TraceStateHeader, c.State)
+		}
+		if c.LegacyTraceID != "" {
+			req.Header.Set(TraceIDHeader, c.LegacyTraceID)
+		}
+
+		m(h)(context.Background(), httptest.NewRecorder(), req)
+
+		if ctxTraceID != c.CtxTraceID {
+			t.Errorf("%s: invalid TraceID, expected %v - got %v", k, c.CtxTraceID, ctxTraceID)
+		}
+		if ctxParentID != c.CtxParentID {
+			t.Errorf("%s: invalid ParentSpanID, expected %v - got %v", k, c.CtxParentID, ctxParentID)
+		}
+		if ctxSampled != c.CtxSampled {
+			t.Errorf("%s: invalid sampled flag, expected %v - got %v", k, c.CtxSampled, ctxSampled)
+		}
+		if ctxState != c.CtxState {
+			t.Errorf("%s: invalid trace state, expected %v - got %v", k, c.CtxState, ctxState)
+		}
+		if tp := outgoing.Get(TraceParentHeader); tp != c.OutgoingTracing {
+			t.Errorf("%s: invalid outgoing traceparent, expected %v - got %v", k, c.OutgoingTracing, tp)
+		}
+		if ts := outgoing.Get(TraceStateHeader); ts != c.CtxState {
+			t.Errorf("%s: invalid outgoing tracestate, expected %v - got %v", k, c.CtxState, ts)
+		}
+	}
+}
+
+func TestTraceDoerXRay(t *testing.T) {
+	var outgoing http.Header
+	doer := TraceDoer(doFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		outgoing = req.Header
+		return nil, nil
+	}))
+	ctx := WithTrace(context.Background(), "1-5759e988-bd862e3fe1be46a994272793", "53995c3f42cd8ad8", "")
+	req, _ := http.NewRequest("GET", "/", nil)
+	doer.Do(ctx, req)
+	expected := "00-5759e988bd862e3fe1be46a994272793-53995c3f42cd8ad8-01"
+	if tp := outgoing.Get(TraceParentHeader); tp != expected {
+		t.Errorf("invalid traceparent, expected %v - got %v", expected, tp)
+	}
+}
+
+// doFunc is a client.Doer implemented with a function.
+type doFunc func(context.Context, *http.Request) (*http.Response, error)
+
+func (f doFunc) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
@@ -34,6 +34,7 @@ type (
 		samplingPercent int
 		maxSamplingRate int
 		sampleSize      int
+		traceContext    bool
 	}
 
 	// tracedDoer is a goa client Doer that inserts the tracing headers for
@@ -106,6 +107,16 @@ func SampleSize(s int) TracerOption {
 	}
 }
 
+// TraceContext is a constructor option that enables the propagation of traces using the W3C
+// Trace Context traceparent and tracestate headers, see https://www.w3.org/TR/trace-context/.
+// Trace and span IDs default to W3C compatible values unless TraceIDFunc or SpanIDFunc is used.
+func TraceContext() TracerOption {
+	return func(o *tracerOptions) *tracerOptions {
+		o.traceContext = true
+		return o
+	}
+}
+
 // NewTracer returns a trace middleware that initializes the trace information
 // in the request context. The information can be retrieved using any of the
 // ContextXXX functions.
@@ -118,16 +129,34 @@ func SampleSize(s int) TracerOption {
 // IDs respectively. This is configurable so that the created IDs are compatible
 // with the various backend tracing systems. The xray package provides
 // implementations that produce AWS X-Ray compatible IDs.
+//
+// If the TraceContext option is used the middleware also reads the W3C
+// traceparent and tracestate headers, they take precedence over the TraceID and
+// ParentSpanID headers. The sampling decision of the caller recorded in the
+// traceparent header is honored, the sampler decides for requests that do not
+// belong to a trace. Requests that are not sampled are still given a trace so
+// that the decision propagates to downstream services, use ContextTraceSampled
+// to check whether a trace should be recorded.
 func NewTracer(opts ...TracerOption) goa.Middleware {
 	o := &tracerOptions{
-		traceIDFunc:     shortID,
-		spanIDFunc:      shortID,
 		samplingPercent: 100,
 		sampleSize:      1000, // only applies if maxSamplingRate is set
 	}
 	for _, opt := range opts {
 		o = opt(o)
 	}
+	if o.traceIDFunc == nil {
+		o.traceIDFunc = shortID
+		if o.traceContext {
+			o.traceIDFunc = NewW3CTraceID
+		}
+	}
+	if o.spanIDFunc == nil {
+		o.spanIDFunc = shortID
+		if o.traceContext {
+			o.spanIDFunc = NewW3CSpanID
+		}
+	}
 	var sampler Sampler
 	if o.maxSamplingRate > 0 {
 		sampler = NewAdaptiveSampler(o.maxSamplingRate, o.sampleSize)
@@ -136,21 +165,34 @@ func NewTracer(opts ...TracerOption) goa.Middleware {
 	}
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			// continue the W3C trace if any.
+			if o.traceContext {
+				if tp, err := ParseTraceParent(req.Header.Get(TraceParentHeader)); err == nil {
+					ctx = WithTrace(ctx, tp.TraceID, o.spanIDFunc(), tp.ParentID)
+					ctx = WithTraceContext(ctx, tp.Sampled(), req.Header.Get(TraceStateHeader))
+					return h(ctx, rw, req)
+				}
+			}
+
 			// insert a new trace ID only if not already being traced.
+			sampled := true
 			traceID := req.Header.Get(TraceIDHeader)
 			if traceID == "" {
 				// insert tracing only within sample.
-				if sampler.Sample() {
-					traceID = o.traceIDFunc()
-				} else {
+				sampled = sampler.Sample()
+				if !sampled && !o.traceContext {
 					return h(ctx, rw, req)
 				}
+				traceID = o.traceIDFunc()
 			}
 
 			// insert IDs into context to enable tracing.
 			spanID := o.spanIDFunc()
 			parentID := req.Header.Get(ParentSpanIDHeader)
 			ctx = WithTrace(ctx, traceID, spanID, parentID)
+			if o.traceContext {
+				ctx = WithTraceContext(ctx, sampled, "")
+			}
 			return h(ctx, rw, req)
 		}
 	}
@@ -163,6 +205,8 @@ func Tracer(sampleRate int, spanIDFunc, traceIDFunc IDFunc) goa.Middleware {
 
 // TraceDoer wraps a goa client Doer and sets the trace headers so that the
 // downstream service may properly retrieve the parent span ID and trace ID.
+// The W3C traceparent and tracestate headers are also set when the trace and
+// span IDs are W3C compatible, AWS X-Ray trace IDs are converted.
 func TraceDoer(doer client.Doer) client.Doer {
 	return &tracedDoer{doer}
 }
@@ -214,6 +258,12 @@ func (d *tracedDoer) Do(ctx context.Context, req *http.Request) (*http.Response,
 	if traceID != "" {
 		req.Header.Set(TraceIDHeader, traceID)
 		req.Header.Set(ParentSpanIDHeader, spanID)
+		if tp := contextTraceParent(ctx); tp != nil {
+			req.Header.Set(TraceParentHeader, tp.String())
+			if state := ContextTraceState(ctx); state != "" {
+				req.Header.Set(TraceStateHeader, state)
+			}
+		}
 	}
 
 	return d.Doer.Do(ctx, req)
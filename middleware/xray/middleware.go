@@ -26,7 +26,8 @@ const (
 //
 // The middleware works by extracting the trace information from the context
 // using the tracing middleware package. The tracing middleware must be mounted
-// first on the service.
+// first on the service. Traces that are not sampled are not recorded and W3C
+// Trace Context trace IDs are converted to the X-Ray format, see FromW3CTraceID.
 //
 // The middleware stores the request segment in the context. Use ContextSegment
 // to retrieve it. User code can further configure the segment for example to set
@@ -59,7 +60,7 @@ func New(service, daemon string) (goa.Middleware, error) {
 				err     error
 				traceID = middleware.ContextTraceID(ctx)
 			)
-			if traceID == "" {
+			if traceID == "" || !middleware.ContextTraceSampled(ctx) {
 				// No tracing
 				return h(ctx, rw, req)
 			}
@@ -122,6 +123,9 @@ func newSegment(ctx context.Context, traceID, name string, req *http.Request, c
 		parentID = middleware.ContextParentSpanID(ctx)
 	)
 
+	if id, err := FromW3CTraceID(traceID); err == nil {
+		traceID = id
+	}
 	s := NewSegment(name, traceID, spanID, c)
 	s.RecordRequest(req, "")
 
@@ -0,0 +1,55 @@
+package xray
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goadesign/goa/middleware"
+)
+
+// ToW3CTraceID converts an AWS X-Ray trace ID of the form 1-<time>-<random> into a W3C Trace
+// Context trace ID, e.g. "1-5759e988-bd862e3fe1be46a994272793" becomes
+// "5759e988bd862e3fe1be46a994272793".
+func ToW3CTraceID(traceID string) (string, error) {
+	elems := strings.Split(traceID, "-")
+	if len(elems) != 3 || elems[0] != "1" || len(elems[1]) != 8 || len(elems[2]) != 24 {
+		return "", fmt.Errorf("xray: invalid trace ID %q", traceID)
+	}
+	id := elems[1] + elems[2]
+	if !isHex(id) {
+		return "", fmt.Errorf("xray: invalid trace ID %q", traceID)
+	}
+	return id, nil
+}
+
+// FromW3CTraceID converts a W3C Trace Context trace ID into an AWS X-Ray trace ID. It is the
+// inverse of ToW3CTraceID: the first 8 hexadecimal characters are used as the X-Ray time field.
+func FromW3CTraceID(traceID string) (string, error) {
+	if len(traceID) != 32 || !isHex(traceID) {
+		return "", fmt.Errorf("xray: invalid W3C trace ID %q", traceID)
+	}
+	return fmt.Sprintf("1-%s-%s", traceID[:8], traceID[8:]), nil
+}
+
+// TraceParent returns the W3C traceparent that identifies the segment as the parent of
+// downstream requests, nil if the segment trace ID is not a valid X-Ray trace ID. Segments are
+// only created for sampled traces so the sampled flag is always set.
+func (s *Segment) TraceParent() *middleware.TraceParent {
+	s.Lock()
+	defer s.Unlock()
+	traceID, err := ToW3CTraceID(s.TraceID)
+	if err != nil {
+		return nil
+	}
+	return &middleware.TraceParent{TraceID: traceID, ParentID: s.ID, Flags: middleware.TraceFlagSampled}
+}
+
+// isHex returns true if s only contains lower case hexadecimal characters.
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
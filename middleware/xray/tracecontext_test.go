@@ -0,0 +1,69 @@
+package xray
+
+import (
+	"testing"
+
+	"github.com/goadesign/goa/middleware"
+)
+
+func TestW3CTraceID(t *testing.T) {
+	const (
+		xrayID = "1-5759e988-bd862e3fe1be46a994272793"
+		w3cID  = "5759e988bd862e3fe1be46a994272793"
+	)
+	id, err := ToW3CTraceID(xrayID)
+	if err != nil || id != w3cID {
+		t.Errorf("invalid W3C trace ID, expected %s got %s (%v)", w3cID, id, err)
+	}
+	id, err = FromW3CTraceID(w3cID)
+	if err != nil || id != xrayID {
+		t.Errorf("invalid X-Ray trace ID, expected %s got %s (%v)", xrayID, id, err)
+	}
+	for _, invalid := range []string{"", "traceID1", "2-5759e988-bd862e3fe1be46a994272793", "1-5759e988-bd862e3fe1be46a99427279z"} {
+		if _, err := ToW3CTraceID(invalid); err == nil {
+			t.Errorf("expected an error converting %q", invalid)
+		}
+	}
+	for _, invalid := range []string{"", "5759e988bd862e3f", "5759E988BD862E3FE1BE46A994272793"} {
+		if _, err := FromW3CTraceID(invalid); err == nil {
+			t.Errorf("expected an error converting %q", invalid)
+		}
+	}
+	if id := NewTraceID(); id != mustFromW3C(t, mustToW3C(t, id)) {
+		t.Errorf("conversion of %s does not round trip", id)
+	}
+}
+
+func TestSegmentTraceParent(t *testing.T) {
+	s := NewSegment("service", "1-5759e988-bd862e3fe1be46a994272793", "53995c3f42cd8ad8", nil)
+	tp := s.TraceParent()
+	if tp == nil {
+		t.Fatal("expected a traceparent")
+	}
+	expected := "00-5759e988bd862e3fe1be46a994272793-53995c3f42cd8ad8-01"
+	if tp.String() != expected {
+		t.Errorf("invalid traceparent, expected %s got %s", expected, tp)
+	}
+	if _, err := middleware.ParseTraceParent(tp.String()); err != nil {
+		t.Errorf("invalid traceparent: %s", err)
+	}
+	if s := NewSegment("service", "traceID1", "spanID1", nil); s.TraceParent() != nil {
+		t.Errorf("expected no traceparent for an invalid trace ID")
+	}
+}
+
+func mustToW3C(t *testing.T, id string) string {
+	res, err := ToW3CTraceID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func mustFromW3C(t *testing.T, id string) string {
+	res, err := FromW3CTraceID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -3,6 +3,7 @@ package goa_test
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 
 	"context"
 
@@ -114,6 +115,41 @@ var _ = Describe("NewMiddleware", func() {
 			})
 		})
 
+		Context("using a http middleware func that modifies the request", func() {
+			BeforeEach(func() {
+				input = func(h http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						r = r.WithContext(context.WithValue(r.Context(), testKey, "value"))
+						r.URL.Path = "/rewritten"
+						h.ServeHTTP(w, r)
+					})
+				}
+			})
+
+			It("passes the context values and the request to the goa handler", func() {
+				Ω(mErr).ShouldNot(HaveOccurred())
+				rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+				ctx = goa.NewContext(ctx, rw, req, nil)
+				var value interface{}
+				var path string
+				var reqData *goa.RequestData
+				h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					value = ctx.Value(testKey)
+					path = req.URL.Path
+					reqData = goa.ContextRequest(ctx)
+					service.Send(ctx, 200, "ok")
+					return nil
+				}
+				Ω(middleware(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+				Ω(value).Should(Equal("value"))
+				Ω(path).Should(Equal("/rewritten"))
+				Ω(reqData).ShouldNot(BeNil())
+				Ω(reqData.URL.Path).Should(Equal("/rewritten"))
+				Ω(goa.ContextResponse(ctx).Status).Should(Equal(200))
+				Ω(rw.Header().Get("Vary")).Should(Equal("Accept"))
+			})
+		})
+
 		Context("using a http handler", func() {
 			BeforeEach(func() {
 				input = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -152,3 +188,67 @@ var _ = Describe("NewMiddleware", func() {
 
 	})
 })
+
+var _ = Describe("HTTPMiddleware", func() {
+	var service *goa.Service
+	var middleware goa.Middleware
+	var rw *httptest.ResponseRecorder
+	var value interface{}
+	var reqData *goa.RequestData
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		value = nil
+		reqData = nil
+	})
+
+	JustBeforeEach(func() {
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value = r.Context().Value(testKey)
+			reqData = goa.ContextRequest(r.Context())
+			w.WriteHeader(http.StatusNoContent)
+		})
+		rw = httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		goa.HTTPMiddleware(service, middleware)(next).ServeHTTP(rw, req)
+	})
+
+	Context("with a middleware that sets a context value", func() {
+		BeforeEach(func() {
+			middleware = func(h goa.Handler) goa.Handler {
+				return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					return h(context.WithValue(ctx, testKey, "value"), rw, req)
+				}
+			}
+		})
+
+		It("calls the next handler with the goa context", func() {
+			Ω(rw.Code).Should(Equal(http.StatusNoContent))
+			Ω(value).Should(Equal("value"))
+			Ω(reqData).ShouldNot(BeNil())
+			Ω(reqData.URL.Path).Should(Equal("/goo"))
+		})
+	})
+
+	Context("with a middleware that returns an error", func() {
+		BeforeEach(func() {
+			middleware = func(h goa.Handler) goa.Handler {
+				return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					return goa.ErrUnauthorized("not allowed")
+				}
+			}
+		})
+
+		It("writes the error response", func() {
+			Ω(rw.Code).Should(Equal(http.StatusUnauthorized))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal(service.ErrorContentType()))
+			Ω(rw.Body.String()).Should(ContainSubstring("not allowed"))
+			Ω(reqData).Should(BeNil())
+		})
+	})
+})
+
+// testKey is the context key used by the middleware tests.
+var testKey = &struct{ name string }{"test"}
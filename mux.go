@@ -1,8 +1,13 @@
 package goa
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/dimfeld/httptreemux"
 )
@@ -37,65 +42,416 @@ type (
 		Lookup(method, path string) MuxHandler
 	}
 
+	// RouteMux is the interface implemented by the muxes that keep track of the controller
+	// and action that handle each route. The mux returned by NewMux implements RouteMux.
+	RouteMux interface {
+		ServeMux
+		// HandleRoute sets the MuxHandler for the given route. It returns an error if the
+		// route conflicts with a route registered previously.
+		HandleRoute(route *Route, handle MuxHandler) error
+		// Routes returns the registered routes sorted by path then method.
+		Routes() []*Route
+	}
+
+	// Route describes a request route registered with a RouteMux.
+	Route struct {
+		// Method is the route HTTP method.
+		Method string
+		// Path is the route path template, e.g. "/accounts/:accountID/bottles/*path".
+		Path string
+		// Controller is the name of the controller that handles the route if any.
+		Controller string
+		// Action is the name of the action that handles the route if any.
+		Action string
+		// Metadata contains the design metadata of the action that is used at runtime, e.g.
+		// "ratelimit".
+		Metadata map[string][]string
+	}
+
 	// Muxer implements an adapter that given a request handler can produce a mux handler.
 	Muxer interface {
 		MuxHandler(string, Handler, Unmarshaler) MuxHandler
 	}
 
-	// mux is the default ServeMux implementation.
+	// mux is the default ServeMux implementation. It stores the routes in a radix tree
+	// keyed by path segments so that lookups cost one map access per segment.
 	mux struct {
-		router  *httptreemux.TreeMux
-		handles map[string]MuxHandler
+		lock     sync.RWMutex // Protects the fields below
+		root     *node
+		handles  map[string]MuxHandler
+		routes   []*Route
+		notFound MuxHandler
+		notAllow MethodNotAllowedHandler
+	}
+
+	// node is a node of the mux radix tree. Each node matches one path segment.
+	node struct {
+		// static holds the children matching literal path segments.
+		static map[string]*node
+		// param is the child matching any non-empty segment if any.
+		param *node
+		// catchAll is the child matching the remainder of the path if any.
+		catchAll *node
+		// names lists the names of the parameters captured by the routes that end at this
+		// node. Parameter nodes are shared by all the routes going through them so that
+		// routes may use different names at the same position, e.g. "/bottles/:bottleID"
+		// and "/bottles/:id/ratings". Only the routes ending at the same node must agree.
+		names []string
+		// endpoints lists the routes that end at this node indexed by HTTP method.
+		endpoints map[string]*endpoint
+	}
+
+	// endpoint associates a route with its handler.
+	endpoint struct {
+		route  *Route
+		handle MuxHandler
+	}
+
+	// match is the result of a successful lookup, names and params list the captured
+	// parameter names and values.
+	match struct {
+		*endpoint
+		names  []string
+		params []string
 	}
 )
 
 // NewMux returns a Mux.
 func NewMux() ServeMux {
-	r := httptreemux.New()
-	r.EscapeAddedRoutes = true
 	return &mux{
-		router:  r,
+		root:    &node{},
 		handles: make(map[string]MuxHandler),
 	}
 }
 
-// Handle sets the handler for the given verb and path.
+// String returns a human friendly representation of the route, e.g. "GET /bottles/:id (bottle#show)".
+func (r *Route) String() string {
+	s := r.Method + " " + r.Path
+	if r.Controller != "" || r.Action != "" {
+		s += fmt.Sprintf(" (%s#%s)", r.Controller, r.Action)
+	}
+	return s
+}
+
+// Handle sets the handler for the given verb and path. It panics if the route conflicts with
+// a route registered previously, use HandleRoute to get an error instead.
 func (m *mux) Handle(method, path string, handle MuxHandler) {
-	hthandle := func(rw http.ResponseWriter, req *http.Request, htparams map[string]string) {
-		params := req.URL.Query()
-		for n, p := range htparams {
-			params.Set(n, p)
+	if err := m.HandleRoute(&Route{Method: method, Path: path}, handle); err != nil {
+		panic(err)
+	}
+}
+
+// HandleRoute sets the handler for the given route. It returns an error if the route path is
+// invalid or if it conflicts with a route registered previously: both routes define the same
+// method and path or both end with the same path template using different parameter names.
+func (m *mux) HandleRoute(route *Route, handle MuxHandler) error {
+	segments, err := splitRoute(route.Path)
+	if err != nil {
+		return fmt.Errorf("invalid route %s: %s", route, err)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	n := m.root
+	var names []string
+	for i, seg := range segments {
+		switch {
+		case seg.kind == paramSegment:
+			if n.param == nil {
+				n.param = &node{}
+			}
+			n = n.param
+			names = append(names, seg.value)
+		case seg.kind == catchAllSegment:
+			if i != len(segments)-1 {
+				return fmt.Errorf("invalid route %s: catch-all parameter %q must be last", route, seg.value)
+			}
+			if n.catchAll == nil {
+				n.catchAll = &node{}
+			}
+			n = n.catchAll
+			names = append(names, seg.value)
+		default:
+			child, ok := n.static[seg.value]
+			if !ok {
+				if n.static == nil {
+					n.static = make(map[string]*node)
+				}
+				child = &node{}
+				n.static[seg.value] = child
+			}
+			n = child
+		}
+	}
+	if e, ok := n.endpoints[route.Method]; ok {
+		return conflictError(route, e.route, "route is already registered")
+	}
+	if n.endpoints == nil {
+		n.endpoints = make(map[string]*endpoint)
+		n.names = names
+	} else {
+		for i, name := range names {
+			if name != n.names[i] {
+				var existing *Route
+				for _, e := range n.endpoints {
+					existing = e.route
+					break
+				}
+				return conflictError(route, existing,
+					fmt.Sprintf("parameter %q conflicts with parameter %q", name, n.names[i]))
+			}
 		}
-		handle(rw, req, params)
 	}
-	m.handles[method+path] = handle
-	m.router.Handle(method, path, hthandle)
+	n.endpoints[route.Method] = &endpoint{route: route, handle: handle}
+	m.handles[route.Method+route.Path] = handle
+	m.routes = append(m.routes, route)
+	return nil
+}
+
+// Routes returns the registered routes sorted by path then method.
+func (m *mux) Routes() []*Route {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	routes := make([]*Route, len(m.routes))
+	copy(routes, m.routes)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes
 }
 
 // HandleNotFound sets the MuxHandler invoked for requests that don't match any
 // handler registered with Handle.
 func (m *mux) HandleNotFound(handle MuxHandler) {
-	nfh := func(rw http.ResponseWriter, req *http.Request) {
-		handle(rw, req, nil)
-	}
-	m.router.NotFoundHandler = nfh
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.notFound = handle
 }
 
 // HandleMethodNotAllowed sets the MuxHandler invoked for requests that match
 // the path of a handler but not its HTTP method.
 func (m *mux) HandleMethodNotAllowed(handle MethodNotAllowedHandler) {
-	mna := func(rw http.ResponseWriter, req *http.Request, methods map[string]httptreemux.HandlerFunc) {
-		handle(rw, req, nil, methods)
-	}
-	m.router.MethodNotAllowedHandler = mna
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.notAllow = handle
 }
 
 // Lookup returns the MuxHandler associated with the given method and path.
 func (m *mux) Lookup(method, path string) MuxHandler {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.handles[method+path]
 }
 
 // ServeHTTP is the function called back by the underlying HTTP server to handle incoming requests.
+// The matched route is stored in the request context and can be retrieved with ContextRoute.
 func (m *mux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	m.router.ServeHTTP(rw, req)
+	m.lock.RLock()
+	notFound, notAllow := m.notFound, m.notAllow
+	m.lock.RUnlock()
+	e, methods, alt := m.match(req)
+	switch {
+	case e != nil:
+		values := req.URL.Query()
+		for i, name := range e.names {
+			values.Set(name, e.params[i])
+		}
+		req = req.WithContext(WithRoute(req.Context(), e.route))
+		e.handle(rw, req, values)
+	case alt != "":
+		redirect(rw, req, alt)
+	case methods != nil:
+		if notAllow != nil {
+			notAllow(rw, req, nil, methods)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	default:
+		if notFound != nil {
+			notFound(rw, req, nil)
+			return
+		}
+		http.NotFound(rw, req)
+	}
+}
+
+// match looks up the endpoint that handles the request. If there is none it returns the
+// handlers registered for the request path with other HTTP methods or, if the path does not
+// match any route, the path with or without a trailing slash if that matches a route.
+func (m *mux) match(req *http.Request) (*match, map[string]httptreemux.HandlerFunc, string) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	path := req.URL.EscapedPath()
+	n, params := m.root.lookup(splitPath(path), nil)
+	if n == nil {
+		if path == "/" || path == "" {
+			return nil, nil, ""
+		}
+		alt := path + "/"
+		if strings.HasSuffix(path, "/") {
+			alt = path[:len(path)-1]
+		}
+		if an, _ := m.root.lookup(splitPath(alt), nil); an != nil {
+			return nil, nil, alt
+		}
+		return nil, nil, ""
+	}
+	e, ok := n.endpoints[req.Method]
+	if !ok && req.Method == "HEAD" {
+		e, ok = n.endpoints["GET"]
+	}
+	if !ok {
+		methods := make(map[string]httptreemux.HandlerFunc, len(n.endpoints))
+		for meth, e := range n.endpoints {
+			methods[meth] = e.handlerFunc()
+		}
+		return nil, methods, ""
+	}
+	return &match{endpoint: e, names: n.names, params: params}, nil, ""
+}
+
+// lookup returns the node matching the given path segments and the captured parameter values.
+// Literal segments take precedence over parameters which take precedence over
+// catch-all parameters, lookup backtracks when a branch does not match.
+func (n *node) lookup(segments []string, params []string) (*node, []string) {
+	if len(segments) == 0 {
+		if len(n.endpoints) > 0 {
+			return n, params
+		}
+		return nil, nil
+	}
+	seg, rest := segments[0], segments[1:]
+	if n.static != nil {
+		if val, err := url.PathUnescape(seg); err == nil {
+			if child, ok := n.static[val]; ok {
+				if found, ps := child.lookup(rest, params); found != nil {
+					return found, ps
+				}
+			}
+		}
+	}
+	if n.param != nil && seg != "" {
+		val, err := url.PathUnescape(seg)
+		if err == nil {
+			if found, ps := n.param.lookup(rest, append(params, val)); found != nil {
+				return found, ps
+			}
+		}
+	}
+	if n.catchAll != nil && len(n.catchAll.endpoints) > 0 {
+		val, err := url.PathUnescape(strings.Join(segments, "/"))
+		if err == nil {
+			return n.catchAll, append(params, val)
+		}
+	}
+	return nil, nil
+}
+
+// handlerFunc adapts the endpoint handler to the httptreemux handler signature used by
+// MethodNotAllowedHandler.
+func (e *endpoint) handlerFunc() httptreemux.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request, params map[string]string) {
+		values := req.URL.Query()
+		for n, p := range params {
+			values.Set(n, p)
+		}
+		e.handle(rw, req.WithContext(WithRoute(req.Context(), e.route)), values)
+	}
+}
+
+// Kinds of route segments.
+const (
+	staticSegment = iota
+	paramSegment
+	catchAllSegment
+)
+
+// segment is a parsed route path segment.
+type segment struct {
+	kind  int
+	value string
+}
+
+// splitRoute parses a route path template. Segments starting with ':' define parameters and
+// the last segment may start with '*' to define a catch-all parameter. A leading backslash
+// escapes these characters.
+func splitRoute(path string) ([]segment, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path must start with /")
+	}
+	parts := strings.Split(path[1:], "/")
+	segments := make([]segment, len(parts))
+	for i, p := range parts {
+		switch {
+		case strings.HasPrefix(p, ":"):
+			if len(p) == 1 {
+				return nil, fmt.Errorf("missing parameter name in segment %d", i+1)
+			}
+			segments[i] = segment{paramSegment, p[1:]}
+		case strings.HasPrefix(p, "*"):
+			if len(p) == 1 {
+				return nil, fmt.Errorf("missing catch-all parameter name in segment %d", i+1)
+			}
+			segments[i] = segment{catchAllSegment, p[1:]}
+		case strings.HasPrefix(p, `\:`), strings.HasPrefix(p, `\*`):
+			segments[i] = segment{staticSegment, unescapeRouteSegment(p[1:])}
+		default:
+			segments[i] = segment{staticSegment, unescapeRouteSegment(p)}
+		}
+	}
+	return segments, nil
+}
+
+// unescapeRouteSegment removes the backslashes escaping special characters in a literal
+// route segment.
+func unescapeRouteSegment(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return strings.NewReplacer(`\*`, "*", `\:`, ":").Replace(s)
+}
+
+// splitPath splits the escaped request path into segments.
+func splitPath(path string) []string {
+	if path == "" {
+		return []string{""}
+	}
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// redirect redirects the client to the given path preserving the query string.
+func redirect(rw http.ResponseWriter, req *http.Request, path string) {
+	u := *req.URL
+	u.RawPath = ""
+	if p, err := url.PathUnescape(path); err == nil {
+		u.Path = p
+		u.RawPath = path
+	}
+	code := http.StatusMovedPermanently
+	if req.Method != "GET" && req.Method != "HEAD" {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(rw, req, u.String(), code)
+}
+
+// conflictError returns the error reported when route conflicts with existing.
+func conflictError(route, existing *Route, reason string) error {
+	return fmt.Errorf("route %s conflicts with route %s: %s", route, existing, reason)
+}
+
+// WithRoute creates a context with the given route.
+func WithRoute(ctx context.Context, route *Route) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// ContextRoute extracts the route that matched the request from the given context. The route
+// path is the path template, e.g. "/bottles/:id", which makes it suitable for use in logs and
+// metrics. ContextRoute returns nil if the request was not dispatched by a RouteMux.
+func ContextRoute(ctx context.Context) *Route {
+	if r := ctx.Value(routeKey); r != nil {
+		return r.(*Route)
+	}
+	return nil
 }
@@ -2,8 +2,10 @@ package goa_test
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 
 	"github.com/goadesign/goa"
@@ -66,6 +68,187 @@ var _ = Describe("Mux", func() {
 		})
 	})
 
+	Context("with path parameters", func() {
+		var params url.Values
+		var route *goa.Route
+
+		BeforeEach(func() {
+			var err error
+			req, err = http.NewRequest("GET", "/accounts/42/bottles/a%2Fb?q=1", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			mux.Handle("GET", "/accounts/:accountID/bottles", func(http.ResponseWriter, *http.Request, url.Values) {
+				Fail("unexpected handler")
+			})
+			mux.Handle("GET", "/accounts/:accountID/bottles/:id", func(rw http.ResponseWriter, req *http.Request, vals url.Values) {
+				params = vals
+				route = goa.ContextRoute(req.Context())
+			})
+		})
+
+		It("captures the parameters and the route", func() {
+			Ω(params.Get("accountID")).Should(Equal("42"))
+			Ω(params.Get("id")).Should(Equal("a/b"))
+			Ω(params.Get("q")).Should(Equal("1"))
+			Ω(route).ShouldNot(BeNil())
+			Ω(route.Path).Should(Equal("/accounts/:accountID/bottles/:id"))
+		})
+	})
+
+	Context("with static, parameter and catch-all routes", func() {
+		var matched string
+		var params url.Values
+
+		handler := func(name string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, vals url.Values) {
+				matched = name
+				params = vals
+			}
+		}
+
+		BeforeEach(func() {
+			matched = ""
+			mux.Handle("GET", "/files/latest", handler("static"))
+			mux.Handle("GET", "/files/:id", handler("param"))
+			mux.Handle("GET", "/files/:id/meta", handler("meta"))
+			mux.Handle("GET", "/*path", handler("catchall"))
+		})
+
+		Context("with a literal match", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", "/files/latest", nil)
+			})
+
+			It("prefers the static route", func() {
+				Ω(matched).Should(Equal("static"))
+			})
+		})
+
+		Context("with a parameter match", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", "/files/latest/meta", nil)
+			})
+
+			It("backtracks to the parameter route", func() {
+				Ω(matched).Should(Equal("meta"))
+				Ω(params.Get("id")).Should(Equal("latest"))
+			})
+		})
+
+		Context("with no other match", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", "/assets/css/main.css", nil)
+			})
+
+			It("uses the catch-all route", func() {
+				Ω(matched).Should(Equal("catchall"))
+				Ω(params.Get("path")).Should(Equal("assets/css/main.css"))
+			})
+		})
+
+		Context("with a HEAD request", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("HEAD", "/files/latest", nil)
+			})
+
+			It("uses the GET handler", func() {
+				Ω(matched).Should(Equal("static"))
+			})
+		})
+	})
+
+	Context("with a trailing slash", func() {
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/foo/?a=b", nil)
+			mux.Handle("GET", "/foo", func(http.ResponseWriter, *http.Request, url.Values) {})
+		})
+
+		It("redirects to the registered path", func() {
+			Ω(rw.Status).Should(Equal(301))
+			Ω(rw.ParentHeader.Get("Location")).Should(Equal("/foo?a=b"))
+		})
+	})
+
+	Context("with conflicting routes", func() {
+		var rmux goa.RouteMux
+
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/", nil)
+			rmux = mux.(goa.RouteMux)
+			err := rmux.HandleRoute(&goa.Route{Method: "GET", Path: "/bottles/:id", Controller: "bottle", Action: "show"}, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("reports duplicate routes naming both actions", func() {
+			err := rmux.HandleRoute(&goa.Route{Method: "GET", Path: "/bottles/:id", Controller: "bottle", Action: "get"}, nil)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("(bottle#get)"))
+			Ω(err.Error()).Should(ContainSubstring("(bottle#show)"))
+		})
+
+		It("reports parameter name mismatches", func() {
+			err := rmux.HandleRoute(&goa.Route{Method: "DELETE", Path: "/bottles/:bottleID", Controller: "bottle", Action: "delete"}, nil)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring(`parameter "bottleID" conflicts with parameter "id"`))
+		})
+
+		It("accepts different parameter names in routes ending at different nodes", func() {
+			var values []url.Values
+			handle := func(rw http.ResponseWriter, req *http.Request, vals url.Values) {
+				values = append(values, vals)
+			}
+			Ω(rmux.HandleRoute(&goa.Route{Method: "GET", Path: "/x/:a"}, handle)).Should(Succeed())
+			Ω(rmux.HandleRoute(&goa.Route{Method: "GET", Path: "/x/:b/y"}, handle)).Should(Succeed())
+			for _, path := range []string{"/x/1", "/x/2/y"} {
+				req, _ = http.NewRequest("GET", path, nil)
+				mux.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			Ω(values).Should(HaveLen(2))
+			Ω(values[0].Get("a")).Should(Equal("1"))
+			Ω(values[1].Get("b")).Should(Equal("2"))
+			Ω(values[1]).ShouldNot(HaveKey("a"))
+		})
+
+		It("panics in Handle", func() {
+			Ω(func() { mux.Handle("GET", "/bottles/:id", nil) }).Should(Panic())
+		})
+
+		It("lists the routes", func() {
+			err := rmux.HandleRoute(&goa.Route{Method: "DELETE", Path: "/bottles/:id", Controller: "bottle", Action: "delete"}, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			err = rmux.HandleRoute(&goa.Route{Method: "GET", Path: "/bottles", Controller: "bottle", Action: "list"}, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			routes := rmux.Routes()
+			Ω(routes).Should(HaveLen(3))
+			Ω(routes[0].Action).Should(Equal("list"))
+			Ω(routes[1].Action).Should(Equal("delete"))
+			Ω(routes[2].Action).Should(Equal("show"))
+		})
+	})
+
+	Context("with a route mounted on a service", func() {
+		var route *goa.Route
+
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/bottles/1", nil)
+			service := goa.New("test")
+			mux = service.Mux
+			ctrl := service.NewController("bottle")
+			h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				route = goa.ContextRoute(ctx)
+				return nil
+			}
+			service.Mount(&goa.Route{Method: "GET", Path: "/bottles/:id", Controller: "bottle", Action: "show"},
+				ctrl.MuxHandler("show", h, nil))
+		})
+
+		It("sets the route in the handler context", func() {
+			Ω(route).ShouldNot(BeNil())
+			Ω(route.Controller).Should(Equal("bottle"))
+			Ω(route.Action).Should(Equal("show"))
+			Ω(route.Path).Should(Equal("/bottles/:id"))
+		})
+	})
+
 	Context("with registered handlers and wrong method", func() {
 		const handlerMeth = "POST"
 		const reqMeth = "GET"
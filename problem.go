@@ -0,0 +1,148 @@
+package goa
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemMediaIdentifier is the media type identifier of RFC 7807 problem details documents.
+const ProblemMediaIdentifier = "application/problem+json"
+
+// Problem is a RFC 7807 problem details document, see https://tools.ietf.org/html/rfc7807.
+// Services render errors as problem details when the service ProblemDetails field is true or when
+// the response Content-Type is ProblemMediaIdentifier. The error code maps to the problem type,
+// the error detail to the problem detail and the error ID and metadata to extension members.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	Type string `json:"type" xml:"type"`
+	// Title is a short human-readable summary of the problem type.
+	Title string `json:"title,omitempty" xml:"title,omitempty"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status,omitempty" xml:"status,omitempty"`
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty" xml:"detail,omitempty"`
+	// Instance is a URI reference that identifies the specific occurrence of the problem.
+	Instance string `json:"instance,omitempty" xml:"instance,omitempty"`
+	// Extensions contains the problem extension members.
+	Extensions map[string]interface{} `json:"-" xml:"-"`
+}
+
+// problemMembers lists the members defined by RFC 7807, extensions may not override them.
+var problemMembers = map[string]bool{"type": true, "title": true, "status": true, "detail": true, "instance": true}
+
+// NewProblem returns the problem details document describing err. The error ID is stored in the
+// "id" extension member.
+func NewProblem(err ServiceError) *Problem {
+	e, ok := err.(*ErrorResponse)
+	if !ok {
+		return &Problem{
+			Type:       "about:blank",
+			Title:      http.StatusText(err.ResponseStatus()),
+			Status:     err.ResponseStatus(),
+			Detail:     err.Error(),
+			Extensions: map[string]interface{}{"id": err.Token()},
+		}
+	}
+	ext := make(map[string]interface{}, len(e.Meta)+1)
+	for k, v := range e.Meta {
+		ext[k] = v
+	}
+	ext["id"] = e.ID
+	return &Problem{
+		Type:       e.Code,
+		Title:      http.StatusText(e.Status),
+		Status:     e.Status,
+		Detail:     e.Detail,
+		Extensions: ext,
+	}
+}
+
+// ErrorResponse converts the problem back into an error response. It is the inverse of NewProblem.
+func (p *Problem) ErrorResponse() *ErrorResponse {
+	e := &ErrorResponse{Code: p.Type, Status: p.Status, Detail: p.Detail}
+	for k, v := range p.Extensions {
+		if k == "id" {
+			e.ID, _ = v.(string)
+			continue
+		}
+		if e.Meta == nil {
+			e.Meta = make(map[string]interface{})
+		}
+		e.Meta[k] = v
+	}
+	return e
+}
+
+// MarshalJSON renders the problem extension members alongside the members defined by RFC 7807.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		if !problemMembers[k] {
+			m[k] = v
+		}
+	}
+	m["type"] = p.Type
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON reads the problem members, members not defined by RFC 7807 are stored in
+// Extensions.
+func (p *Problem) UnmarshalJSON(b []byte) error {
+	type problem Problem // prevents infinite recursion
+	var members problem
+	if err := json.Unmarshal(b, &members); err != nil {
+		return err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	*p = Problem(members)
+	for k, v := range all {
+		if problemMembers[k] {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]interface{})
+		}
+		p.Extensions[k] = v
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes error responses rendered by goa services both as
+// application/vnd.goa.error and as RFC 7807 problem details documents.
+func (e *ErrorResponse) UnmarshalJSON(b []byte) error {
+	type errorResponse ErrorResponse // prevents infinite recursion
+	var shape struct {
+		Code *string `json:"code"`
+		Type *string `json:"type"`
+	}
+	if err := json.Unmarshal(b, &shape); err != nil {
+		return err
+	}
+	if shape.Code == nil && shape.Type != nil {
+		var p Problem
+		if err := p.UnmarshalJSON(b); err != nil {
+			return err
+		}
+		*e = *p.ErrorResponse()
+		return nil
+	}
+	return json.Unmarshal(b, (*errorResponse)(e))
+}
@@ -0,0 +1,99 @@
+package goa_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Problem", func() {
+	var err error
+
+	BeforeEach(func() {
+		err = goa.ErrBadRequest("invalid value", "field", "id")
+	})
+
+	Describe("NewProblem", func() {
+		It("maps the error fields", func() {
+			p := goa.NewProblem(err.(goa.ServiceError))
+			Ω(p.Type).Should(Equal("bad_request"))
+			Ω(p.Title).Should(Equal("Bad Request"))
+			Ω(p.Status).Should(Equal(400))
+			Ω(p.Detail).Should(Equal("invalid value"))
+			Ω(p.Extensions).Should(Equal(map[string]interface{}{
+				"field": "id",
+				"id":    err.(goa.ServiceError).Token(),
+			}))
+		})
+	})
+
+	Describe("MarshalJSON", func() {
+		It("renders the extensions as top level members", func() {
+			p := goa.NewProblem(err.(goa.ServiceError))
+			p.Extensions["status"] = "overridden"
+			b, e := json.Marshal(p)
+			Ω(e).ShouldNot(HaveOccurred())
+			var m map[string]interface{}
+			Ω(json.Unmarshal(b, &m)).Should(Succeed())
+			Ω(m).Should(HaveKeyWithValue("type", "bad_request"))
+			Ω(m).Should(HaveKeyWithValue("status", 400.0))
+			Ω(m).Should(HaveKeyWithValue("field", "id"))
+			Ω(m).ShouldNot(HaveKey("instance"))
+		})
+	})
+
+	Describe("ErrorResponse UnmarshalJSON", func() {
+		It("decodes problem details documents", func() {
+			b, e := json.Marshal(goa.NewProblem(err.(goa.ServiceError)))
+			Ω(e).ShouldNot(HaveOccurred())
+			var decoded goa.ErrorResponse
+			Ω(json.Unmarshal(b, &decoded)).Should(Succeed())
+			Ω(&decoded).Should(Equal(err))
+		})
+
+		It("decodes goa errors", func() {
+			b, e := json.Marshal(err)
+			Ω(e).ShouldNot(HaveOccurred())
+			var decoded goa.ErrorResponse
+			Ω(json.Unmarshal(b, &decoded)).Should(Succeed())
+			Ω(&decoded).Should(Equal(err))
+		})
+	})
+
+	Describe("Service Send", func() {
+		var service *goa.Service
+		var rw *TestResponseWriter
+		var ctx context.Context
+
+		BeforeEach(func() {
+			service = goa.New("test")
+			service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			ctx = goa.NewContext(context.Background(), rw, req, nil)
+		})
+
+		It("renders errors as goa errors by default", func() {
+			Ω(service.Send(ctx, 400, err)).Should(Succeed())
+			Ω(string(rw.Body)).Should(ContainSubstring(`"code":"bad_request"`))
+		})
+
+		It("renders errors as problem details if the content type says so", func() {
+			rw.Header().Set("Content-Type", goa.ProblemMediaIdentifier)
+			Ω(service.Send(ctx, 400, err)).Should(Succeed())
+			Ω(string(rw.Body)).Should(ContainSubstring(`"type":"bad_request"`))
+		})
+
+		It("renders errors as problem details if the service says so", func() {
+			service.ProblemDetails = true
+			rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
+			Ω(service.Send(ctx, 400, err)).Should(Succeed())
+			Ω(rw.Header().Get("Content-Type")).Should(Equal(goa.ProblemMediaIdentifier))
+			Ω(string(rw.Body)).Should(ContainSubstring(`"type":"bad_request"`))
+		})
+	})
+})
@@ -72,6 +72,10 @@ type JWTSecurity struct {
 	Name string
 	// TokenURL defines the URL where you'd get the JWT tokens.
 	TokenURL string
+	// Issuers lists the accepted values of the "iss" claim, any issuer is accepted if empty.
+	Issuers []string
+	// Audiences lists the accepted values of the "aud" claim, any audience is accepted if empty.
+	Audiences []string
 	// Scopes defines a list of scopes for the security scheme, along with their description.
 	Scopes map[string]string
 }
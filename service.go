@@ -5,18 +5,40 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dimfeld/httptreemux"
 )
 
+// MaxRequestBodyLengthKey is the design metadata key used to override the maximum length of the
+// request bodies of an action. The value is a number of bytes optionally followed by one of the
+// KB, MB or GB units, e.g. "10MB". A value of 0 removes the limit altogether.
+const MaxRequestBodyLengthKey = "request:max_body_length"
+
+// ShutdownHookGracePeriod is the duration given to the hooks registered with OnShutdown to
+// complete when the context given to Shutdown is already done by the time they run, for example
+// because draining the connections took until its deadline.
+var ShutdownHookGracePeriod = 5 * time.Second
+
+// requestBodyErrorCodes lists the codes of the errors produced while reading and decoding request
+// bodies that are returned as is instead of being wrapped into a bad request error.
+var requestBodyErrorCodes = map[string]bool{
+	"request_too_large": true,
+	"nesting_too_deep":  true,
+	"unknown_field":     true,
+}
+
 type (
 	// Service is the data structure supporting goa services.
 	// It provides methods for configuring a service and running it.
@@ -39,9 +61,25 @@ type (
 		Decoder *HTTPDecoder
 		// Response body encoder
 		Encoder *HTTPEncoder
+		// ProblemDetails causes errors to be rendered as RFC 7807 problem details documents
+		// using the application/problem+json content type instead of
+		// application/vnd.goa.error, see Problem.
+		ProblemDetails bool
+		// MaxRequestBodyLength is the maximum length read from request bodies used by the
+		// controllers created after it is set. Set to 0 to remove the limit altogether.
+		// Defaults to 1GB.
+		MaxRequestBodyLength int64
 
 		middleware []Middleware       // Middleware chain
 		cancel     context.CancelFunc // Service context cancel signal trigger
+
+		shutdownLock  sync.Mutex                    // Protects shutdownHooks
+		shutdownHooks []func(context.Context) error // Functions run by Shutdown
+		shuttingDown  int32                         // Set to 1 by Shutdown, fails readiness
+
+		healthLock    sync.Mutex     // Protects healthChecks and healthMounted
+		healthChecks  []*healthCheck // Checks registered with AddHealthCheck
+		healthMounted bool           // Whether the health endpoints are mounted
 	}
 
 	// Controller defines the common fields and behavior of generated controllers.
@@ -52,8 +90,9 @@ type (
 		Service *Service
 		// Controller root context
 		Context context.Context
-		// MaxRequestBodyLength is the maximum length read from request bodies.
-		// Set to 0 to remove the limit altogether. Defaults to 1GB.
+		// MaxRequestBodyLength is the maximum length read from request bodies. Actions may
+		// override it with the MaxRequestBodyLengthKey design metadata. Set to 0 to remove the
+		// limit altogether. Defaults to the service MaxRequestBodyLength.
 		MaxRequestBodyLength int64
 		// FileSystem is used in FileHandler to open files. By default it returns
 		// http.Dir but you can override it with another one that implements http.FileSystem.
@@ -105,6 +144,8 @@ func New(name string) *Service {
 			Decoder: NewHTTPDecoder(),
 			Encoder: NewHTTPEncoder(),
 
+			MaxRequestBodyLength: 1073741824, // 1 GB
+
 			cancel: cancel,
 		}
 		notFoundHandler         Handler
@@ -175,6 +216,58 @@ func (service *Service) CancelAll() {
 	service.cancel()
 }
 
+// OnShutdown registers a function that Shutdown runs once the server has stopped accepting
+// connections and all in-flight requests have completed (or the shutdown deadline was reached).
+// Hooks run in the reverse order of registration so that resources are released in the opposite
+// order they were acquired.
+func (service *Service) OnShutdown(hook func(context.Context) error) {
+	service.shutdownLock.Lock()
+	defer service.shutdownLock.Unlock()
+	service.shutdownHooks = append(service.shutdownHooks, hook)
+}
+
+// Shutdown gracefully shuts down the service: the server closes its listeners, then waits for
+// in-flight requests to complete. ctx controls how long Shutdown waits, if it is done before all
+// connections have been drained Shutdown stops waiting and returns the context error. Shutdown
+// then cancels the service root context (see CancelAll) and runs the hooks registered with
+// OnShutdown. The hooks are given ctx unless it is done by then in which case they get a new
+// context that expires after ShutdownHookGracePeriod. It returns the first error encountered.
+//
+// Once Shutdown has been called ListenAndServe, ListenAndServeTLS and Serve return
+// http.ErrServerClosed. Callers should make sure the program does not exit before Shutdown
+// returns.
+func (service *Service) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&service.shuttingDown, 1)
+	service.LogInfo("shutdown", "transport", "http", "addr", service.Server.Addr)
+	err := service.Server.Shutdown(ctx)
+	if err != nil {
+		service.LogError("shutdown failed to drain connections", "err", err)
+	}
+	service.cancel()
+
+	service.shutdownLock.Lock()
+	hooks := service.shutdownHooks
+	service.shutdownHooks = nil
+	service.shutdownLock.Unlock()
+	if ctx.Err() != nil && len(hooks) > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), ShutdownHookGracePeriod)
+		defer cancel()
+	}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if herr := hooks[i](ctx); herr != nil {
+			service.LogError("shutdown hook failed", "err", herr)
+			if err == nil {
+				err = herr
+			}
+		}
+	}
+	if err == nil {
+		service.LogInfo("shutdown complete")
+	}
+	return err
+}
+
 // Use adds a middleware to the service wide middleware chain.
 // goa comes with a set of commonly used middleware, see the middleware package.
 // Controller specific middleware should be mounted using the Controller struct Use method instead.
@@ -198,6 +291,7 @@ func (service *Service) LogError(msg string, keyvals ...interface{}) {
 }
 
 // ListenAndServe starts a HTTP server and sets up a listener on the given host/port.
+// It returns http.ErrServerClosed after Shutdown is called.
 func (service *Service) ListenAndServe(addr string) error {
 	service.LogInfo("listen", "transport", "http", "addr", addr)
 	service.Server.Addr = addr
@@ -205,6 +299,7 @@ func (service *Service) ListenAndServe(addr string) error {
 }
 
 // ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port.
+// It returns http.ErrServerClosed after Shutdown is called.
 func (service *Service) ListenAndServeTLS(addr, certFile, keyFile string) error {
 	service.LogInfo("listen", "transport", "https", "addr", addr)
 	service.Server.Addr = addr
@@ -212,10 +307,31 @@ func (service *Service) ListenAndServeTLS(addr, certFile, keyFile string) error
 }
 
 // Serve accepts incoming HTTP connections on the listener l, invoking the service mux handler for each.
+// It returns http.ErrServerClosed after Shutdown is called.
 func (service *Service) Serve(l net.Listener) error {
+	service.LogInfo("listen", "transport", "http", "addr", l.Addr().String())
 	return service.Server.Serve(l)
 }
 
+// Mount registers the handler for the given route with the service mux. If the mux implements
+// RouteMux the route controller and action names are recorded and Mount panics with an error
+// describing both routes if the route conflicts with a route mounted previously. This method is
+// mainly intended for use by the generated code.
+func (service *Service) Mount(route *Route, handle MuxHandler) {
+	if err := service.mount(route, handle); err != nil {
+		panic(err)
+	}
+}
+
+// mount registers the handler for the given route with the service mux.
+func (service *Service) mount(route *Route, handle MuxHandler) error {
+	if rm, ok := service.Mux.(RouteMux); ok {
+		return rm.HandleRoute(route, handle)
+	}
+	service.Mux.Handle(route.Method, route.Path, handle)
+	return nil
+}
+
 // NewController returns a controller for the given resource. This method is mainly intended for
 // use by the generated code. User code shouldn't have to call it directly.
 func (service *Service) NewController(name string) *Controller {
@@ -223,7 +339,7 @@ func (service *Service) NewController(name string) *Controller {
 		Name:                 name,
 		Service:              service,
 		Context:              context.WithValue(service.Context, ctrlKey, name),
-		MaxRequestBodyLength: 1073741824, // 1 GB
+		MaxRequestBodyLength: service.MaxRequestBodyLength,
 		FileSystem: func(dir string) http.FileSystem {
 			return http.Dir(dir)
 		},
@@ -237,8 +353,38 @@ func (service *Service) Send(ctx context.Context, code int, body interface{}) er
 	if r == nil {
 		return fmt.Errorf("no response data in context")
 	}
+	if e, ok := body.(ServiceError); ok {
+		switch ct := r.Header().Get("Content-Type"); {
+		case ct == ProblemMediaIdentifier:
+			body = NewProblem(e)
+		case service.ProblemDetails && (ct == "" || ct == ErrorMediaIdentifier):
+			r.Header().Set("Content-Type", ProblemMediaIdentifier)
+			body = NewProblem(e)
+		}
+	}
+	contentType, err := service.negotiate(ctx)
+	if err != nil && code >= 400 {
+		// Error responses are sent even if the client does not accept them.
+		contentType, err = service.Encoder.Negotiate("*/*")
+	}
+	if _, ok := err.(ServiceError); ok {
+		return err
+	}
+	service.setContentType(r, contentType)
 	r.WriteHeader(code)
-	return service.EncodeResponse(ctx, body)
+	if err != nil {
+		return err
+	}
+	return service.Encoder.encode(body, r, contentType)
+}
+
+// ErrorContentType returns the content type of error responses, ProblemMediaIdentifier if the
+// service renders errors as problem details, ErrorMediaIdentifier otherwise.
+func (service *Service) ErrorContentType() string {
+	if service.ProblemDetails {
+		return ProblemMediaIdentifier
+	}
+	return ErrorMediaIdentifier
 }
 
 // ServeFiles create a "FileServer" controller and calls ServerFiles on it.
@@ -247,6 +393,21 @@ func (service *Service) ServeFiles(path, filename string) error {
 	return ctrl.ServeFiles(path, filename)
 }
 
+// ServeMetrics creates a "Metrics" controller that serves the given handler under path, for
+// example:
+//
+//	service.ServeMetrics("/metrics", goa.NewPrometheusCollector("cellar"))
+func (service *Service) ServeMetrics(path string, metrics http.Handler) error {
+	ctrl := service.NewController("Metrics")
+	LogInfo(ctrl.Context, "mount metrics", "route", fmt.Sprintf("GET %s", path))
+	handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		metrics.ServeHTTP(rw, req)
+		return nil
+	}
+	route := &Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "metrics"}
+	return service.mount(route, ctrl.MuxHandler("metrics", handler, nil))
+}
+
 // DecodeRequest uses the HTTP decoder to unmarshal the request body into the provided value based
 // on the request Content-Type header.
 func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
@@ -254,6 +415,9 @@ func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 	defer body.Close()
 
 	if err := service.Decoder.Decode(v, body, contentType); err != nil {
+		if _, ok := err.(ServiceError); ok {
+			return err
+		}
 		return fmt.Errorf("failed to decode request body with content type %#v: %s", contentType, err)
 	}
 
@@ -263,8 +427,45 @@ func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 // EncodeResponse uses the HTTP encoder to marshal and write the response body based on the request
 // Accept header.
 func (service *Service) EncodeResponse(ctx context.Context, v interface{}) error {
-	accept := ContextRequest(ctx).Header.Get("Accept")
-	return service.Encoder.Encode(v, ContextResponse(ctx), accept)
+	contentType, err := service.negotiate(ctx)
+	if err != nil {
+		return err
+	}
+	r := ContextResponse(ctx)
+	if !r.Written() {
+		service.setContentType(r, contentType)
+	}
+	return service.Encoder.encode(v, r, contentType)
+}
+
+// negotiate returns the content type used to encode the response given the request Accept header
+// and the media type declared for the response by the handler via the Content-Type header if any.
+func (service *Service) negotiate(ctx context.Context) (string, error) {
+	var declared string
+	if r := ContextResponse(ctx); r != nil && r.Header() != nil {
+		declared = r.Header().Get("Content-Type")
+	}
+	return service.Encoder.NegotiateFor(ContextRequest(ctx).Header.Get("Accept"), declared)
+}
+
+// setContentType sets the response Content-Type header to the negotiated content type unless
+// the handler already set a compatible value and adds Accept to the Vary header.
+func (service *Service) setContentType(r *ResponseData, contentType string) {
+	h := r.Header()
+	if h == nil {
+		return
+	}
+	if ct := service.Encoder.responseContentType(h.Get("Content-Type"), contentType); ct != "" {
+		h.Set("Content-Type", ct)
+	}
+	for _, v := range h["Vary"] {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, "Accept") {
+				return
+			}
+		}
+	}
+	h.Add("Vary", "Accept")
 }
 
 // ServeFiles replies to the request with the contents of the named file or directory. See
@@ -280,8 +481,8 @@ func (ctrl *Controller) ServeFiles(path, filename string) error {
 		}
 		return nil
 	}
-	ctrl.Service.Mux.Handle("GET", path, ctrl.MuxHandler("serve", handler, nil))
-	return nil
+	route := &Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "serve"}
+	return ctrl.Service.mount(route, ctrl.MuxHandler("serve", handler, nil))
 }
 
 // Use adds a middleware to the controller.
@@ -322,22 +523,27 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 		ctx := NewContext(WithAction(ctrl.Context, name), rw, req, params)
 
 		// Protect against request bodies with unreasonable length
-		if ctrl.MaxRequestBodyLength > 0 {
-			req.Body = http.MaxBytesReader(rw, req.Body, ctrl.MaxRequestBodyLength)
+		var bodyErr error
+		if max := ctrl.maxRequestBodyLength(ctx); max > 0 {
+			if req.ContentLength > max {
+				bodyErr = requestBodyTooLarge(max)
+			}
+			req.Body = &limitedBody{ReadCloser: http.MaxBytesReader(rw, req.Body, max), max: max}
 		}
 
 		// Load body if any
-		if req.ContentLength > 0 && unm != nil {
+		if bodyErr == nil && req.ContentLength > 0 && unm != nil {
 			if err := unm(ctx, ctrl.Service, req); err != nil {
-				if err.Error() == "http: request body too large" {
-					msg := fmt.Sprintf("request body length exceeds %d bytes", ctrl.MaxRequestBodyLength)
-					err = ErrRequestBodyTooLarge(msg)
+				if e, ok := err.(*ErrorResponse); ok && requestBodyErrorCodes[e.Code] {
+					bodyErr = err
 				} else {
-					err = ErrBadRequest(err)
+					bodyErr = ErrBadRequest(err)
 				}
-				ctx = WithError(ctx, err)
 			}
 		}
+		if bodyErr != nil {
+			ctx = WithError(ctx, bodyErr)
+		}
 
 		// Invoke handler
 		if err := handler(ctx, ContextResponse(ctx), req); err != nil {
@@ -348,6 +554,64 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 	}
 }
 
+// maxRequestBodyLength returns the maximum length of the body of the request for the route in
+// ctx, see MaxRequestBodyLengthKey.
+func (ctrl *Controller) maxRequestBodyLength(ctx context.Context) int64 {
+	if route := ContextRoute(ctx); route != nil {
+		if vals := route.Metadata[MaxRequestBodyLengthKey]; len(vals) > 0 {
+			max, err := ParseByteSize(vals[0])
+			if err == nil {
+				return max
+			}
+			LogError(ctx, "invalid max request body length metadata", "route", route.String(), "err", err)
+		}
+	}
+	return ctrl.MaxRequestBodyLength
+}
+
+// ParseByteSize parses a number of bytes optionally followed by one of the KB, MB or GB units,
+// e.g. "512", "64KB" or "10MB". Units are powers of 1024.
+func ParseByteSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(v, u.suffix) {
+			v, mult = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/mult {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	return n * mult, nil
+}
+
+// limitedBody is a request body that produces ErrRequestBodyTooLarge errors when reading past the
+// maximum body length.
+type limitedBody struct {
+	io.ReadCloser
+	max int64
+}
+
+// Read reads from the underlying http.MaxBytesReader and converts its error.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err.Error() == "http: request body too large" {
+		err = requestBodyTooLarge(b.max)
+	}
+	return n, err
+}
+
+// requestBodyTooLarge returns the error produced when a request body exceeds max bytes.
+func requestBodyTooLarge(max int64) error {
+	msg := fmt.Sprintf("request body length exceeds %d bytes", max)
+	return ErrRequestBodyTooLarge(msg, "max_length", max)
+}
+
 // FileHandler returns a handler that serves files under the given filename for the given route path.
 // The logic for what to do when the filename points to a file vs. a directory is the same as the
 // standard http package ServeFile function. The path may end with a wildcard that matches the rest
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"context"
 
 	"sync"
+	"time"
 
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
@@ -114,8 +116,10 @@ var _ = Describe("Service", func() {
 		var rw *TestResponseWriter
 		var req *http.Request
 		var muxHandler goa.MuxHandler
+		var route *goa.Route
 
 		BeforeEach(func() {
+			route = nil
 			body := bytes.NewBuffer([]byte{'"', '2', '3', '4', '"'})
 			req, _ = http.NewRequest("GET", "/foo", body)
 			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
@@ -126,20 +130,78 @@ var _ = Describe("Service", func() {
 				return err
 			}
 			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-				rw.WriteHeader(400)
-				rw.Write([]byte(goa.ContextError(ctx).Error()))
+				if err := goa.ContextError(ctx); err != nil {
+					rw.WriteHeader(400)
+					rw.Write([]byte(err.Error()))
+					return nil
+				}
+				rw.WriteHeader(200)
 				return nil
 			}
 			muxHandler = ctrl.MuxHandler("testMax", handler, unmarshaler)
 		})
 
 		JustBeforeEach(func() {
+			if route != nil {
+				req = req.WithContext(goa.WithRoute(req.Context(), route))
+			}
 			muxHandler(rw, req, nil)
 		})
 
 		It("prevents reading more bytes", func() {
 			Ω(string(rw.Body)).Should(MatchRegexp(`\[.*\] 413 request_too_large: request body length exceeds 4 bytes`))
 		})
+
+		Context("with a content length lower than the body length", func() {
+			BeforeEach(func() {
+				req.ContentLength = 3
+			})
+
+			It("stops reading at the limit", func() {
+				Ω(string(rw.Body)).Should(MatchRegexp(`\[.*\] 413 request_too_large: request body length exceeds 4 bytes`))
+			})
+		})
+
+		Context("with route metadata", func() {
+			Context("raising the limit", func() {
+				BeforeEach(func() {
+					md := map[string][]string{goa.MaxRequestBodyLengthKey: {"1KB"}}
+					route = &goa.Route{Method: "GET", Path: "/foo", Metadata: md}
+				})
+
+				It("reads the body", func() {
+					Ω(rw.Status).Should(Equal(200))
+				})
+			})
+
+			Context("lowering the limit", func() {
+				BeforeEach(func() {
+					md := map[string][]string{goa.MaxRequestBodyLengthKey: {"2"}}
+					route = &goa.Route{Method: "GET", Path: "/foo", Metadata: md}
+				})
+
+				It("uses the route limit", func() {
+					Ω(string(rw.Body)).Should(MatchRegexp(`\[.*\] 413 request_too_large: request body length exceeds 2 bytes`))
+				})
+			})
+		})
+	})
+
+	Describe("ParseByteSize", func() {
+		It("parses sizes with and without units", func() {
+			for s, expected := range map[string]int64{"0": 0, "512": 512, "12B": 12, "64KB": 64 << 10, "10 mb": 10 << 20, "1GB": 1 << 30} {
+				n, err := goa.ParseByteSize(s)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(n).Should(Equal(expected), s)
+			}
+		})
+
+		It("rejects invalid sizes", func() {
+			for _, s := range []string{"", "MB", "-1", "-1KB", "10TB", "1.5MB", "8589934592GB", "9223372036854775807KB"} {
+				_, err := goa.ParseByteSize(s)
+				Ω(err).Should(HaveOccurred(), s)
+			}
+		})
 	})
 
 	Describe("MuxHandler", func() {
@@ -362,6 +424,23 @@ var _ = Describe("Service", func() {
 					})
 				})
 
+				Context("with a decoder that disallows unknown fields", func() {
+					BeforeEach(func() {
+						s.Decoder.DisallowUnknownFields = true
+						unmarshaler = func(c context.Context, service *goa.Service, req *http.Request) error {
+							var payload struct {
+								Name string `json:"name"`
+							}
+							return service.DecodeRequest(req, &payload)
+						}
+					})
+
+					It("rejects the request with the field path", func() {
+						Ω(rw.(*TestResponseWriter).Status).Should(Equal(400))
+						Ω(string(rw.(*TestResponseWriter).Body)).Should(ContainSubstring(`400 unknown_field: unknown field "hello"`))
+					})
+				})
+
 				Context("with a Content-Type of 'application/octet-stream' or any other and no default decoder", func() {
 					BeforeEach(func() {
 						s = goa.New("test")
@@ -377,6 +456,175 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Describe("Send", func() {
+		var rw *TestResponseWriter
+		var ctx context.Context
+		var accept string
+		var code int
+		var body interface{}
+		var err error
+
+		BeforeEach(func() {
+			s.Encoder.Register(goa.NewXMLEncoder, "application/xml")
+			s.Encoder.Register(goa.NewJSONEncoder, "application/json")
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			code = 200
+			body = "ok"
+		})
+
+		JustBeforeEach(func() {
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			req.Header.Set("Accept", accept)
+			ctx = goa.NewContext(context.Background(), rw, req, nil)
+			err = s.Send(ctx, code, body)
+		})
+
+		Context("with an acceptable content type", func() {
+			BeforeEach(func() {
+				accept = "application/xml;q=0.5, application/json"
+			})
+
+			It("sets the Content-Type and Vary headers", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(rw.Status).Should(Equal(200))
+				Ω(rw.Header().Get("Content-Type")).Should(Equal("application/json"))
+				Ω(rw.Header().Get("Vary")).Should(Equal("Accept"))
+				Ω(string(rw.Body)).Should(Equal("\"ok\"\n"))
+			})
+		})
+
+		Context("with a compatible Content-Type set by the handler", func() {
+			BeforeEach(func() {
+				accept = "application/json"
+				rw.Header().Set("Content-Type", "application/vnd.goa.example+json")
+				rw.Header().Set("Vary", "Origin, Accept")
+			})
+
+			It("keeps the headers", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(rw.Header().Get("Content-Type")).Should(Equal("application/vnd.goa.example+json"))
+				Ω(rw.Header()["Vary"]).Should(Equal([]string{"Origin, Accept"}))
+			})
+		})
+
+		Context("with an incompatible Content-Type set by the handler", func() {
+			BeforeEach(func() {
+				accept = "application/xml"
+				rw.Header().Set("Content-Type", "application/vnd.goa.example+json")
+			})
+
+			It("overrides the Content-Type header", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(rw.Header().Get("Content-Type")).Should(Equal("application/xml"))
+				Ω(string(rw.Body)).Should(ContainSubstring("<string>ok</string>"))
+			})
+		})
+
+		Context("with no acceptable content type", func() {
+			BeforeEach(func() {
+				accept = "text/html"
+			})
+
+			It("returns a not acceptable error without writing the response", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(406))
+				Ω(rw.Status).Should(Equal(0))
+			})
+
+			Context("and an error response", func() {
+				BeforeEach(func() {
+					code = 406
+					body = goa.ErrNotAcceptable("not acceptable")
+				})
+
+				It("writes the response using the default encoder", func() {
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(rw.Status).Should(Equal(406))
+					Ω(string(rw.Body)).Should(ContainSubstring(`"code":"not_acceptable"`))
+				})
+			})
+		})
+	})
+
+	Describe("Shutdown", func() {
+		var (
+			listener net.Listener
+			serveErr chan error
+			started  chan struct{}
+			release  chan struct{}
+			hooks    []string
+		)
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Ω(err).ShouldNot(HaveOccurred())
+			serveErr = make(chan error, 1)
+			started = make(chan struct{})
+			release = make(chan struct{})
+			hooks = nil
+			ctrl := s.NewController("test")
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				close(started)
+				<-release
+				return s.Send(ctx, 200, "done")
+			}
+			s.Mux.Handle("GET", "/slow", ctrl.MuxHandler("slow", handler, nil))
+			s.OnShutdown(func(context.Context) error { hooks = append(hooks, "first"); return nil })
+			s.OnShutdown(func(context.Context) error { hooks = append(hooks, "second"); return nil })
+			go func() { serveErr <- s.Serve(listener) }()
+		})
+
+		It("drains in-flight requests and runs the hooks", func() {
+			respCh := make(chan *http.Response, 1)
+			go func() {
+				defer GinkgoRecover()
+				resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+				Ω(err).ShouldNot(HaveOccurred())
+				respCh <- resp
+			}()
+			Eventually(started).Should(BeClosed())
+
+			shutdownErr := make(chan error, 1)
+			go func() { shutdownErr <- s.Shutdown(context.Background()) }()
+			Eventually(serveErr).Should(Receive(Equal(http.ErrServerClosed)))
+			Consistently(shutdownErr, 50*time.Millisecond).ShouldNot(Receive())
+
+			close(release)
+			var resp *http.Response
+			Eventually(respCh).Should(Receive(&resp))
+			Ω(resp.StatusCode).Should(Equal(200))
+			resp.Body.Close()
+			Eventually(shutdownErr).Should(Receive(BeNil()))
+			Ω(hooks).Should(Equal([]string{"second", "first"}))
+			Ω(s.Context.Err()).Should(Equal(context.Canceled))
+		})
+
+		It("stops waiting when the context is done", func() {
+			go http.Get("http://" + listener.Addr().String() + "/slow")
+			Eventually(started).Should(BeClosed())
+			defer close(release)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			Ω(s.Shutdown(ctx)).Should(Equal(context.DeadlineExceeded))
+			Ω(hooks).Should(Equal([]string{"second", "first"}))
+		})
+
+		It("gives the hooks a new context when the context is done", func() {
+			var hookErr error
+			s.OnShutdown(func(ctx context.Context) error { hookErr = ctx.Err(); return nil })
+			go http.Get("http://" + listener.Addr().String() + "/slow")
+			Eventually(started).Should(BeClosed())
+			defer close(release)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			Ω(s.Shutdown(ctx)).Should(Equal(context.DeadlineExceeded))
+			Ω(hookErr).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Describe("FileHandler", func() {
 		const publicPath = "github.com/goadesign/goa/public"
 
@@ -0,0 +1,131 @@
+package goa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventStreamContentType is the content type of Server-Sent Events streams.
+const EventStreamContentType = "text/event-stream"
+
+// EventStreamHeartbeat is the interval at which event streams write a comment line to keep idle
+// connections from being closed by proxies. A value of 0 disables heartbeats.
+var EventStreamHeartbeat = 15 * time.Second
+
+// errEventStreamClosed is returned when sending events on a closed stream.
+var errEventStreamClosed = errors.New("event stream closed")
+
+// EventStream writes Server-Sent Events to a HTTP response, see
+// https://html.spec.whatwg.org/multipage/server-sent-events.html.
+type EventStream struct {
+	rw     *ResponseData
+	lock   sync.Mutex
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewEventStream writes the event stream response headers using the given status code and starts
+// sending heartbeats until ctx is done or the stream is closed.
+func NewEventStream(ctx context.Context, rw *ResponseData, status int) *EventStream {
+	h := rw.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", EventStreamContentType)
+	}
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(status)
+	rw.Flush()
+	s := &EventStream{rw: rw, done: make(chan struct{})}
+	if EventStreamHeartbeat > 0 {
+		s.wg.Add(1)
+		go s.heartbeat(ctx, EventStreamHeartbeat)
+	}
+	return s
+}
+
+// Send sends an unnamed event with the given data. See SendEvent.
+func (s *EventStream) Send(v interface{}) error {
+	return s.SendEvent("", "", v)
+}
+
+// SendEvent sends an event with the given id, name and data. id and name may be empty in which
+// case the corresponding fields are omitted. data is written as is if it is a string or a byte
+// slice and encoded into JSON otherwise.
+func (s *EventStream) SendEvent(id, name string, data interface{}) error {
+	var b []byte
+	switch actual := data.(type) {
+	case []byte:
+		b = actual
+	case string:
+		b = []byte(actual)
+	default:
+		var err error
+		if b, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	if name != "" {
+		fmt.Fprintf(&buf, "event: %s\n", name)
+	}
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Close stops the heartbeats. Events cannot be sent once the stream is closed.
+func (s *EventStream) Close() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+// heartbeat writes a comment line at each interval until ctx is done or the stream is closed.
+func (s *EventStream) heartbeat(ctx context.Context, interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.write([]byte(":\n\n")); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// write writes b to the response and flushes it.
+func (s *EventStream) write(b []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return errEventStreamClosed
+	}
+	if _, err := s.rw.Write(b); err != nil {
+		return err
+	}
+	s.rw.Flush()
+	return nil
+}
@@ -0,0 +1,69 @@
+package goa_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventStream", func() {
+	var rw *httptest.ResponseRecorder
+	var ctx context.Context
+	var heartbeat time.Duration
+	var stream *goa.EventStream
+
+	BeforeEach(func() {
+		rw = httptest.NewRecorder()
+		ctx = goa.NewContext(context.Background(), rw, httptest.NewRequest("GET", "/", nil), nil)
+		heartbeat = goa.EventStreamHeartbeat
+		goa.EventStreamHeartbeat = 0
+	})
+
+	JustBeforeEach(func() {
+		stream = goa.NewEventStream(ctx, goa.ContextResponse(ctx), 200)
+	})
+
+	AfterEach(func() {
+		stream.Close()
+		goa.EventStreamHeartbeat = heartbeat
+	})
+
+	It("writes the response headers", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal(goa.EventStreamContentType))
+		Ω(rw.Header().Get("Cache-Control")).Should(Equal("no-cache"))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+
+	It("sends JSON encoded events", func() {
+		Ω(stream.Send(map[string]string{"name": "foo"})).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("data: {\"name\":\"foo\"}\n\n"))
+	})
+
+	It("sends named events with ids and multiline data", func() {
+		Ω(stream.SendEvent("1", "update", "foo\nbar")).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("id: 1\nevent: update\ndata: foo\ndata: bar\n\n"))
+	})
+
+	It("fails to send events once closed", func() {
+		stream.Close()
+		Ω(stream.Send("foo")).Should(HaveOccurred())
+		Ω(rw.Body.String()).Should(BeEmpty())
+	})
+
+	Context("with heartbeats", func() {
+		BeforeEach(func() {
+			goa.EventStreamHeartbeat = time.Millisecond
+		})
+
+		It("sends comment lines", func() {
+			time.Sleep(20 * time.Millisecond)
+			stream.Close()
+			Ω(rw.Body.String()).Should(HavePrefix(":\n\n"))
+		})
+	})
+})
@@ -0,0 +1,80 @@
+package goa
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// NDJSONContentType is the content type of newline delimited JSON streams.
+const NDJSONContentType = "application/x-ndjson"
+
+type (
+	// NDJSONDecoder decodes the values of a newline delimited JSON stream.
+	NDJSONDecoder struct {
+		dec *json.Decoder
+	}
+
+	// NDJSONEncoder writes newline delimited JSON values to a stream. It flushes the
+	// underlying writer after each value if it implements http.Flusher.
+	NDJSONEncoder struct {
+		w   io.Writer
+		enc *json.Encoder
+	}
+
+	// flushWriter is a writer that flushes the underlying writer after each write.
+	flushWriter struct {
+		io.Writer
+	}
+)
+
+// NewNDJSONDecoder returns a decoder that reads newline delimited JSON values from r.
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	return &NDJSONDecoder{dec: json.NewDecoder(r)}
+}
+
+// Decode decodes the next value of the stream into v. It returns io.EOF once the stream is
+// exhausted and a bad request error if the value cannot be decoded.
+func (d *NDJSONDecoder) Decode(v interface{}) error {
+	if err := d.dec.Decode(v); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return ErrBadRequest(err)
+	}
+	return nil
+}
+
+// NewNDJSONEncoder returns an encoder that writes newline delimited JSON values to w.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{w: w, enc: json.NewEncoder(w)}
+}
+
+// Encode writes the JSON encoding of v followed by a newline and flushes the stream.
+func (e *NDJSONEncoder) Encode(v interface{}) error {
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	flush(e.w)
+	return nil
+}
+
+// NewFlushWriter returns a writer that flushes w after each write if it implements
+// http.Flusher.
+func NewFlushWriter(w io.Writer) io.Writer {
+	return &flushWriter{Writer: w}
+}
+
+// Write writes b and flushes the underlying writer.
+func (w *flushWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	flush(w.Writer)
+	return n, err
+}
+
+// flush flushes w if it implements http.Flusher.
+func flush(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,79 @@
+package goa_test
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NDJSONDecoder", func() {
+	var body string
+	var dec *goa.NDJSONDecoder
+
+	JustBeforeEach(func() {
+		dec = goa.NewNDJSONDecoder(strings.NewReader(body))
+	})
+
+	Context("with a valid stream", func() {
+		BeforeEach(func() {
+			body = "{\"name\":\"foo\"}\n{\"name\":\"bar\"}\n"
+		})
+
+		It("decodes each value then returns io.EOF", func() {
+			var v struct{ Name string }
+			Ω(dec.Decode(&v)).ShouldNot(HaveOccurred())
+			Ω(v.Name).Should(Equal("foo"))
+			Ω(dec.Decode(&v)).ShouldNot(HaveOccurred())
+			Ω(v.Name).Should(Equal("bar"))
+			Ω(dec.Decode(&v)).Should(Equal(io.EOF))
+		})
+	})
+
+	Context("with an invalid value", func() {
+		BeforeEach(func() {
+			body = "{\"name\":\n"
+		})
+
+		It("returns a bad request error", func() {
+			var v struct{ Name string }
+			err := dec.Decode(&v)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(400))
+		})
+	})
+})
+
+var _ = Describe("NDJSONEncoder", func() {
+	It("writes newline delimited values and flushes", func() {
+		rw := httptest.NewRecorder()
+		enc := goa.NewNDJSONEncoder(rw)
+		Ω(enc.Encode(map[string]int{"a": 1})).ShouldNot(HaveOccurred())
+		Ω(enc.Encode(map[string]int{"a": 2})).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("{\"a\":1}\n{\"a\":2}\n"))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+})
+
+var _ = Describe("NewFlushWriter", func() {
+	It("flushes after each write", func() {
+		rw := httptest.NewRecorder()
+		w := goa.NewFlushWriter(rw)
+		n, err := w.Write([]byte("foo"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(Equal(3))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+
+	It("writes to writers that cannot flush", func() {
+		var buf bytes.Buffer
+		w := goa.NewFlushWriter(&buf)
+		_, err := w.Write([]byte("foo"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("foo"))
+	})
+})